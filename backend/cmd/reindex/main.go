@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/m4rk1sov/ecommerce/config"
+	"github.com/m4rk1sov/ecommerce/internal/repository/fulltext"
+	mongorepo "github.com/m4rk1sov/ecommerce/internal/repository/mongodb"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"github.com/m4rk1sov/ecommerce/pkg/logger"
+)
+
+// reindex rebuilds the embedded search index in SEARCH_INDEX_DIR from the products collection.
+// The index files are not shared, run it while the app is stopped
+func main() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Fatalf("Config error: %s", err)
+	}
+
+	l, err := logger.New(cfg.Log.Level, cfg.App.Env)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v\n", err)
+	}
+	defer func() {
+		_ = logger.Close(l)
+	}()
+
+	mongoClient, mdb := mongorepo.InitMongoDB(l, cfg)
+	defer func() {
+		_ = mongorepo.Close(mongoClient, context.Background())
+	}()
+
+	index, err := fulltext.Open(cfg.Search.IndexDir)
+	if err != nil {
+		l.Fatalf("failed to open search index: %v", err)
+	}
+	defer func() {
+		if err := index.Close(); err != nil {
+			l.Errorf("failed to close search index: %v", err)
+		}
+	}()
+
+	indexed, err := usecase.ReindexProducts(context.Background(), mongorepo.NewProductRepository(mdb), index)
+	if err != nil {
+		l.Errorf("reindex failed: %v", err)
+		return
+	}
+	l.Infow("Search index rebuilt", "dir", cfg.Search.IndexDir, "products", indexed)
+}
@@ -10,15 +10,25 @@ import (
 
 type (
 	Config struct {
-		App         App
-		HTTP        HTTP
-		Log         Log
-		MongoDB     MongoDB
-		Redis       Redis
-		Neo4j       Neo4j
-		JWT         JWT
-		Swagger     Swagger
-		Interaction Interaction
+		App          App
+		HTTP         HTTP
+		Log          Log
+		MongoDB      MongoDB
+		Redis        Redis
+		Neo4j        Neo4j
+		JWT          JWT
+		Swagger      Swagger
+		Interaction  Interaction
+		Catalog      Catalog
+		Media        Media
+		CartRecovery CartRecovery
+		ProductPurge ProductPurge
+		Suggest      Suggest
+		Search       Search
+		Currency     Currency
+		PriceAlert   PriceAlert
+		Sale         Sale
+		Locale       Locale
 	}
 
 	App struct {
@@ -56,8 +66,11 @@ type (
 	}
 
 	JWT struct {
-		Secret     string        `env:"JWT_SECRET,required"`
-		Expiration time.Duration `env:"JWT_EXPIRATION,required"`
+		Secret          string        `env:"JWT_SECRET,required"`
+		Expiration      time.Duration `env:"JWT_EXPIRATION,required"`
+		GuestExpiration time.Duration `env:"JWT_GUEST_EXPIRATION" envDefault:"720h"`
+		// GuestClaimExpiration - how long an emailed guest claim token can be redeemed
+		GuestClaimExpiration time.Duration `env:"JWT_GUEST_CLAIM_EXPIRATION" envDefault:"24h"`
 	}
 	Swagger struct {
 		Enabled bool `env:"SWAGGER_ENABLED" envDefault:"false"`
@@ -67,6 +80,70 @@ type (
 		CacheTTL        int `env:"RECOMMENDATION_CACHE_TTL,required"`
 		MinInteractions int `env:"MIN_INTERACTIONS_FOR_RECOMMENDATION,required"`
 	}
+
+	Catalog struct {
+		CacheTTL int `env:"PRODUCT_CACHE_TTL" envDefault:"600"`
+	}
+
+	Media struct {
+		Dir           string `env:"MEDIA_DIR" envDefault:"./uploads"`
+		BaseURL       string `env:"MEDIA_BASE_URL" envDefault:"/media"`
+		MaxUploadSize int64  `env:"MEDIA_MAX_UPLOAD_SIZE" envDefault:"10485760"`
+		ThumbnailSize int    `env:"MEDIA_THUMBNAIL_SIZE" envDefault:"320"`
+	}
+
+	CartRecovery struct {
+		Enabled             bool          `env:"CART_RECOVERY_ENABLED" envDefault:"true"`
+		Interval            time.Duration `env:"CART_RECOVERY_INTERVAL" envDefault:"1h"`
+		AbandonWindow       time.Duration `env:"CART_ABANDON_WINDOW" envDefault:"24h"`
+		Lookback            time.Duration `env:"CART_ABANDON_LOOKBACK" envDefault:"168h"`
+		AttributionWindow   time.Duration `env:"CART_RECOVERY_ATTRIBUTION_WINDOW" envDefault:"168h"`
+		RecommendationCount int           `env:"CART_RECOVERY_RECOMMENDATIONS" envDefault:"4"`
+	}
+
+	ProductPurge struct {
+		Enabled   bool          `env:"PRODUCT_PURGE_ENABLED" envDefault:"true"`
+		Interval  time.Duration `env:"PRODUCT_PURGE_INTERVAL" envDefault:"24h"`
+		Retention time.Duration `env:"PRODUCT_ARCHIVE_RETENTION" envDefault:"720h"`
+	}
+
+	Search struct {
+		// Backend - mongo (text index of the products collection) or embedded (BM25 index on disk)
+		Backend           string        `env:"SEARCH_BACKEND" envDefault:"mongo"`
+		IndexDir          string        `env:"SEARCH_INDEX_DIR" envDefault:"./data/search"`
+		FuzzyMaxEdits     int           `env:"SEARCH_FUZZY_MAX_EDITS" envDefault:"2"`
+		VocabularyRefresh time.Duration `env:"SEARCH_VOCABULARY_REFRESH" envDefault:"1h"`
+	}
+
+	Suggest struct {
+		RebuildInterval  time.Duration `env:"SUGGEST_REBUILD_INTERVAL" envDefault:"1h"`
+		PopularityWindow time.Duration `env:"SUGGEST_POPULARITY_WINDOW" envDefault:"720h"`
+		MaxQueries       int           `env:"SUGGEST_MAX_QUERIES" envDefault:"10000"`
+	}
+
+	PriceAlert struct {
+		Enabled  bool          `env:"PRICE_ALERTS_ENABLED" envDefault:"true"`
+		Interval time.Duration `env:"PRICE_ALERT_INTERVAL" envDefault:"5m"`
+	}
+
+	Sale struct {
+		// PriceRefresh - how soon search and price alerts see a sale start or end
+		PriceRefresh time.Duration `env:"SALE_PRICE_REFRESH" envDefault:"1m"`
+	}
+
+	Currency struct {
+		// Base - prices are stored in this currency, it must have two decimals
+		Base         string        `env:"BASE_CURRENCY" envDefault:"USD"`
+		RatesFile    string        `env:"CURRENCY_RATES_FILE"`
+		RatesRefresh time.Duration `env:"CURRENCY_RATES_REFRESH" envDefault:"5m"`
+	}
+
+	Locale struct {
+		// Default - the locale product names and descriptions are written in
+		Default string `env:"DEFAULT_LOCALE" envDefault:"en"`
+		// Supported - further locales products and categories may be translated to, e.g. "de,fr,pt-BR"
+		Supported []string `env:"SUPPORTED_LOCALES" envSeparator:","`
+	}
 )
 
 func NewConfig() (*Config, error) {
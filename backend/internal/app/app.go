@@ -13,12 +13,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/m4rk1sov/ecommerce/config"
 	v1 "github.com/m4rk1sov/ecommerce/internal/controller/http/v1"
+	"github.com/m4rk1sov/ecommerce/internal/notifier"
+	"github.com/m4rk1sov/ecommerce/internal/repository/filesystem"
+	"github.com/m4rk1sov/ecommerce/internal/repository/fulltext"
 	mongorepo "github.com/m4rk1sov/ecommerce/internal/repository/mongodb"
 	neo4jrepo "github.com/m4rk1sov/ecommerce/internal/repository/neo4j"
 	redisrepo "github.com/m4rk1sov/ecommerce/internal/repository/redis"
 	"github.com/m4rk1sov/ecommerce/internal/usecase"
 	"github.com/m4rk1sov/ecommerce/pkg/httpserver"
 	"github.com/m4rk1sov/ecommerce/pkg/logger"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"github.com/m4rk1sov/ecommerce/pkg/scheduler"
 	"go.uber.org/zap"
 )
 
@@ -69,22 +74,128 @@ func Run(cfg *config.Config) {
 		}
 	}()
 
+	blobStore, err := filesystem.NewBlobStore(cfg.Media.Dir, cfg.Media.BaseURL)
+	if err != nil {
+		l.Fatalf("failed to init media storage: %v", err)
+	}
+
 	// Repositories
 	userRepo := mongorepo.NewUserRepository(mdb)
 	productRepo := mongorepo.NewProductRepository(mdb)
 	interactionRepo := mongorepo.NewInteractionRepository(mdb)
 	cacheRepo := redisrepo.NewCacheRepository(redisClient)
 	sessionRepo := redisrepo.NewSessionRepository(redisClient)
+	suggestRepo := redisrepo.NewSuggestRepository(redisClient)
 	graphRepo := neo4jrepo.NewGraphRepository(neo4jDriver)
+	wishlistRepo := mongorepo.NewWishlistRepository(mdb)
+	guestRepo := mongorepo.NewGuestRepository(mdb)
+	cartReminderRepo := mongorepo.NewCartReminderRepository(mdb)
+	importJobRepo := mongorepo.NewImportJobRepository(mdb)
+	reviewRepo := mongorepo.NewReviewRepository(mdb)
+	categoryRepo := mongorepo.NewCategoryRepository(mdb)
+	productVersionRepo := mongorepo.NewProductVersionRepository(mdb)
+	synonymRepo := mongorepo.NewSynonymRepository(mdb)
+	searchRuleRepo := mongorepo.NewSearchRuleRepository(mdb)
+	searchLogRepo := mongorepo.NewSearchLogRepository(mdb)
+	exchangeRateRepo := mongorepo.NewExchangeRateRepository(mdb)
+	priceHistoryRepo := mongorepo.NewPriceHistoryRepository(mdb)
+	priceWatchRepo := mongorepo.NewPriceWatchRepository(mdb)
+
+	baseCurrency, ok := money.LookupCurrency(cfg.Currency.Base)
+	if !ok || baseCurrency.Decimals != money.BaseDecimals {
+		l.Fatalf("unsupported base currency %q, it must be a known currency with %d decimals", cfg.Currency.Base, money.BaseDecimals)
+	}
+	localeUC, err := usecase.NewLocaleUseCase(cfg.Locale.Default, cfg.Locale.Supported)
+	if err != nil {
+		l.Fatalf("failed to configure locales: %v", err)
+	}
+
+	var searchIndex usecase.SearchIndex
+	var fulltextIndex *fulltext.Index
+	switch cfg.Search.Backend {
+	case "mongo":
+		searchIndex = mongorepo.NewProductSearchIndex(productRepo)
+	case "embedded":
+		fulltextIndex, err = fulltext.Open(cfg.Search.IndexDir)
+		if err != nil {
+			l.Fatalf("failed to open search index: %v", err)
+		}
+		defer func() {
+			closeErr := fulltextIndex.Close()
+			if closeErr != nil {
+				err = errors.Join(err, closeErr)
+				l.Errorf("failed to close search index: %v\n", err)
+			}
+		}()
+		searchIndex = fulltextIndex
+	default:
+		l.Fatalf("unknown search backend %q, use mongo or embedded", cfg.Search.Backend)
+	}
 
 	// Use cases
-	userUC := usecase.NewUserUseCase(userRepo, sessionRepo, cfg.JWT.Secret, cfg.JWT.Expiration)
-	productUC := usecase.NewProductUseCase(productRepo, cacheRepo)
-	interactionUC := usecase.NewInteractionUseCase(interactionRepo, graphRepo)
+	logNotifier := notifier.NewLogNotifier(l)
+	guestUC := usecase.NewGuestUseCase(
+		guestRepo,
+		interactionRepo,
+		graphRepo,
+		cacheRepo,
+		logNotifier,
+		cfg.JWT.Secret,
+		cfg.JWT.GuestExpiration,
+		cfg.JWT.GuestClaimExpiration,
+	)
+	userUC := usecase.NewUserUseCase(userRepo, sessionRepo, guestUC, l, cfg.JWT.Secret, cfg.JWT.Expiration)
+	productUC := usecase.NewProductUseCase(
+		productRepo,
+		categoryRepo,
+		productVersionRepo,
+		priceHistoryRepo,
+		suggestRepo,
+		searchIndex,
+		cacheRepo,
+		cfg.Catalog.CacheTTL,
+		cfg.Search.FuzzyMaxEdits,
+	)
+	catalogUC := usecase.NewCatalogUseCase(productRepo, productUC, importJobRepo, l)
+	// Before the server starts, so imports started by this run are never touched
+	if failed, err := catalogUC.FailInterruptedImports(context.Background()); err != nil {
+		l.Errorw("Failed to mark interrupted catalog imports as failed", "error", err)
+	} else if failed > 0 {
+		l.Infow("Interrupted catalog imports marked as failed", "jobs", failed)
+	}
+	categoryUC := usecase.NewCategoryUseCase(categoryRepo, productRepo, productUC)
+	productImageUC := usecase.NewProductImageUseCase(productRepo, productUC, blobStore, cfg.Media.MaxUploadSize, cfg.Media.ThumbnailSize)
+	interactionUC := usecase.NewInteractionUseCase(interactionRepo, graphRepo, productRepo, productUC, l)
+	wishlistUC := usecase.NewWishlistUseCase(wishlistRepo, productRepo, interactionUC)
+	reviewUC := usecase.NewReviewUseCase(reviewRepo, productUC, interactionRepo, interactionUC)
+	suggestUC := usecase.NewSuggestUseCase(
+		suggestRepo,
+		productRepo,
+		categoryRepo,
+		interactionRepo,
+		cfg.Suggest.PopularityWindow,
+		cfg.Suggest.MaxQueries,
+	)
+	merchandisingUC := usecase.NewMerchandisingUseCase(synonymRepo, searchRuleRepo, productUC)
+	searchAnalyticsUC := usecase.NewSearchAnalyticsUseCase(searchLogRepo, interactionUC, productUC)
+	currencyUC := usecase.NewCurrencyUseCase(exchangeRateRepo, baseCurrency, cfg.Currency.RatesFile)
+	translationUC := usecase.NewTranslationUseCase(productUC, productRepo, categoryRepo, localeUC)
+	productPurgeUC := usecase.NewProductPurgeUseCase(
+		productRepo,
+		productVersionRepo,
+		priceHistoryRepo,
+		priceWatchRepo,
+		productUC,
+		graphRepo,
+		blobStore,
+		cfg.ProductPurge.Retention,
+	)
 
 	recommendationUC := usecase.NewRecommendationUseCase(
 		userRepo,
 		productRepo,
+		productUC,
+		categoryRepo,
 		interactionRepo,
 		cacheRepo,
 		graphRepo,
@@ -92,6 +203,127 @@ func Run(cfg *config.Config) {
 		cfg.Interaction.MinInteractions,
 	)
 
+	cartRecoveryUC := usecase.NewCartRecoveryUseCase(
+		interactionRepo,
+		cartReminderRepo,
+		userRepo,
+		productRepo,
+		recommendationUC,
+		logNotifier,
+		l,
+		cfg.CartRecovery.AbandonWindow,
+		cfg.CartRecovery.Lookback,
+		cfg.CartRecovery.AttributionWindow,
+		cfg.CartRecovery.RecommendationCount,
+	)
+
+	priceAlertUC := usecase.NewPriceAlertUseCase(
+		priceWatchRepo,
+		priceHistoryRepo,
+		productUC,
+		userRepo,
+		wishlistRepo,
+		interactionRepo,
+		logNotifier,
+		l,
+	)
+
+	// Background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := scheduler.New(l)
+	if cfg.CartRecovery.Enabled {
+		jobs.Every(jobsCtx, "cart-recovery", cfg.CartRecovery.Interval, func(ctx context.Context) error {
+			run, err := cartRecoveryUC.Run(ctx)
+			if err != nil {
+				return err
+			}
+			l.Infow("Cart recovery run", "reminders_sent", run.RemindersSent, "failed", run.Failed, "recovered", run.Recovered)
+			return nil
+		})
+	}
+	if cfg.PriceAlert.Enabled {
+		jobs.Every(jobsCtx, "price-alerts", cfg.PriceAlert.Interval, func(ctx context.Context) error {
+			run, err := priceAlertUC.Run(ctx)
+			if err != nil {
+				return err
+			}
+			l.Infow("Price alerts run", "changes", run.Changes, "alerts_sent", run.AlertsSent, "failed", run.Failed)
+			return nil
+		})
+	}
+	// The first run also stores the current prices of products written before they were kept
+	refreshPrices := func(ctx context.Context) error {
+		repriced, err := productUC.RefreshPrices(ctx)
+		if err != nil {
+			return err
+		}
+		if repriced > 0 {
+			l.Infow("Sale prices refreshed", "repriced", repriced)
+		}
+		return nil
+	}
+	jobs.Once(jobsCtx, "sale-prices", refreshPrices)
+	jobs.Every(jobsCtx, "sale-prices", cfg.Sale.PriceRefresh, refreshPrices)
+	// A fresh embedded index is filled from the catalog, later writes keep it in sync
+	if fulltextIndex != nil && fulltextIndex.Len() == 0 {
+		jobs.Once(jobsCtx, "search-reindex", func(ctx context.Context) error {
+			indexed, err := productUC.Reindex(ctx)
+			if err != nil {
+				return err
+			}
+			l.Infow("Search index rebuilt", "products", indexed)
+			return nil
+		})
+	}
+	refreshVocabulary := func(ctx context.Context) error {
+		words, err := productUC.RefreshVocabulary(ctx)
+		if err != nil {
+			return err
+		}
+		l.Infow("Search vocabulary refreshed", "words", words)
+		return nil
+	}
+	jobs.Once(jobsCtx, "search-vocabulary", refreshVocabulary)
+	jobs.Every(jobsCtx, "search-vocabulary", cfg.Search.VocabularyRefresh, refreshVocabulary)
+
+	rebuildSuggestions := func(ctx context.Context) error {
+		terms, err := suggestUC.Rebuild(ctx)
+		if err != nil {
+			return err
+		}
+		l.Infow("Search suggestions rebuilt", "terms", terms)
+		return nil
+	}
+	jobs.Once(jobsCtx, "suggest-rebuild", rebuildSuggestions)
+	jobs.Every(jobsCtx, "suggest-rebuild", cfg.Suggest.RebuildInterval, rebuildSuggestions)
+	refreshRates := func(ctx context.Context) error {
+		_, err := currencyUC.Refresh(ctx)
+		return err
+	}
+	// Rates from the file replace those set through the API for the same currencies on every start
+	jobs.Once(jobsCtx, "currency-rates", func(ctx context.Context) error {
+		if cfg.Currency.RatesFile != "" {
+			rates, err := currencyUC.LoadFile(ctx)
+			if err != nil {
+				return err
+			}
+			l.Infow("Exchange rates loaded", "file", cfg.Currency.RatesFile, "rates", len(rates.Rates))
+			return nil
+		}
+		return refreshRates(ctx)
+	})
+	jobs.Every(jobsCtx, "currency-rates", cfg.Currency.RatesRefresh, refreshRates)
+	if cfg.ProductPurge.Enabled {
+		jobs.Every(jobsCtx, "product-purge", cfg.ProductPurge.Interval, func(ctx context.Context) error {
+			purged, err := productPurgeUC.Run(ctx)
+			if err != nil {
+				return err
+			}
+			l.Infow("Archived products purged", "purged", purged)
+			return nil
+		})
+	}
+
 	// HTTP
 	//r := gin.Default()
 	gin.SetMode(gin.ReleaseMode)
@@ -99,14 +331,30 @@ func Run(cfg *config.Config) {
 
 	// Inject middleware with cfg secret
 	authMw := v1.AuthMiddleware(cfg.JWT.Secret)
+	guestAuthMw := v1.GuestOrAuthMiddleware(cfg.JWT.Secret, guestUC)
 
 	// Build v1 routes
 	v1.NewRouterWithMiddleware(l, router, &v1.UseCases{
-		User:           userUC,
-		Product:        productUC,
-		Interaction:    interactionUC,
-		Recommendation: recommendationUC,
-	}, authMw)
+		User:            userUC,
+		Product:         productUC,
+		Interaction:     interactionUC,
+		Recommendation:  recommendationUC,
+		Wishlist:        wishlistUC,
+		Guest:           guestUC,
+		CartRecovery:    cartRecoveryUC,
+		Catalog:         catalogUC,
+		Review:          reviewUC,
+		Category:        categoryUC,
+		ProductImage:    productImageUC,
+		ProductPurge:    productPurgeUC,
+		Suggest:         suggestUC,
+		Merchandising:   merchandisingUC,
+		SearchAnalytics: searchAnalyticsUC,
+		Currency:        currencyUC,
+		PriceAlert:      priceAlertUC,
+		Locale:          localeUC,
+		Translation:     translationUC,
+	}, authMw, guestAuthMw)
 
 	srv := httpserver.New(router, cfg.HTTP.Port)
 	l.Infow("HTTP server starting", "port", cfg.HTTP.Port)
@@ -130,5 +378,8 @@ func Run(cfg *config.Config) {
 	if err = srv.Shutdown(shutdownCtx); err != nil {
 		l.Errorw("http shutdown error", "error", err)
 	}
+
+	stopJobs()
+	jobs.Wait()
 	l.Info("Server stopped")
 }
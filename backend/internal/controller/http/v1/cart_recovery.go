@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+)
+
+func GetCartRecoveryStats(uc *usecase.CartRecoveryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+		if err != nil || days < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+
+		since := time.Now().AddDate(0, 0, -days)
+		stats, err := uc.GetStats(c.Request.Context(), since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+func RunCartRecovery(uc *usecase.CartRecoveryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, err := uc.Run(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, run)
+	}
+}
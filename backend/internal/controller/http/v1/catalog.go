@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const maxImportSize = 32 << 20
+
+// ImportCatalog accepts a CSV or JSONL file either as the multipart "file" field or as the raw body.
+// The format comes from ?format= or the file extension
+func ImportCatalog(uc *usecase.CatalogUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+		format := c.Query("format")
+		var data []byte
+		if file, header, err := c.Request.FormFile("file"); err == nil {
+			defer file.Close()
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(header.Filename), ".")
+			}
+			data, err = io.ReadAll(file)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		} else {
+			data, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		catalogFormat, err := usecase.ParseCatalogFormat(format)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		job, err := uc.StartImport(c.Request.Context(), getUserIDFromContext(c), catalogFormat, data)
+		if err != nil {
+			writeCatalogError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+func GetImportJob(uc *usecase.CatalogUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid jobID"})
+			return
+		}
+
+		job, err := uc.GetImportJob(c.Request.Context(), id)
+		if err != nil {
+			writeCatalogError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// ExportCatalog streams the catalog as ?format=csv (default) or jsonl
+func ExportCatalog(uc *usecase.CatalogUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format, err := usecase.ParseCatalogFormat(c.DefaultQuery("format", string(entity.CatalogCSV)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		contentType := "text/csv"
+		if format == entity.CatalogJSONL {
+			contentType = "application/x-ndjson"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="catalog-%s.%s"`, time.Now().Format("20060102"), format))
+
+		if err := uc.Export(c.Request.Context(), format, c.Writer); err != nil {
+			// Once rows were streamed the status can't change anymore
+			if c.Writer.Written() {
+				_ = c.Error(err)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	}
+}
+
+func writeCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrImportJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidCatalogFormat), errors.Is(err, usecase.ErrInvalidImportFile),
+		errors.Is(err, usecase.ErrEmptyImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type categoryAttributesReq struct {
+	Attributes []entity.AttributeDef `json:"attributes" binding:"required"`
+}
+
+type categoryReq struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Slug     string `json:"slug" binding:"max=100"`
+	ParentID string `json:"parentID"`
+	Order    int    `json:"order"`
+}
+
+func GetCategoryTree(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tree, err := uc.GetTree(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"categories": localizeCategoryTree(c, tree)})
+	}
+}
+
+func GetCategory(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category, err := uc.GetBySlug(c.Request.Context(), c.Param("slug"))
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, localizeCategoryTree(c, []*entity.CategoryNode{category})[0])
+	}
+}
+
+// BrowseCategory lists products of the category and its subcategories, with the same filters as search
+func BrowseCategory(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		params, err := parseSearchParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		category, result, err := uc.Browse(c.Request.Context(), c.Param("slug"), params)
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		elapsed := time.Since(start)
+		response := searchResponse(c, result)
+		response["category"] = localizeCategory(c, category)
+		response["time_taken (seconds)"] = elapsed.Seconds()
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func CreateCategory(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category, ok := bindCategory(c)
+		if !ok {
+			return
+		}
+
+		if err := uc.Create(c.Request.Context(), category); err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, category)
+	}
+}
+
+// UpdateCategory replaces name, slug, order and parent; an empty parentID moves the category to the root
+func UpdateCategory(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid categoryID"})
+			return
+		}
+
+		category, ok := bindCategory(c)
+		if !ok {
+			return
+		}
+		category.ID = id
+
+		updated, err := uc.Update(c.Request.Context(), category)
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteCategory(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid categoryID"})
+			return
+		}
+
+		if err := uc.Delete(c.Request.Context(), id); err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+	}
+}
+
+// GetCategoryAttributes lists the attributes products of the category carry, inherited ones first
+func GetCategoryAttributes(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		attributes, err := uc.Attributes(c.Request.Context(), c.Param("slug"))
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+		if attributes == nil {
+			attributes = []entity.AttributeDef{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"attributes": attributes})
+	}
+}
+
+// SetCategoryAttributes replaces the category's own attribute schema
+func SetCategoryAttributes(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid categoryID"})
+			return
+		}
+		var req categoryAttributesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		category, err := uc.SetAttributes(c.Request.Context(), id, req.Attributes)
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, category)
+	}
+}
+
+// SyncCategories adds the categories already used by products to the tree as root categories
+func SyncCategories(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		created, err := uc.SyncFromProducts(c.Request.Context())
+		if err != nil {
+			writeCategoryError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"created": created})
+	}
+}
+
+func bindCategory(c *gin.Context) (*entity.Category, bool) {
+	var req categoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	category := &entity.Category{
+		Name:  req.Name,
+		Slug:  req.Slug,
+		Order: req.Order,
+	}
+	if req.ParentID != "" {
+		parentID, err := bson.ObjectIDFromHex(req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parentID"})
+			return nil, false
+		}
+		category.ParentID = &parentID
+	}
+	return category, true
+}
+
+func writeCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrCategoryExists), errors.Is(err, usecase.ErrCategoryHasChildren),
+		errors.Is(err, usecase.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrParentCategoryNotFound), errors.Is(err, usecase.ErrCategoryCycle),
+		errors.Is(err, usecase.ErrInvalidCategory), errors.Is(err, usecase.ErrInvalidSearchSort),
+		errors.Is(err, usecase.ErrInvalidPriceRange), errors.Is(err, entity.ErrInvalidCursor),
+		errors.Is(err, usecase.ErrInvalidAttributeSchema), errors.Is(err, usecase.ErrInvalidAttributeFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type comparisonReq struct {
+	ProductIDs []string `json:"productIDs" binding:"required"`
+}
+
+// CompareProducts lines up 2 to 4 products given as ?ids=a,b,c with the rows where they differ
+// marked. The returned signals are sent back to RecordComparison once the comparison was viewed
+func CompareProducts(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids, ok := parseProductIDs(c, queryList(c, "ids"))
+		if !ok {
+			return
+		}
+
+		comparison, err := uc.Compare(c.Request.Context(), ids)
+		if err != nil {
+			writeComparisonError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, localizeComparison(c, comparison))
+	}
+}
+
+// RecordComparison records that the user or guest compared the products with each other
+func RecordComparison(uc *usecase.InteractionUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req comparisonReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ids, ok := parseProductIDs(c, req.ProductIDs)
+		if !ok {
+			return
+		}
+
+		userID := getUserIDFromContext(c)
+		guestID, _, _ := getGuestFromContext(c)
+		if err := uc.RecordComparison(c.Request.Context(), userID, guestID, ids); err != nil {
+			writeComparisonError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func parseProductIDs(c *gin.Context, raw []string) ([]bson.ObjectID, bool) {
+	ids := make([]bson.ObjectID, len(raw))
+	for i, s := range raw {
+		id, err := bson.ObjectIDFromHex(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID " + s})
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
+// localizeComparison localizes the products and shows the price row in the request currency
+func localizeComparison(c *gin.Context, comparison *entity.ProductComparison) *entity.ProductComparison {
+	quote, ok := getQuoteFromContext(c)
+	if !ok {
+		return comparison
+	}
+
+	cmp := *comparison
+	cmp.Products = localizeProducts(c, cmp.Products)
+	cmp.Rows = slices.Clone(cmp.Rows)
+	for i := range cmp.Rows {
+		row := &cmp.Rows[i]
+		if row.Type != entity.ComparisonPrice {
+			continue
+		}
+		row.Values = slices.Clone(row.Values)
+		for j, v := range row.Values {
+			if amount, ok := v.(money.Amount); ok {
+				row.Values[j] = quote.Convert(amount)
+			}
+		}
+	}
+	return &cmp
+}
+
+func writeComparisonError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidComparison):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeProductError(c, err)
+	}
+}
@@ -0,0 +1,192 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+)
+
+// currencyMiddleware resolves the display currency from ?currency= or the X-Currency header,
+// the base currency when neither is given
+func currencyMiddleware(uc *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		code := c.Query("currency")
+		if code == "" {
+			code = c.GetHeader("X-Currency")
+		}
+
+		quote, err := uc.Quote(code)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		c.Set("currency", quote)
+		c.Next()
+	}
+}
+
+// getQuoteFromContext returns the display currency of the request
+func getQuoteFromContext(c *gin.Context) (money.Quote, bool) {
+	quote, exists := c.Get("currency")
+	if !exists {
+		return money.Quote{}, false
+	}
+	return quote.(money.Quote), true
+}
+
+// localizeProduct returns a copy of the product with display prices in the request currency and
+// its name and description in the request locale. Products may be shared with a cache, so they are
+// never changed in place
+func localizeProduct(c *gin.Context, product *entity.Product) *entity.Product {
+	if product == nil {
+		return nil
+	}
+
+	p := *product
+	translateProduct(c, &p)
+	quote, ok := getQuoteFromContext(c)
+	if !ok {
+		return &p
+	}
+	p.DisplayPrice, p.DisplaySalePrice = convertPrices(quote, p.Price, p.SalePrice)
+	p.Variants = slices.Clone(p.Variants)
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		v.DisplayPrice, v.DisplaySalePrice = convertPrices(quote, v.Price, v.SalePrice)
+	}
+	return &p
+}
+
+// convertPrices converts a regular price and the sale price, when there is one
+func convertPrices(quote money.Quote, price money.Amount, salePrice *money.Amount) (*money.Money, *money.Money) {
+	display := quote.Convert(price)
+	if salePrice == nil {
+		return &display, nil
+	}
+	displaySale := quote.Convert(*salePrice)
+	return &display, &displaySale
+}
+
+func localizeProducts(c *gin.Context, products []*entity.Product) []*entity.Product {
+	localized := make([]*entity.Product, len(products))
+	for i, p := range products {
+		localized[i] = localizeProduct(c, p)
+	}
+	return localized
+}
+
+func localizeRecommendation(c *gin.Context, recommendation *entity.Recommendation) *entity.Recommendation {
+	if recommendation == nil {
+		return nil
+	}
+	r := *recommendation
+	r.Products = slices.Clone(r.Products)
+	for i := range r.Products {
+		r.Products[i].Product = *localizeProduct(c, &r.Products[i].Product)
+	}
+	return &r
+}
+
+// parseQuotedAmount reads a non-negative amount in minor units of the request currency
+func parseQuotedAmount(c *gin.Context, amount json.Number, quote money.Quote) (int64, bool) {
+	v, err := money.ParseMinor(amount.String(), quote.Currency.Decimals)
+	if err != nil || v < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Invalid amount %q for %s", amount.String(), quote.Currency.Code),
+		})
+		return 0, false
+	}
+	return v, true
+}
+
+// parseOptionalPrice converts a price given in the request currency to the base currency, nil when omitted
+func parseOptionalPrice(c *gin.Context, price json.Number) (*money.Amount, bool) {
+	if price == "" {
+		return nil, true
+	}
+	quote, _ := getQuoteFromContext(c)
+	minor, ok := parseQuotedAmount(c, price, quote)
+	if !ok {
+		return nil, false
+	}
+	amount := quote.ToBase(minor)
+	return &amount, true
+}
+
+// GetCurrencyRates lists the base currency and the currencies prices can be shown in
+func GetCurrencyRates(uc *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rates, err := uc.ListRates(c.Request.Context())
+		if err != nil {
+			writeCurrencyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rates)
+	}
+}
+
+// SetCurrencyRates sets exchange rates from an object of currency codes to rates, e.g. {"EUR": "0.92"}
+func SetCurrencyRates(uc *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req map[string]string
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rates, err := uc.SetRates(c.Request.Context(), req)
+		if err != nil {
+			writeCurrencyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rates)
+	}
+}
+
+func DeleteCurrencyRate(uc *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := uc.DeleteRate(c.Request.Context(), c.Param("code")); err != nil {
+			writeCurrencyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+	}
+}
+
+// ReloadCurrencyRates sets the exchange rates from the configured rates file
+func ReloadCurrencyRates(uc *usecase.CurrencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rates, err := uc.LoadFile(c.Request.Context())
+		if err != nil {
+			writeCurrencyError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rates)
+	}
+}
+
+func writeCurrencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrExchangeRateNotFound), errors.Is(err, os.ErrNotExist):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedCurrency), errors.Is(err, usecase.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrRatesFileNotConfigured):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
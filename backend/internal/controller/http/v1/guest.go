@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+)
+
+type GuestSessionRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type GuestClaimRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func StartGuestSession(uc *usecase.GuestUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req GuestSessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		guest, token, err := uc.StartSession(c.Request.Context(), req.Email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"guest": guest,
+			"token": token,
+		})
+	}
+}
+
+func GetGuestHistory(uc *usecase.GuestUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guestID, _, ok := getGuestFromContext(c)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Guest token required"})
+			return
+		}
+
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		purchases, info, err := uc.GetPurchaseHistory(c.Request.Context(), guestID, page)
+		if err != nil {
+			writeInteractionError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("purchases", purchases, info))
+	}
+}
+
+// RequestGuestClaim emails the user a token to claim the guest checkouts made with their email
+func RequestGuestClaim(uc *usecase.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guests, err := uc.RequestGuestClaim(c.Request.Context(), getUserIDFromContext(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"guests": guests})
+	}
+}
+
+// ClaimGuests moves the activity of the guests a claim token covers to the user
+func ClaimGuests(uc *usecase.UserUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req GuestClaimRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		claimed, err := uc.ClaimGuests(c.Request.Context(), getUserIDFromContext(c), req.Token)
+		if err != nil {
+			if errors.Is(err, usecase.ErrInvalidClaimToken) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"claimed": claimed})
+	}
+}
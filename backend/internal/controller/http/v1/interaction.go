@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,21 +13,23 @@ import (
 
 type interactionReq struct {
 	ProductID string `json:"productID" binding:"required"`
+	SKU       string `json:"sku"`
 }
 
 type purchaseReq struct {
 	Products []struct {
-		ProductID string  `json:"productId" binding:"required"`
-		Quantity  int     `json:"quantity" binding:"required,min=1"`
-		Price     float64 `json:"price" binding:"required,min=0"`
+		ProductID string `json:"productId" binding:"required"`
+		SKU       string `json:"sku"`
+		Quantity  int    `json:"quantity" binding:"required,min=1"`
 	} `json:"products" binding:"required,min=1"`
-	Total  float64 `json:"total" binding:"required,min=0"`
-	Status string  `json:"status"`
+	// Total - what the customer was shown in the request currency. Items are priced by the server,
+	// a total that no longer matches is rejected so the customer can review the new prices
+	Total  json.Number `json:"total"`
+	Status string      `json:"status"`
 }
 
 func RecordView(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := getUserIDFromContext(c)
 		var req interactionReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -36,8 +40,8 @@ func RecordView(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productID"})
 			return
 		}
-		if err := uc.RecordInteraction(c.Request.Context(), userID, pid, entity.InteractionView); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := recordInteraction(c, uc, pid, req.SKU, entity.InteractionView); err != nil {
+			writeInteractionError(c, err)
 			return
 		}
 		c.Status(http.StatusNoContent)
@@ -46,7 +50,6 @@ func RecordView(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 
 func RecordLike(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := getUserIDFromContext(c)
 		var req interactionReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -57,8 +60,8 @@ func RecordLike(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid productID"})
 			return
 		}
-		if err := uc.RecordInteraction(c.Request.Context(), userID, pid, entity.InteractionLike); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := recordInteraction(c, uc, pid, req.SKU, entity.InteractionLike); err != nil {
+			writeInteractionError(c, err)
 			return
 		}
 		c.Status(http.StatusNoContent)
@@ -67,7 +70,6 @@ func RecordLike(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 
 func RecordCart(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := getUserIDFromContext(c)
 		var req interactionReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -80,8 +82,8 @@ func RecordCart(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 			return
 		}
 
-		if err := uc.RecordInteraction(c.Request.Context(), userID, pid, entity.InteractionCart); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := recordInteraction(c, uc, pid, req.SKU, entity.InteractionCart); err != nil {
+			writeInteractionError(c, err)
 			return
 		}
 
@@ -91,7 +93,6 @@ func RecordCart(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 
 func RecordPurchase(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID := getUserIDFromContext(c)
 		var req purchaseReq
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -99,11 +100,16 @@ func RecordPurchase(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 		}
 		// Convert request to entity
 		purchase := &entity.Purchase{
-			UserID:   userID,
-			Total:    req.Total,
 			Status:   req.Status,
 			Products: make([]entity.PurchaseItem, len(req.Products)),
 		}
+		// Guest orders are stored without a user ID until the email is claimed
+		if guestID, guestEmail, ok := getGuestFromContext(c); ok {
+			purchase.GuestID = guestID
+			purchase.GuestEmail = guestEmail
+		} else {
+			purchase.UserID = getUserIDFromContext(c)
+		}
 
 		for i, p := range req.Products {
 			pid, err := bson.ObjectIDFromHex(p.ProductID)
@@ -111,22 +117,36 @@ func RecordPurchase(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
 				return
 			}
-
 			purchase.Products[i] = entity.PurchaseItem{
 				ProductID: pid,
+				SKU:       p.SKU,
 				Quantity:  p.Quantity,
-				Price:     p.Price,
 			}
+		}
+		if err := uc.PricePurchase(c.Request.Context(), purchase); err != nil {
+			writeInteractionError(c, err)
+			return
+		}
 
-			// Record purchase interaction for each product
-			if err := uc.RecordInteraction(c.Request.Context(), userID, pid, entity.InteractionPurchase); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// The order is placed in the display currency and stored in the base currency at the current rate
+		quote, _ := getQuoteFromContext(c)
+		purchase.Currency = quote.Currency.Code
+		purchase.ExchangeRate = quote.RateString()
+		purchase.Paid = quote.Convert(purchase.Total)
+		if req.Total != "" {
+			total, ok := parseQuotedAmount(c, req.Total, quote)
+			if !ok {
+				return
+			}
+			if total != purchase.Paid.Amount {
+				c.JSON(http.StatusConflict, gin.H{"error": "Prices have changed, review the order", "total": purchase.Paid})
 				return
 			}
 		}
 
+		// Every item was checked by PricePurchase, stock and interactions follow the stored purchase
 		if err := uc.CreatePurchase(c.Request.Context(), purchase); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			writeInteractionError(c, err)
 			return
 		}
 
@@ -137,13 +157,59 @@ func RecordPurchase(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 func GetUserHistory(uc *usecase.InteractionUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := getUserIDFromContext(c)
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		purchases, err := uc.GetUserPurchaseHistory(c.Request.Context(), userID)
+		purchases, info, err := uc.GetUserPurchaseHistory(c.Request.Context(), userID, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			writeInteractionError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"purchases": purchases})
+		c.JSON(http.StatusOK, pageResponse("purchases", purchases, info))
+	}
+}
+
+func GetUserInteractions(uc *usecase.InteractionUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		interactions, info, err := uc.GetUserInteractionHistory(c.Request.Context(), userID, page)
+		if err != nil {
+			writeInteractionError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("interactions", interactions, info))
+	}
+}
+
+// recordInteraction records the interaction for the signed-in user or for the guest behind a guest token
+func recordInteraction(c *gin.Context, uc *usecase.InteractionUseCase, productID bson.ObjectID, sku string, t entity.InteractionType) error {
+	if guestID, _, ok := getGuestFromContext(c); ok {
+		return uc.RecordGuestInteraction(c.Request.Context(), guestID, productID, sku, t)
+	}
+	return uc.RecordInteraction(c.Request.Context(), getUserIDFromContext(c), productID, sku, t)
+}
+
+func writeInteractionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOutOfStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrVariantRequired), errors.Is(err, usecase.ErrVariantNotFound),
+		errors.Is(err, entity.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
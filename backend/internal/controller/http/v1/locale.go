@@ -0,0 +1,222 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type translationReq struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type categoryTranslationReq struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// localeMiddleware resolves the locale content is read in from ?locale=, which must be supported, or
+// the Accept-Language header, which falls back to the default locale
+func localeMiddleware(uc *usecase.LocaleUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chain, err := uc.Negotiate(c.Query("locale"), c.GetHeader("Accept-Language"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		locale := uc.Locales().Default
+		if len(chain) > 0 {
+			locale = chain[0]
+		}
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Set("locale", chain)
+		c.Next()
+	}
+}
+
+// getLocaleFromContext returns the translations the request reads, most specific first; none for the default locale
+func getLocaleFromContext(c *gin.Context) []string {
+	chain, exists := c.Get("locale")
+	if !exists {
+		return nil
+	}
+	return chain.([]string)
+}
+
+// translateProduct shows the name and description of a product copy in the request locale
+func translateProduct(c *gin.Context, p *entity.Product) {
+	if chain := getLocaleFromContext(c); len(chain) > 0 {
+		p.Name, p.Description, p.Locale = p.Translations.Localize(chain, p.Name, p.Description)
+	}
+}
+
+// localizeCategory returns a copy of the category with DisplayName in the request locale
+func localizeCategory(c *gin.Context, category *entity.Category) *entity.Category {
+	if category == nil {
+		return nil
+	}
+	cat := *category
+	cat.DisplayName, _, _ = cat.Translations.Localize(getLocaleFromContext(c), cat.Name, "")
+	return &cat
+}
+
+func localizeCategoryTree(c *gin.Context, nodes []*entity.CategoryNode) []*entity.CategoryNode {
+	localized := make([]*entity.CategoryNode, len(nodes))
+	for i, n := range nodes {
+		localized[i] = &entity.CategoryNode{
+			Category: localizeCategory(c, n.Category),
+			Children: localizeCategoryTree(c, n.Children),
+		}
+	}
+	return localized
+}
+
+// GetLocales lists the default locale and the locales content may be read in
+func GetLocales(uc *usecase.LocaleUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, uc.Locales())
+	}
+}
+
+// SetProductTranslation adds or replaces the product's name and description in the locale of the path
+func SetProductTranslation(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		var req translationReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		product, err := uc.SetProductTranslation(c.Request.Context(), id, entity.Translation{
+			Locale:      c.Param("locale"),
+			Name:        req.Name,
+			Description: req.Description,
+		})
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": product.ID, "translations": product.Translations})
+	}
+}
+
+func DeleteProductTranslation(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+
+		product, err := uc.DeleteProductTranslation(c.Request.Context(), id, c.Param("locale"))
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": product.ID, "translations": product.Translations})
+	}
+}
+
+// SetCategoryTranslation adds or replaces the category's name in the locale of the path
+func SetCategoryTranslation(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		var req categoryTranslationReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		category, err := uc.SetCategoryTranslation(c.Request.Context(), id, entity.Translation{
+			Locale: c.Param("locale"),
+			Name:   req.Name,
+		})
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": category.ID, "translations": category.Translations})
+	}
+}
+
+func DeleteCategoryTranslation(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+
+		category, err := uc.DeleteCategoryTranslation(c.Request.Context(), id, c.Param("locale"))
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": category.ID, "translations": category.Translations})
+	}
+}
+
+// ListMissingProductTranslations pages through the products still to be translated to ?locale=,
+// to any supported locale when it is omitted
+func ListMissingProductTranslations(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		missing, info, err := uc.MissingProductTranslations(c.Request.Context(), c.Query("locale"), page)
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("products", missing, info))
+	}
+}
+
+// ListMissingCategoryTranslations lists the categories whose name is still to be translated to
+// ?locale=, to any supported locale when it is omitted
+func ListMissingCategoryTranslations(uc *usecase.TranslationUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		missing, err := uc.MissingCategoryTranslations(c.Request.Context(), c.Query("locale"))
+		if err != nil {
+			writeTranslationError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"categories": missing})
+	}
+}
+
+func writeTranslationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrTranslationNotFound), errors.Is(err, usecase.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidTranslation), errors.Is(err, usecase.ErrUnsupportedLocale):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeProductError(c, err)
+	}
+}
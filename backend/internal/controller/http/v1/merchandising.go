@@ -0,0 +1,297 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type synonymSetReq struct {
+	Terms []string `json:"terms" binding:"required,min=2,max=50,dive,max=100"`
+}
+
+type productMatchReq struct {
+	ProductIDs []string `json:"productIDs" binding:"max=100"`
+	Tags       []string `json:"tags" binding:"max=50"`
+	Categories []string `json:"categories" binding:"max=50"`
+}
+
+type searchRuleReq struct {
+	Name        string          `json:"name" binding:"required,max=100"`
+	Queries     []string        `json:"queries" binding:"required,min=1,max=50,dive,max=200"`
+	Pins        []string        `json:"pins"`
+	Boost       productMatchReq `json:"boost"`
+	BoostFactor float64         `json:"boostFactor"`
+	Bury        productMatchReq `json:"bury"`
+	ActiveFrom  *time.Time      `json:"activeFrom"`
+	ActiveUntil *time.Time      `json:"activeUntil"`
+}
+
+// PreviewSearch shows the results of a search with and without synonyms and merchandising rules
+func PreviewSearch(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params, err := parseSearchParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		preview, err := uc.Preview(c.Request.Context(), params)
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"withRules":    searchResponse(c, preview.WithRules),
+			"withoutRules": searchResponse(c, preview.WithoutRules),
+			"rules":        preview.Rules,
+		})
+	}
+}
+
+func ListSynonymSets(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sets, info, err := uc.ListSynonyms(c.Request.Context(), page)
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("synonyms", sets, info))
+	}
+}
+
+func CreateSynonymSet(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req synonymSetReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		set := &entity.SynonymSet{Terms: req.Terms}
+		if err := uc.CreateSynonyms(c.Request.Context(), set); err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, set)
+	}
+}
+
+func UpdateSynonymSet(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid synonym set ID"})
+			return
+		}
+
+		var req synonymSetReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		set, err := uc.UpdateSynonyms(c.Request.Context(), &entity.SynonymSet{ID: id, Terms: req.Terms})
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, set)
+	}
+}
+
+func DeleteSynonymSet(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid synonym set ID"})
+			return
+		}
+
+		if err := uc.DeleteSynonyms(c.Request.Context(), id); err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Synonym set deleted"})
+	}
+}
+
+func ListSearchRules(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rules, info, err := uc.ListRules(c.Request.Context(), page)
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("rules", rules, info))
+	}
+}
+
+func GetSearchRule(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search rule ID"})
+			return
+		}
+
+		rule, err := uc.GetRule(c.Request.Context(), id)
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, rule)
+	}
+}
+
+func CreateSearchRule(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := bindSearchRule(c)
+		if !ok {
+			return
+		}
+
+		if err := uc.CreateRule(c.Request.Context(), rule); err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// UpdateSearchRule replaces the rule
+func UpdateSearchRule(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search rule ID"})
+			return
+		}
+
+		rule, ok := bindSearchRule(c)
+		if !ok {
+			return
+		}
+		rule.ID = id
+
+		updated, err := uc.UpdateRule(c.Request.Context(), rule)
+		if err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func DeleteSearchRule(uc *usecase.MerchandisingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search rule ID"})
+			return
+		}
+
+		if err := uc.DeleteRule(c.Request.Context(), id); err != nil {
+			writeMerchandisingError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Search rule deleted"})
+	}
+}
+
+func bindSearchRule(c *gin.Context) (*entity.SearchRule, bool) {
+	var req searchRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	pins, err := parseObjectIDs(req.Pins)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pinned productID"})
+		return nil, false
+	}
+	boost, err := req.Boost.toEntity()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid boosted productID"})
+		return nil, false
+	}
+	bury, err := req.Bury.toEntity()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid buried productID"})
+		return nil, false
+	}
+
+	return &entity.SearchRule{
+		Name:        req.Name,
+		Queries:     req.Queries,
+		Pins:        pins,
+		Boost:       boost,
+		BoostFactor: req.BoostFactor,
+		Bury:        bury,
+		ActiveFrom:  req.ActiveFrom,
+		ActiveUntil: req.ActiveUntil,
+	}, true
+}
+
+func (r productMatchReq) toEntity() (entity.ProductMatch, error) {
+	ids, err := parseObjectIDs(r.ProductIDs)
+	if err != nil {
+		return entity.ProductMatch{}, err
+	}
+	return entity.ProductMatch{
+		ProductIDs: ids,
+		Tags:       r.Tags,
+		Categories: r.Categories,
+	}, nil
+}
+
+func parseObjectIDs(hexes []string) ([]bson.ObjectID, error) {
+	ids := make([]bson.ObjectID, 0, len(hexes))
+	for _, hex := range hexes {
+		id, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func writeMerchandisingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrSynonymSetNotFound), errors.Is(err, usecase.ErrSearchRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidSynonymSet), errors.Is(err, usecase.ErrInvalidSearchRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeProductError(c, err)
+	}
+}
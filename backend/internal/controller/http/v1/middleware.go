@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -8,66 +9,141 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.uber.org/zap"
 )
 
 func AuthMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		claims, ok := parseBearerClaims(c, secret)
+		if !ok {
 			return
 		}
 
-		//tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		//tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+		if !setUserFromClaims(c, claims) {
 			return
 		}
-		tokenString := parts[1]
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secret), nil
-		})
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+// GuestOrAuthMiddleware accepts either a user token or a guest checkout token of a guest that
+// hasn't been claimed by an account
+func GuestOrAuthMiddleware(secret string, guests *usecase.GuestUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := parseBearerClaims(c, secret)
+		if !ok {
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+		if _, isUser := claims["user_id"]; isUser {
+			if !setUserFromClaims(c, claims) {
+				return
+			}
+			c.Next()
 			return
 		}
-		userIDStr, ok := claims["user_id"].(string)
+
+		guestIDStr, ok := claims["guest_id"].(string)
 		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
 			c.Abort()
 			return
 		}
-		userID, err := bson.ObjectIDFromHex(userIDStr)
+		guestID, err := bson.ObjectIDFromHex(guestIDStr)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to get id from token"})
 			c.Abort()
 			return
 		}
+		if err := guests.CheckSession(c.Request.Context(), guestID); err != nil {
+			switch {
+			case errors.Is(err, usecase.ErrGuestClaimed), errors.Is(err, usecase.ErrGuestNotFound):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			c.Abort()
+			return
+		}
+		guestEmail, _ := claims["guest_email"].(string)
 
-		c.Set("user_id", userID)
+		c.Set("guest_id", guestID)
+		c.Set("guest_email", guestEmail)
 		c.Next()
 	}
 }
 
+// optional runs mw only for requests that carry an Authorization header, anonymous requests pass through
+func optional(mw gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		mw(c)
+	}
+}
+
+func parseBearerClaims(c *gin.Context, secret string) (jwt.MapClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return nil, false
+	}
+
+	//tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+	//tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		c.Abort()
+		return nil, false
+	}
+	tokenString := parts[1]
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		c.Abort()
+		return nil, false
+	}
+	return claims, true
+}
+
+func setUserFromClaims(c *gin.Context, claims jwt.MapClaims) bool {
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+		c.Abort()
+		return false
+	}
+	userID, err := bson.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to get id from token"})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user_id", userID)
+	return true
+}
+
 func getUserIDFromContext(c *gin.Context) bson.ObjectID {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -76,12 +152,21 @@ func getUserIDFromContext(c *gin.Context) bson.ObjectID {
 	return userID.(bson.ObjectID)
 }
 
+// getGuestFromContext returns the guest ID and email when the request carries a guest token
+func getGuestFromContext(c *gin.Context) (bson.ObjectID, string, bool) {
+	guestID, exists := c.Get("guest_id")
+	if !exists {
+		return bson.NilObjectID, "", false
+	}
+	return guestID.(bson.ObjectID), c.GetString("guest_email"), true
+}
+
 // CORS middleware
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Currency")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const testSecret = "test-secret"
+
+// guestRepo - an in-memory usecase.GuestRepository
+type guestRepo map[bson.ObjectID]*entity.Guest
+
+func (r guestRepo) Create(_ context.Context, guest *entity.Guest) error {
+	guest.ID = bson.NewObjectID()
+	r[guest.ID] = guest
+	return nil
+}
+
+func (r guestRepo) GetByID(_ context.Context, id bson.ObjectID) (*entity.Guest, error) {
+	guest, ok := r[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return guest, nil
+}
+
+func (r guestRepo) GetUnclaimedByEmail(_ context.Context, email string) ([]*entity.Guest, error) {
+	var guests []*entity.Guest
+	for _, g := range r {
+		if g.Email == email && g.ClaimedBy.IsZero() {
+			guests = append(guests, g)
+		}
+	}
+	return guests, nil
+}
+
+func (r guestRepo) MarkClaimed(_ context.Context, id, userID bson.ObjectID) error {
+	r[id].ClaimedBy = userID
+	return nil
+}
+
+func TestGuestOrAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repo := guestRepo{}
+	guests := usecase.NewGuestUseCase(repo, nil, nil, nil, nil, testSecret, time.Hour, time.Hour)
+
+	_, activeToken, err := guests.StartSession(ctx, "active@example.com")
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	claimed, claimedToken, err := guests.StartSession(ctx, "claimed@example.com")
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := repo.MarkClaimed(ctx, claimed.ID, bson.NewObjectID()); err != nil {
+		t.Fatalf("MarkClaimed: %v", err)
+	}
+	_, unknownToken, err := guests.StartSession(ctx, "unknown@example.com")
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	for id, g := range repo {
+		if g.Email == "unknown@example.com" {
+			delete(repo, id)
+		}
+	}
+	userToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": bson.NewObjectID().Hex(),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign user token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+		actor string
+	}{
+		{name: "user", token: userToken, want: http.StatusOK, actor: "user"},
+		{name: "unclaimed guest", token: activeToken, want: http.StatusOK, actor: "guest"},
+		// Activity recorded with the token would stay with the guest the user already claimed
+		{name: "claimed guest", token: claimedToken, want: http.StatusUnauthorized},
+		{name: "unknown guest", token: unknownToken, want: http.StatusUnauthorized},
+		{name: "invalid token", token: "not-a-jwt", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.GET("/", GuestOrAuthMiddleware(testSecret, guests), func(c *gin.Context) {
+			actor := "guest"
+			if _, ok := c.Get("user_id"); ok {
+				actor = "user"
+			}
+			c.String(http.StatusOK, actor)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+			continue
+		}
+		if tt.actor != "" && rec.Body.String() != tt.actor {
+			t.Errorf("%s: authenticated as %q, want %q", tt.name, rec.Body.String(), tt.actor)
+		}
+	}
+}
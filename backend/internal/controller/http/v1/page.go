@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePageRequest reads ?cursor= and ?limit= for cursor paginated listings
+func parsePageRequest(c *gin.Context) (entity.PageRequest, error) {
+	page := entity.PageRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  defaultPageLimit,
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return page, fmt.Errorf("invalid limit")
+		}
+		page.Limit = min(limit, maxPageLimit)
+	}
+
+	return page, nil
+}
+
+// pageResponse puts a page of items under key together with its paging metadata
+func pageResponse(key string, items interface{}, page entity.PageInfo) gin.H {
+	return gin.H{
+		key:          items,
+		"total":      page.Total,
+		"nextCursor": page.NextCursor,
+		"hasMore":    page.HasMore,
+	}
+}
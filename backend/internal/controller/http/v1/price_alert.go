@@ -0,0 +1,206 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxPriceHistoryDays - the longest period a price chart covers
+const maxPriceHistoryDays = 365
+
+type priceWatchReq struct {
+	ProductID string `json:"productID" binding:"required"`
+	SKU       string `json:"sku"`
+	// TargetPrice - in the request currency, omitted to be told of every drop
+	TargetPrice json.Number `json:"targetPrice"`
+}
+
+type priceWatchUpdateReq struct {
+	TargetPrice json.Number `json:"targetPrice"`
+}
+
+// GetPriceHistory returns the product price (or a variant price with ?sku=) over the last ?days=90 days
+func GetPriceHistory(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+		days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+		if err != nil || days < 1 || days > maxPriceHistoryDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+			return
+		}
+
+		since := time.Now().AddDate(0, 0, -days)
+		history, err := uc.PriceHistory(c.Request.Context(), id, c.Query("sku"), since)
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, localizePriceHistory(c, history))
+	}
+}
+
+// localizePriceHistory adds display prices in the request currency to every point
+func localizePriceHistory(c *gin.Context, history *entity.PriceHistory) *entity.PriceHistory {
+	quote, ok := getQuoteFromContext(c)
+	if !ok {
+		return history
+	}
+
+	localize := func(p *entity.PricePoint) {
+		price := quote.Convert(p.Price)
+		p.DisplayPrice = &price
+	}
+	localize(&history.Current)
+	localize(&history.Lowest)
+	localize(&history.Highest)
+	for i := range history.Points {
+		localize(&history.Points[i])
+	}
+	return history
+}
+
+func ListPriceWatches(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		watches, info, err := uc.ListWatches(c.Request.Context(), getUserIDFromContext(c), page)
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("watches", watches, info))
+	}
+}
+
+// CreatePriceWatch watches a product or variant price for drops, below targetPrice when given
+func CreatePriceWatch(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req priceWatchReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		pid, err := bson.ObjectIDFromHex(req.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+		target, ok := parseOptionalPrice(c, req.TargetPrice)
+		if !ok {
+			return
+		}
+
+		watch, err := uc.Watch(c.Request.Context(), getUserIDFromContext(c), pid, req.SKU, target)
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, watch)
+	}
+}
+
+// UpdatePriceWatch changes the target price, an omitted target reports every drop
+func UpdatePriceWatch(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price watch ID"})
+			return
+		}
+		var req priceWatchUpdateReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		target, ok := parseOptionalPrice(c, req.TargetPrice)
+		if !ok {
+			return
+		}
+
+		watch, err := uc.UpdateWatch(c.Request.Context(), getUserIDFromContext(c), id, target)
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, watch)
+	}
+}
+
+func DeletePriceWatch(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price watch ID"})
+			return
+		}
+
+		if err := uc.Unwatch(c.Request.Context(), getUserIDFromContext(c), id); err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Price watch deleted"})
+	}
+}
+
+// ListPriceWatchCandidates offers wishlisted and liked products that aren't watched yet
+func ListPriceWatchCandidates(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		candidates, err := uc.Candidates(c.Request.Context(), getUserIDFromContext(c))
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		for _, candidate := range candidates {
+			candidate.Product = localizeProduct(c, candidate.Product)
+		}
+		c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+	}
+}
+
+// RunPriceAlerts checks pending price changes against watches without waiting for the schedule
+func RunPriceAlerts(uc *usecase.PriceAlertUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		run, err := uc.Run(c.Request.Context())
+		if err != nil {
+			writePriceAlertError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, run)
+	}
+}
+
+func writePriceAlertError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrPriceWatchNotFound), errors.Is(err, usecase.ErrVariantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrPriceWatchExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidTargetPrice), errors.Is(err, usecase.ErrTooManyPriceWatches):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeProductError(c, err)
+	}
+}
@@ -1,30 +1,40 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/m4rk1sov/ecommerce/internal/entity"
 	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 func ListProducts(uc *usecase.ProductUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		products, err := uc.List(c.Request.Context(), limit, offset)
+		products, info, err := uc.List(c.Request.Context(), page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			writeProductError(c, err)
 			return
 		}
 
 		elapsed := time.Since(start)
-		c.JSON(http.StatusOK, gin.H{"products": products, "time_taken (seconds)": elapsed.Seconds()})
+		response := pageResponse("products", localizeProducts(c, products), info)
+		response["time_taken (seconds)"] = elapsed.Seconds()
+		c.JSON(http.StatusOK, response)
 	}
 }
 
@@ -42,26 +52,221 @@ func GetProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, product)
+		c.JSON(http.StatusOK, localizeProduct(c, product))
 	}
 }
 
-func SearchProducts(uc *usecase.ProductUseCase) gin.HandlerFunc {
+// SearchProducts searches the catalog with synonyms and merchandising rules applied and logs the search.
+// The returned searchId is sent back with result clicks
+func SearchProducts(uc *usecase.MerchandisingUseCase, analyticsUC *usecase.SearchAnalyticsUseCase) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		query := c.Query("q")
-		category := c.Query("category")
-		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		params, err := parseSearchParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		products, err := uc.Search(c.Request.Context(), query, category, limit)
+		result, err := uc.Search(c.Request.Context(), params)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			writeMerchandisingError(c, err)
 			return
 		}
 
 		elapsed := time.Since(start)
-		c.JSON(http.StatusOK, gin.H{"products": products, "time_taken (seconds)": elapsed.Seconds()})
+		response := searchResponse(c, result)
+		response["time_taken (seconds)"] = elapsed.Seconds()
+
+		search := &entity.SearchLog{
+			Query:      params.Query,
+			UserID:     getUserIDFromContext(c),
+			Results:    result.Page.Total,
+			LatencyMs:  float64(elapsed.Microseconds()) / 1000,
+			NextPage:   params.Page.Cursor != "",
+			ProductIDs: make([]bson.ObjectID, len(result.Products)),
+		}
+		for i, p := range result.Products {
+			search.ProductIDs[i] = p.ID
+		}
+		if guestID, _, ok := getGuestFromContext(c); ok {
+			search.GuestID = guestID
+		}
+		// Logging is best effort, a failure doesn't fail the search
+		if err := analyticsUC.Record(c.Request.Context(), search); err == nil && !search.ID.IsZero() {
+			response["searchId"] = search.ID
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// searchResponse - a products page with facets and, when present, fuzzy corrections, synonyms,
+// pinned products and "did you mean". Products carry display prices in the request currency
+func searchResponse(c *gin.Context, result *entity.ProductSearchResult) gin.H {
+	response := pageResponse("products", localizeProducts(c, result.Products), result.Page)
+	response["facets"] = result.Facets
+	if len(result.FuzzyTerms) > 0 {
+		response["fuzzyTerms"] = result.FuzzyTerms
+	}
+	if len(result.SynonymTerms) > 0 {
+		response["synonymTerms"] = result.SynonymTerms
+	}
+	if len(result.Pinned) > 0 {
+		response["pinned"] = result.Pinned
+	}
+	if result.DidYouMean != "" {
+		response["didYouMean"] = result.DidYouMean
+	}
+	return response
+}
+
+// RefreshSearchVocabulary rebuilds the words fuzzy search corrects to without waiting for the schedule
+func RefreshSearchVocabulary(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		words, err := uc.RefreshVocabulary(c.Request.Context())
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"words": words})
+	}
+}
+
+// ReindexProducts rebuilds the search index from the catalog
+func ReindexProducts(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		indexed, err := uc.Reindex(c.Request.Context())
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"indexed": indexed})
+	}
+}
+
+// parseSearchParams reads filters from the query string. Multi-value filters accept
+// repeated keys or comma separated values: ?category=Books&category=Toys or ?tags=a,b.
+// Price filters, like the price facets, are in the base currency
+func parseSearchParams(c *gin.Context) (entity.ProductSearchParams, error) {
+	params := entity.ProductSearchParams{
+		Query:      c.Query("q"),
+		Categories: queryList(c, "category"),
+		Tags:       queryList(c, "tags"),
+		Sort:       entity.SearchSort(c.Query("sort")),
+	}
+
+	var err error
+	if params.Page, err = parsePageRequest(c); err != nil {
+		return params, err
+	}
+	if params.MinPrice, err = queryAmount(c, "minPrice"); err != nil {
+		return params, err
+	}
+	if params.MaxPrice, err = queryAmount(c, "maxPrice"); err != nil {
+		return params, err
+	}
+
+	minRating, err := queryFloat(c, "minRating")
+	if err != nil {
+		return params, err
+	}
+	if minRating != nil {
+		params.MinRating = *minRating
+	}
+
+	if v := c.Query("inStock"); v != "" {
+		if params.InStock, err = strconv.ParseBool(v); err != nil {
+			return params, fmt.Errorf("invalid inStock")
+		}
+	}
+	if params.Attributes, err = queryAttributes(c); err != nil {
+		return params, err
+	}
+
+	return params, nil
+}
+
+// queryAttributes reads attribute filters given as ?attr=key:value, repeated for alternatives of a key,
+// or as ?attr=key:min..max for number ranges with either end left open
+func queryAttributes(c *gin.Context) ([]entity.AttributeFilter, error) {
+	var filters []entity.AttributeFilter
+	for _, raw := range c.QueryArray("attr") {
+		key, value, ok := strings.Cut(raw, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid attr %q, use key:value", raw)
+		}
+
+		i := slices.IndexFunc(filters, func(f entity.AttributeFilter) bool { return f.Key == key })
+		if i < 0 {
+			filters = append(filters, entity.AttributeFilter{Key: key})
+			i = len(filters) - 1
+		}
+		f := &filters[i]
+
+		low, high, isRange := strings.Cut(value, "..")
+		if !isRange {
+			f.Raw = append(f.Raw, value)
+			continue
+		}
+		var err error
+		if f.Min, err = parseBound(low); err != nil {
+			return nil, fmt.Errorf("invalid attr range %q", raw)
+		}
+		if f.Max, err = parseBound(high); err != nil {
+			return nil, fmt.Errorf("invalid attr range %q", raw)
+		}
 	}
+	return filters, nil
+}
+
+// parseBound reads an end of a range, nil when left open
+func parseBound(raw string) (*float64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func queryAmount(c *gin.Context, key string) (*money.Amount, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := money.ParseAmount(raw)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &v, nil
+}
+
+func queryFloat(c *gin.Context, key string) (*float64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &v, nil
 }
 
 func CreateProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
@@ -72,8 +277,8 @@ func CreateProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
 			return
 		}
 
-		if err := uc.Create(c.Request.Context(), &product); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := uc.Create(c.Request.Context(), getUserIDFromContext(c), &product); err != nil {
+			writeProductError(c, err)
 			return
 		}
 
@@ -96,8 +301,8 @@ func UpdateProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
 		}
 
 		product.ID = id
-		if err := uc.Update(c.Request.Context(), &product); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := uc.Update(c.Request.Context(), getUserIDFromContext(c), &product); err != nil {
+			writeProductError(c, err)
 			return
 		}
 
@@ -113,11 +318,60 @@ func DeleteProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
 			return
 		}
 
-		if err := uc.Delete(c.Request.Context(), id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := uc.Delete(c.Request.Context(), getUserIDFromContext(c), id); err != nil {
+			writeProductError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
+		c.JSON(http.StatusOK, gin.H{"message": "Product archived"})
+	}
+}
+
+func RestoreProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		if err := uc.Restore(c.Request.Context(), getUserIDFromContext(c), id); err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Product restored"})
+	}
+}
+
+func ListArchivedProducts(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		products, info, err := uc.ListArchived(c.Request.Context(), page)
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("products", products, info))
+	}
+}
+
+func writeProductError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrProductNotFound), errors.Is(err, usecase.ErrProductVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidVariants), errors.Is(err, entity.ErrInvalidCursor),
+		errors.Is(err, usecase.ErrInvalidSearchSort), errors.Is(err, usecase.ErrInvalidPriceRange),
+		errors.Is(err, usecase.ErrUnknownCategory), errors.Is(err, usecase.ErrInvalidAttributes),
+		errors.Is(err, usecase.ErrInvalidAttributeFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
 }
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// multipartOverhead - room for multipart headers on top of the image size limit
+const multipartOverhead = 1 << 20
+
+type imageOrderReq struct {
+	ImageIDs []string `json:"imageIDs" binding:"required"`
+}
+
+// UploadProductImage accepts the image as the multipart "image" field
+func UploadProductImage(uc *usecase.ProductImageUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, uc.MaxUploadSize()+multipartOverhead)
+		file, _, err := c.Request.FormFile("image")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": usecase.ErrImageTooLarge.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "image file is required"})
+			return
+		}
+		defer file.Close()
+
+		// One byte over the limit is enough for the use case to reject the file
+		data, err := io.ReadAll(io.LimitReader(file, uc.MaxUploadSize()+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		product, err := uc.Upload(c.Request.Context(), id, data)
+		if err != nil {
+			writeProductImageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, product)
+	}
+}
+
+func DeleteProductImage(uc *usecase.ProductImageUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		product, err := uc.Delete(c.Request.Context(), id, c.Param("imageId"))
+		if err != nil {
+			writeProductImageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func ReorderProductImages(uc *usecase.ProductImageUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		var req imageOrderReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		product, err := uc.Reorder(c.Request.Context(), id, req.ImageIDs)
+		if err != nil {
+			writeProductImageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+// ServeMedia serves stored files; keys are unique per upload so they can be cached forever
+func ServeMedia(uc *usecase.ProductImageUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("key"), "/")
+
+		file, err := uc.Open(c.Request.Context(), key)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+	}
+}
+
+func writeProductImageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrProductNotFound), errors.Is(err, usecase.ErrImageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrUnsupportedImageType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidImage), errors.Is(err, usecase.ErrInvalidImageOrder):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrImagesChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+)
+
+// RunProductPurge triggers the purge of expired archived products without waiting for the schedule
+func RunProductPurge(uc *usecase.ProductPurgeUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		purged, err := uc.Run(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "purged": purged})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"purged": purged})
+	}
+}
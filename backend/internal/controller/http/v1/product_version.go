@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func ListProductHistory(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		versions, info, err := uc.History(c.Request.Context(), id, page)
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("versions", versions, info))
+	}
+}
+
+func GetProductVersion(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, version, ok := parseProductVersion(c)
+		if !ok {
+			return
+		}
+
+		v, err := uc.GetVersion(c.Request.Context(), id, version)
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, v)
+	}
+}
+
+// RollbackProduct restores the product to the given version and returns the restored product
+func RollbackProduct(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, version, ok := parseProductVersion(c)
+		if !ok {
+			return
+		}
+
+		product, err := uc.Rollback(c.Request.Context(), getUserIDFromContext(c), id, version)
+		if err != nil {
+			writeProductError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, product)
+	}
+}
+
+func parseProductVersion(c *gin.Context) (bson.ObjectID, int, bool) {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+		return bson.NilObjectID, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return bson.NilObjectID, 0, false
+	}
+	return id, version, true
+}
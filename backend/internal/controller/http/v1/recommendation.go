@@ -20,7 +20,7 @@ func GetRecommendations(uc *usecase.RecommendationUseCase) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, recommendations)
+		c.JSON(http.StatusOK, localizeRecommendation(c, recommendations))
 	}
 }
 
@@ -35,7 +35,7 @@ func GetCollaborativeRecommendations(uc *usecase.RecommendationUseCase) gin.Hand
 			return
 		}
 
-		c.JSON(http.StatusOK, recommendations)
+		c.JSON(http.StatusOK, localizeRecommendation(c, recommendations))
 	}
 }
 
@@ -50,7 +50,7 @@ func GetContentBasedRecommendations(uc *usecase.RecommendationUseCase) gin.Handl
 			return
 		}
 
-		c.JSON(http.StatusOK, recommendations)
+		c.JSON(http.StatusOK, localizeRecommendation(c, recommendations))
 	}
 }
 
@@ -70,6 +70,6 @@ func GetRelatedProducts(uc *usecase.RecommendationUseCase) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"products": products})
+		c.JSON(http.StatusOK, gin.H{"products": localizeProducts(c, products)})
 	}
 }
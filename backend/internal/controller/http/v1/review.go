@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type reviewReq struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"max=200"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+func ListProductReviews(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		page, err := parsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		reviews, info, err := uc.GetProductReviews(c.Request.Context(), productID, entity.ReviewSort(c.Query("sort")), page)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, pageResponse("reviews", reviews, info))
+	}
+}
+
+func CreateReview(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		productID, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		var req reviewReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		review := &entity.Review{
+			ProductID: productID,
+			UserID:    userID,
+			Rating:    req.Rating,
+			Title:     req.Title,
+			Body:      req.Body,
+		}
+		if err := uc.Create(c.Request.Context(), review); err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+func UpdateReview(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reviewID"})
+			return
+		}
+
+		var req reviewReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		review, err := uc.Update(c.Request.Context(), userID, id, req.Rating, req.Title, req.Body)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func DeleteReview(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reviewID"})
+			return
+		}
+
+		if err := uc.Delete(c.Request.Context(), userID, id); err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Review deleted"})
+	}
+}
+
+func VoteReviewHelpful(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reviewID"})
+			return
+		}
+
+		review, err := uc.VoteHelpful(c.Request.Context(), userID, id)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func RemoveReviewHelpfulVote(uc *usecase.ReviewUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reviewID"})
+			return
+		}
+
+		review, err := uc.RemoveHelpfulVote(c.Request.Context(), userID, id)
+		if err != nil {
+			writeReviewError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+func writeReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrReviewNotFound), errors.Is(err, usecase.ErrProductNotFound),
+		errors.Is(err, usecase.ErrHelpfulVoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrReviewExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidRating), errors.Is(err, usecase.ErrInvalidReviewSort),
+		errors.Is(err, usecase.ErrOwnReviewVote), errors.Is(err, entity.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -7,13 +7,28 @@ import (
 )
 
 type UseCases struct {
-	User           *usecase.UserUseCase
-	Product        *usecase.ProductUseCase
-	Interaction    *usecase.InteractionUseCase
-	Recommendation *usecase.RecommendationUseCase
+	User            *usecase.UserUseCase
+	Product         *usecase.ProductUseCase
+	Interaction     *usecase.InteractionUseCase
+	Recommendation  *usecase.RecommendationUseCase
+	Wishlist        *usecase.WishlistUseCase
+	Guest           *usecase.GuestUseCase
+	CartRecovery    *usecase.CartRecoveryUseCase
+	Catalog         *usecase.CatalogUseCase
+	Review          *usecase.ReviewUseCase
+	Category        *usecase.CategoryUseCase
+	ProductImage    *usecase.ProductImageUseCase
+	ProductPurge    *usecase.ProductPurgeUseCase
+	Suggest         *usecase.SuggestUseCase
+	Merchandising   *usecase.MerchandisingUseCase
+	SearchAnalytics *usecase.SearchAnalyticsUseCase
+	Currency        *usecase.CurrencyUseCase
+	PriceAlert      *usecase.PriceAlertUseCase
+	Locale          *usecase.LocaleUseCase
+	Translation     *usecase.TranslationUseCase
 }
 
-func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseCases, auth gin.HandlerFunc) {
+func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseCases, auth, guestAuth gin.HandlerFunc) {
 	handler.Use(gin.Recovery())
 	handler.Use(corsMiddleware())
 	handler.Use(loggingMiddleware(l))
@@ -23,8 +38,13 @@ func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseC
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Uploaded files
+	handler.GET("/media/*key", ServeMedia(uc.ProductImage))
+
 	// api v1 group
 	h := handler.Group("/api/v1")
+	h.Use(currencyMiddleware(uc.Currency))
+	h.Use(localeMiddleware(uc.Locale))
 	{
 		// Authentication
 		authG := h.Group("/auth")
@@ -33,13 +53,23 @@ func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseC
 			authG.POST("/login", LoginUser(uc.User))
 		}
 
+		// Guest checkout
+		guest := h.Group("/guest")
+		{
+			guest.POST("/session", StartGuestSession(uc.Guest))
+			guest.GET("/history", guestAuth, GetGuestHistory(uc.Guest))
+		}
+
 		// Users (protected)
 		users := h.Group("/users")
 		users.Use(auth)
 		{
 			users.GET("/profile", GetUserProfile(uc.User))
 			users.PUT("/profile", UpdateUserProfile(uc.User))
+			users.POST("/guest-claims", RequestGuestClaim(uc.User))
+			users.POST("/guest-claims/confirm", ClaimGuests(uc.User))
 			users.GET("/history", GetUserHistory(uc.Interaction))
+			users.GET("/interactions", GetUserInteractions(uc.Interaction))
 		}
 
 		// Products
@@ -47,8 +77,71 @@ func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseC
 		{
 			products.GET("", ListProducts(uc.Product))
 			products.GET("/:id", GetProduct(uc.Product))
-			products.GET("/search", SearchProducts(uc.Product))
+			products.GET("/search", optional(guestAuth), SearchProducts(uc.Merchandising, uc.SearchAnalytics))
+			products.GET("/suggest", SuggestProducts(uc.Suggest))
+			products.GET("/on-sale", ListProductsOnSale(uc.Product))
+			products.GET("/compare", CompareProducts(uc.Product))
 			products.GET("/:id/related", GetRelatedProducts(uc.Recommendation))
+			products.GET("/:id/price-history", GetPriceHistory(uc.Product))
+			products.GET("/:id/reviews", ListProductReviews(uc.Review))
+			products.POST("/:id/reviews", auth, CreateReview(uc.Review))
+		}
+
+		// Currencies
+		h.GET("/currencies", GetCurrencyRates(uc.Currency))
+
+		// Exchange rates management (protected)
+		currenciesAdmin := h.Group("/admin/currencies")
+		currenciesAdmin.Use(auth)
+		{
+			currenciesAdmin.PUT("/rates", SetCurrencyRates(uc.Currency))
+			currenciesAdmin.DELETE("/rates/:code", DeleteCurrencyRate(uc.Currency))
+			currenciesAdmin.POST("/rates/reload", ReloadCurrencyRates(uc.Currency))
+		}
+
+		// Locales
+		h.GET("/locales", GetLocales(uc.Locale))
+
+		// Translations management (protected)
+		translationsAdmin := h.Group("/admin/translations")
+		translationsAdmin.Use(auth)
+		{
+			translationsAdmin.GET("/missing/products", ListMissingProductTranslations(uc.Translation))
+			translationsAdmin.GET("/missing/categories", ListMissingCategoryTranslations(uc.Translation))
+		}
+
+		// Categories
+		categories := h.Group("/categories")
+		{
+			categories.GET("", GetCategoryTree(uc.Category))
+			categories.GET("/:slug", GetCategory(uc.Category))
+			categories.GET("/:slug/products", BrowseCategory(uc.Category))
+			categories.GET("/:slug/attributes", GetCategoryAttributes(uc.Category))
+		}
+
+		// Categories management (protected)
+		categoriesAdmin := h.Group("/admin/categories")
+		categoriesAdmin.Use(auth)
+		{
+			categoriesAdmin.POST("", CreateCategory(uc.Category))
+			categoriesAdmin.POST("/sync", SyncCategories(uc.Category))
+			categoriesAdmin.PUT("/:id", UpdateCategory(uc.Category))
+			categoriesAdmin.DELETE("/:id", DeleteCategory(uc.Category))
+			categoriesAdmin.PUT("/:id/attributes", SetCategoryAttributes(uc.Category))
+			categoriesAdmin.POST("/:id/sales", AddCategorySale(uc.Category))
+			categoriesAdmin.DELETE("/:id/sales/:saleId", DeleteCategorySale(uc.Category))
+			categoriesAdmin.PUT("/:id/translations/:locale", SetCategoryTranslation(uc.Translation))
+			categoriesAdmin.DELETE("/:id/translations/:locale", DeleteCategoryTranslation(uc.Translation))
+		}
+
+		// Reviews (protected)
+		reviews := h.Group("/reviews")
+		reviews.Use(auth)
+		{
+			reviews.PUT("/:id", UpdateReview(uc.Review))
+			reviews.DELETE("/:id", DeleteReview(uc.Review))
+			reviews.POST("/:id/helpful", VoteReviewHelpful(uc.Review))
+			reviews.DELETE("/:id/helpful", RemoveReviewHelpfulVote(uc.Review))
 		}
 
 		// Products management (protected)
@@ -58,18 +151,104 @@ func NewRouterWithMiddleware(l *zap.SugaredLogger, handler *gin.Engine, uc *UseC
 			productsAdmin.POST("", CreateProduct(uc.Product))
 			productsAdmin.PUT("/:id", UpdateProduct(uc.Product))
 			productsAdmin.DELETE("/:id", DeleteProduct(uc.Product))
+			productsAdmin.GET("/archived", ListArchivedProducts(uc.Product))
+			productsAdmin.POST("/:id/restore", RestoreProduct(uc.Product))
+			productsAdmin.GET("/:id/history", ListProductHistory(uc.Product))
+			productsAdmin.GET("/:id/history/:version", GetProductVersion(uc.Product))
+			productsAdmin.POST("/:id/history/:version/rollback", RollbackProduct(uc.Product))
+			productsAdmin.POST("/purge", RunProductPurge(uc.ProductPurge))
+			productsAdmin.POST("/price-alerts/run", RunPriceAlerts(uc.PriceAlert))
+			productsAdmin.POST("/:id/sales", AddProductSale(uc.Product))
+			productsAdmin.DELETE("/:id/sales/:saleId", DeleteProductSale(uc.Product))
+			productsAdmin.PUT("/:id/translations/:locale", SetProductTranslation(uc.Translation))
+			productsAdmin.DELETE("/:id/translations/:locale", DeleteProductTranslation(uc.Translation))
+			productsAdmin.POST("/:id/images", UploadProductImage(uc.ProductImage))
+			productsAdmin.PUT("/:id/images/order", ReorderProductImages(uc.ProductImage))
+			productsAdmin.DELETE("/:id/images/:imageId", DeleteProductImage(uc.ProductImage))
+		}
+
+		// Search maintenance (protected)
+		searchAdmin := h.Group("/admin/search")
+		searchAdmin.Use(auth)
+		{
+			searchAdmin.POST("/suggest/rebuild", RebuildSuggestions(uc.Suggest))
+			searchAdmin.POST("/vocabulary/refresh", RefreshSearchVocabulary(uc.Product))
+			searchAdmin.POST("/reindex", ReindexProducts(uc.Product))
+			searchAdmin.GET("/preview", PreviewSearch(uc.Merchandising))
+			searchAdmin.GET("/analytics", GetSearchStats(uc.SearchAnalytics))
+			searchAdmin.GET("/analytics/top-queries", GetTopSearchQueries(uc.SearchAnalytics))
+			searchAdmin.GET("/analytics/zero-results", GetZeroResultQueries(uc.SearchAnalytics))
+
+			searchAdmin.GET("/synonyms", ListSynonymSets(uc.Merchandising))
+			searchAdmin.POST("/synonyms", CreateSynonymSet(uc.Merchandising))
+			searchAdmin.PUT("/synonyms/:id", UpdateSynonymSet(uc.Merchandising))
+			searchAdmin.DELETE("/synonyms/:id", DeleteSynonymSet(uc.Merchandising))
+
+			searchAdmin.GET("/rules", ListSearchRules(uc.Merchandising))
+			searchAdmin.POST("/rules", CreateSearchRule(uc.Merchandising))
+			searchAdmin.GET("/rules/:id", GetSearchRule(uc.Merchandising))
+			searchAdmin.PUT("/rules/:id", UpdateSearchRule(uc.Merchandising))
+			searchAdmin.DELETE("/rules/:id", DeleteSearchRule(uc.Merchandising))
 		}
 
-		// Interactions (protected)
+		// Bulk catalog import/export (protected)
+		catalogAdmin := h.Group("/admin/catalog")
+		catalogAdmin.Use(auth)
+		{
+			catalogAdmin.POST("/imports", ImportCatalog(uc.Catalog))
+			catalogAdmin.GET("/imports/:id", GetImportJob(uc.Catalog))
+			catalogAdmin.GET("/export", ExportCatalog(uc.Catalog))
+		}
+
+		// Cart recovery reports (protected)
+		cartsAdmin := h.Group("/admin/carts")
+		cartsAdmin.Use(auth)
+		{
+			cartsAdmin.GET("/recovery", GetCartRecoveryStats(uc.CartRecovery))
+			cartsAdmin.POST("/recovery/run", RunCartRecovery(uc.CartRecovery))
+		}
+
+		// Interactions (protected, guest tokens allowed)
 		interactions := h.Group("/interactions")
-		interactions.Use(auth)
+		interactions.Use(guestAuth)
 		{
 			interactions.POST("/view", RecordView(uc.Interaction))
 			interactions.POST("/like", RecordLike(uc.Interaction))
 			interactions.POST("/cart", RecordCart(uc.Interaction))
 			interactions.POST("/purchase", RecordPurchase(uc.Interaction))
+			interactions.POST("/search-click", RecordSearchClick(uc.SearchAnalytics))
+			interactions.POST("/compare", RecordComparison(uc.Interaction))
+		}
+
+		// Wishlists (protected)
+		wishlists := h.Group("/wishlists")
+		wishlists.Use(auth)
+		{
+			wishlists.GET("", ListWishlists(uc.Wishlist))
+			wishlists.POST("", CreateWishlist(uc.Wishlist))
+			wishlists.POST("/items", AddWishlistItem(uc.Wishlist))
+			wishlists.DELETE("/items", RemoveWishlistItem(uc.Wishlist))
+			wishlists.POST("/items/move-to-cart", MoveWishlistItemToCart(uc.Wishlist))
+			wishlists.GET("/:id", GetWishlist(uc.Wishlist))
+			wishlists.PUT("/:id", RenameWishlist(uc.Wishlist))
+			wishlists.DELETE("/:id", DeleteWishlist(uc.Wishlist))
+			wishlists.PUT("/:id/share", ShareWishlist(uc.Wishlist))
 		}
 
+		// Price drop alerts (protected)
+		priceWatches := h.Group("/price-watches")
+		priceWatches.Use(auth)
+		{
+			priceWatches.GET("", ListPriceWatches(uc.PriceAlert))
+			priceWatches.POST("", CreatePriceWatch(uc.PriceAlert))
+			priceWatches.GET("/candidates", ListPriceWatchCandidates(uc.PriceAlert))
+			priceWatches.PUT("/:id", UpdatePriceWatch(uc.PriceAlert))
+			priceWatches.DELETE("/:id", DeletePriceWatch(uc.PriceAlert))
+		}
+
+		// Shared wishlists (public)
+		h.GET("/shared/wishlists/:token", GetSharedWishlist(uc.Wishlist))
+
 		// Recommendations (protected)
 		recommendations := h.Group("/recommendations")
 		recommendations.Use(auth)
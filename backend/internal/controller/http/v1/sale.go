@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type saleReq struct {
+	Name string `json:"name"`
+	// Price - a fixed sale price in the request currency, products without variants only
+	Price      json.Number `json:"price"`
+	PercentOff float64     `json:"percentOff"`
+	StartsAt   time.Time   `json:"startsAt" binding:"required"`
+	EndsAt     time.Time   `json:"endsAt" binding:"required"`
+}
+
+// ListProductsOnSale lists products selling below their regular price now, the sales ending soonest first
+func ListProductsOnSale(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+		products, err := uc.OnSale(c.Request.Context(), limit)
+		if err != nil {
+			writeSaleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"products": localizeProducts(c, products)})
+	}
+}
+
+// AddProductSale schedules a sale price or a percentage off the product
+func AddProductSale(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		sale, ok := bindSale(c)
+		if !ok {
+			return
+		}
+
+		product, err := uc.AddSale(c.Request.Context(), id, sale)
+		if err != nil {
+			writeSaleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, localizeProduct(c, product))
+	}
+}
+
+func DeleteProductSale(uc *usecase.ProductUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, saleID, ok := parseSaleIDs(c)
+		if !ok {
+			return
+		}
+
+		product, err := uc.DeleteSale(c.Request.Context(), id, saleID)
+		if err != nil {
+			writeSaleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, localizeProduct(c, product))
+	}
+}
+
+// AddCategorySale schedules a percentage off every product of the category and its subcategories
+func AddCategorySale(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		sale, ok := bindSale(c)
+		if !ok {
+			return
+		}
+
+		category, err := uc.AddSale(c.Request.Context(), id, sale)
+		if err != nil {
+			writeSaleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, category)
+	}
+}
+
+func DeleteCategorySale(uc *usecase.CategoryUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, saleID, ok := parseSaleIDs(c)
+		if !ok {
+			return
+		}
+
+		category, err := uc.DeleteSale(c.Request.Context(), id, saleID)
+		if err != nil {
+			writeSaleError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, category)
+	}
+}
+
+func bindSale(c *gin.Context) (*entity.SaleWindow, bool) {
+	var req saleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	price, ok := parseOptionalPrice(c, req.Price)
+	if !ok {
+		return nil, false
+	}
+
+	return &entity.SaleWindow{
+		Name:       req.Name,
+		Price:      price,
+		PercentOff: req.PercentOff,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+	}, true
+}
+
+func parseSaleIDs(c *gin.Context) (bson.ObjectID, bson.ObjectID, bool) {
+	id, err := bson.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return bson.ObjectID{}, bson.ObjectID{}, false
+	}
+	saleID, err := bson.ObjectIDFromHex(c.Param("saleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sale ID"})
+		return bson.ObjectID{}, bson.ObjectID{}, false
+	}
+	return id, saleID, true
+}
+
+func writeSaleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrSaleNotFound), errors.Is(err, usecase.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidSale):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		writeProductError(c, err)
+	}
+}
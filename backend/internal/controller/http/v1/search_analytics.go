@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type searchClickReq struct {
+	SearchID  string `json:"searchID" binding:"required"`
+	ProductID string `json:"productID" binding:"required"`
+	Position  int    `json:"position" binding:"required,min=1"`
+}
+
+// RecordSearchClick records a click on a search result, position counts from 1 within the page of that search
+func RecordSearchClick(uc *usecase.SearchAnalyticsUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req searchClickReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		searchID, err := bson.ObjectIDFromHex(req.SearchID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid searchID"})
+			return
+		}
+		pid, err := bson.ObjectIDFromHex(req.ProductID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+			return
+		}
+
+		userID := getUserIDFromContext(c)
+		guestID, _, _ := getGuestFromContext(c)
+		if err := uc.RecordClick(c.Request.Context(), userID, guestID, searchID, pid, req.Position); err != nil {
+			writeSearchAnalyticsError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func GetSearchStats(uc *usecase.SearchAnalyticsUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since, ok := parseReportSince(c)
+		if !ok {
+			return
+		}
+
+		stats, err := uc.GetStats(c.Request.Context(), since)
+		if err != nil {
+			writeSearchAnalyticsError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	}
+}
+
+func GetTopSearchQueries(uc *usecase.SearchAnalyticsUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since, ok := parseReportSince(c)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+
+		queries, err := uc.TopQueries(c.Request.Context(), since, limit)
+		if err != nil {
+			writeSearchAnalyticsError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"since": since, "queries": queries})
+	}
+}
+
+// GetZeroResultQueries lists what people search for and don't find
+func GetZeroResultQueries(uc *usecase.SearchAnalyticsUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		since, ok := parseReportSince(c)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+
+		queries, err := uc.ZeroResultQueries(c.Request.Context(), since, limit)
+		if err != nil {
+			writeSearchAnalyticsError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"since": since, "queries": queries})
+	}
+}
+
+// parseReportSince reads the report period from ?days=, 30 days by default
+func parseReportSince(c *gin.Context) (time.Time, bool) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return time.Time{}, false
+	}
+	return time.Now().AddDate(0, 0, -days), true
+}
+
+func writeSearchAnalyticsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrSearchNotFound), errors.Is(err, usecase.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidClickPosition), errors.Is(err, usecase.ErrInvalidSearchReportLimit),
+		errors.Is(err, usecase.ErrProductNotInSearch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+)
+
+// SuggestProducts completes the search box prefix ?q= with up to ?limit= suggestions
+func SuggestProducts(uc *usecase.SuggestUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 0
+		if raw := c.Query("limit"); raw != "" {
+			var err error
+			if limit, err = strconv.Atoi(raw); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": usecase.ErrInvalidSuggestLimit.Error()})
+				return
+			}
+		}
+
+		suggestions, err := uc.Suggest(c.Request.Context(), c.Query("q"), limit)
+		if err != nil {
+			writeSuggestError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+	}
+}
+
+// RebuildSuggestions reindexes product names and categories without waiting for the schedule
+func RebuildSuggestions(uc *usecase.SuggestUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		terms, err := uc.Rebuild(c.Request.Context())
+		if err != nil {
+			writeSuggestError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"terms": terms})
+	}
+}
+
+func writeSuggestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSuggestLimit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
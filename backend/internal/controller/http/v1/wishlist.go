@@ -0,0 +1,265 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/m4rk1sov/ecommerce/internal/usecase"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type wishlistReq struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+type wishlistShareReq struct {
+	Public bool `json:"public"`
+}
+
+type wishlistItemReq struct {
+	ProductID  string `json:"productID" binding:"required"`
+	WishlistID string `json:"wishlistID"`
+	SKU        string `json:"sku"`
+}
+
+func ListWishlists(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+
+		wishlists, err := uc.GetLists(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"wishlists": wishlists})
+	}
+}
+
+func CreateWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		var req wishlistReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		wishlist, err := uc.CreateList(c.Request.Context(), userID, req.Name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, wishlist)
+	}
+}
+
+func GetWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlistID"})
+			return
+		}
+
+		wishlist, err := uc.GetList(c.Request.Context(), userID, id)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+
+func RenameWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlistID"})
+			return
+		}
+
+		var req wishlistReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		wishlist, err := uc.RenameList(c.Request.Context(), userID, id, req.Name)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+
+func DeleteWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlistID"})
+			return
+		}
+
+		if err := uc.DeleteList(c.Request.Context(), userID, id); err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Wishlist deleted"})
+	}
+}
+
+func ShareWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		id, err := bson.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlistID"})
+			return
+		}
+
+		var req wishlistShareReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		wishlist, err := uc.SetPublic(c.Request.Context(), userID, id, req.Public)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+
+func GetSharedWishlist(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wishlist, err := uc.GetShared(c.Request.Context(), c.Param("token"))
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"name":      wishlist.Name,
+			"items":     wishlist.Items,
+			"updatedAt": wishlist.UpdatedAt,
+		})
+	}
+}
+
+func AddWishlistItem(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		var req wishlistItemReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pid, wid, ok := parseWishlistItemReq(c, req)
+		if !ok {
+			return
+		}
+
+		wishlist, err := uc.AddItem(c.Request.Context(), userID, wid, pid)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+
+func RemoveWishlistItem(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		var req wishlistItemReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pid, wid, ok := parseWishlistItemReq(c, req)
+		if !ok {
+			return
+		}
+
+		wishlist, err := uc.RemoveItem(c.Request.Context(), userID, wid, pid)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, wishlist)
+	}
+}
+
+func MoveWishlistItemToCart(uc *usecase.WishlistUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getUserIDFromContext(c)
+		var req wishlistItemReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pid, wid, ok := parseWishlistItemReq(c, req)
+		if !ok {
+			return
+		}
+
+		wishlist, err := uc.MoveToCart(c.Request.Context(), userID, wid, pid, req.SKU)
+		if err != nil {
+			writeWishlistError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Moved to cart", "wishlist": wishlist})
+	}
+}
+
+// parseWishlistItemReq converts ids from the request; an empty wishlistID means the default list
+func parseWishlistItemReq(c *gin.Context, req wishlistItemReq) (bson.ObjectID, bson.ObjectID, bool) {
+	pid, err := bson.ObjectIDFromHex(req.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid productID"})
+		return bson.NilObjectID, bson.NilObjectID, false
+	}
+
+	wid := bson.NilObjectID
+	if req.WishlistID != "" {
+		wid, err = bson.ObjectIDFromHex(req.WishlistID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wishlistID"})
+			return bson.NilObjectID, bson.NilObjectID, false
+		}
+	}
+
+	return pid, wid, true
+}
+
+func writeWishlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrWishlistNotFound), errors.Is(err, usecase.ErrWishlistItemNotFound),
+		errors.Is(err, usecase.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrDefaultWishlistDelete),
+		errors.Is(err, usecase.ErrVariantRequired), errors.Is(err, usecase.ErrVariantNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
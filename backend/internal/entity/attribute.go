@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// AttributeFacetLimit - how many of the most common values an attribute facet lists
+const AttributeFacetLimit = 20
+
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+	// AttributeEnum - one of the attribute's Values
+	AttributeEnum AttributeType = "enum"
+)
+
+// AttributeDef - a specification the products of a category carry, e.g. a monitor's resolution.
+// Subcategories inherit the attributes of their ancestors and may redefine them by Key
+type AttributeDef struct {
+	Key   string        `bson:"key" json:"key"`
+	Label string        `bson:"label" json:"label"`
+	Type  AttributeType `bson:"type" json:"type"`
+	// Unit - shown after number values, e.g. "in" or "Hz"
+	Unit     string   `bson:"unit,omitempty" json:"unit,omitempty"`
+	Values   []string `bson:"values,omitempty" json:"values,omitempty"`
+	Required bool     `bson:"required" json:"required"`
+	// Facet - the attribute is counted in search facets
+	Facet bool `bson:"facet" json:"facet"`
+}
+
+// AttributeValues - product attribute values by key: string for text and enum attributes,
+// float64 for numbers and bool for booleans
+type AttributeValues map[string]any
+
+// AttributeFilter - matches products whose attribute equals any of Values and, for numbers,
+// lies within Min..Max
+type AttributeFilter struct {
+	Key    string   `json:"key"`
+	Values []any    `json:"values,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	// Raw - the values as given in the query, typed into Values by the attribute schema
+	Raw []string `json:"-"`
+}
+
+func (f AttributeFilter) Matches(values AttributeValues) bool {
+	v, ok := values[f.Key]
+	if !ok {
+		return false
+	}
+	if len(f.Values) > 0 && !slices.Contains(f.Values, v) {
+		return false
+	}
+	if f.Min != nil || f.Max != nil {
+		n, ok := v.(float64)
+		if !ok || (f.Min != nil && n < *f.Min) || (f.Max != nil && n > *f.Max) {
+			return false
+		}
+	}
+	return true
+}
+
+// AttributeFacet - value counts of a faceted attribute, values formatted as they are filtered by
+type AttributeFacet struct {
+	Key    string        `json:"key"`
+	Label  string        `json:"label"`
+	Type   AttributeType `json:"type"`
+	Unit   string        `json:"unit,omitempty"`
+	Values []FacetCount  `json:"values"`
+}
+
+// FormatAttributeValue formats a value for facets; numbers without trailing zeros
+func FormatAttributeValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
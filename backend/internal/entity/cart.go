@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// AbandonedCart - cart interactions of a user with no purchase of those products within the window
+type AbandonedCart struct {
+	UserID     bson.ObjectID   `bson:"_id" json:"userID"`
+	ProductIDs []bson.ObjectID `bson:"product_ids" json:"productIDs"`
+	LastCartAt time.Time       `bson:"last_cart_at" json:"lastCartAt"`
+}
+
+type CartReminder struct {
+	ID             bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID         bson.ObjectID   `bson:"user_id" json:"userID"`
+	ProductIDs     []bson.ObjectID `bson:"product_ids" json:"productIDs"`
+	LastCartAt     time.Time       `bson:"last_cart_at" json:"lastCartAt"`
+	SentAt         time.Time       `bson:"sent_at" json:"sentAt"`
+	Recovered      bool            `bson:"recovered" json:"recovered"`
+	RecoveredAt    *time.Time      `bson:"recovered_at,omitempty" json:"recoveredAt,omitempty"`
+	PurchaseID     bson.ObjectID   `bson:"purchase_id,omitempty" json:"purchaseID,omitempty"`
+	RecoveredTotal money.Amount    `bson:"recovered_total" json:"recoveredTotal"`
+}
+
+// CartReminderMessage is what a Notifier delivers to the user
+type CartReminderMessage struct {
+	User            *User      `json:"user"`
+	Products        []*Product `json:"products"`
+	Recommendations []*Product `json:"recommendations"`
+}
+
+type CartRecoveryStats struct {
+	Since            time.Time    `json:"since"`
+	RemindersSent    int          `json:"remindersSent"`
+	Recovered        int          `json:"recovered"`
+	ConversionRate   float64      `json:"conversionRate"`
+	RecoveredRevenue money.Amount `json:"recoveredRevenue"`
+}
+
+type CartRecoveryRun struct {
+	RemindersSent int `json:"remindersSent"`
+	// Failed - carts whose reminder couldn't be built or delivered, retried next run
+	Failed    int `json:"failed"`
+	Recovered int `json:"recovered"`
+}
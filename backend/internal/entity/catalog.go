@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CatalogFormat - file format of catalog imports and exports
+type CatalogFormat string
+
+const (
+	CatalogCSV   CatalogFormat = "csv"
+	CatalogJSONL CatalogFormat = "jsonl"
+)
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportJob tracks a bulk catalog import running in the background
+type ImportJob struct {
+	ID         bson.ObjectID    `bson:"_id,omitempty" json:"id"`
+	UserID     bson.ObjectID    `bson:"user_id,omitempty" json:"userId,omitempty"`
+	Format     CatalogFormat    `bson:"format" json:"format"`
+	Status     ImportStatus     `bson:"status" json:"status"`
+	TotalRows  int              `bson:"total_rows" json:"totalRows"`
+	Processed  int              `bson:"processed" json:"processed"`
+	Created    int              `bson:"created" json:"created"`
+	Updated    int              `bson:"updated" json:"updated"`
+	Failed     int              `bson:"failed" json:"failed"`
+	Errors     []ImportRowError `bson:"errors" json:"errors"`
+	Error      string           `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time        `bson:"created_at" json:"createdAt"`
+	StartedAt  *time.Time       `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time       `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+// ImportRowError - why a row was rejected; Row is 1-based and doesn't count the CSV header
+type ImportRowError struct {
+	Row         int    `bson:"row" json:"row"`
+	ExternalSKU string `bson:"external_sku,omitempty" json:"externalSku,omitempty"`
+	Error       string `bson:"error" json:"error"`
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Category - a node of the category tree. Products refer to categories by Name,
+// Ancestors lists the path from the root so a subtree can be found with a single query
+type Category struct {
+	ID   bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string        `bson:"name" json:"name"`
+	// DisplayName - Name in the locale the request reads content in, never stored. Products refer to
+	// categories by the default Name in every locale
+	DisplayName  string          `bson:"-" json:"displayName,omitempty"`
+	Translations Translations    `bson:"translations,omitempty" json:"translations,omitempty"`
+	Slug         string          `bson:"slug" json:"slug"`
+	ParentID     *bson.ObjectID  `bson:"parent_id,omitempty" json:"parentID,omitempty"`
+	Ancestors    []bson.ObjectID `bson:"ancestors" json:"ancestors"`
+	Order        int             `bson:"order" json:"order"`
+	// Attributes - the category's own attributes, products also carry the ancestors' ones
+	Attributes []AttributeDef `bson:"attributes,omitempty" json:"attributes,omitempty"`
+	// Sales - percentage sales on every product of the subtree
+	Sales     []SaleWindow `bson:"sales,omitempty" json:"sales,omitempty"`
+	CreatedAt time.Time    `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time    `bson:"updated_at" json:"updatedAt"`
+}
+
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "go.mongodb.org/mongo-driver/v2/bson"
+
+// ComparisonPrice - the type of the price row, its values are money.Amount in the base currency
+const ComparisonPrice = "price"
+
+// ProductComparison lines up 2 to 4 products side by side. Every row holds one value per product,
+// in the order of Products, nil where the product doesn't have it
+type ProductComparison struct {
+	Products []*Product      `json:"products"`
+	Rows     []ComparisonRow `json:"rows"`
+	// Signals - the "compared with" pairs to send back to POST /interactions/compare
+	// once the customer has looked at the comparison
+	Signals []ComparisonSignal `json:"signals"`
+}
+
+// ComparisonRow - a compared property: price, rating, review count, stock, category or an attribute.
+// Type is "price" or an AttributeType
+type ComparisonRow struct {
+	Key    string `json:"key"`
+	Label  string `json:"label"`
+	Type   string `json:"type"`
+	Unit   string `json:"unit,omitempty"`
+	Values []any  `json:"values"`
+	// Differs - not every product has the same value, missing values included
+	Differs bool `json:"differs"`
+}
+
+// ComparisonSignal - two products a customer weighed against each other, a hint they are substitutes
+type ComparisonSignal struct {
+	ProductID    bson.ObjectID `json:"productID"`
+	ComparedWith bson.ObjectID `json:"comparedWith"`
+}
+
+// NewComparisonRow builds a row and marks whether its values differ; values are scalars
+func NewComparisonRow(key, label, typ, unit string, values []any) ComparisonRow {
+	row := ComparisonRow{Key: key, Label: label, Type: typ, Unit: unit, Values: values}
+	for _, v := range values[1:] {
+		if v != values[0] {
+			row.Differs = true
+			break
+		}
+	}
+	return row
+}
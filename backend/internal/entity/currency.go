@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// ExchangeRate - units of Currency per unit of the base currency, kept as a decimal string so it is exact
+type ExchangeRate struct {
+	Currency  string    `bson:"_id" json:"currency"`
+	Rate      string    `bson:"rate" json:"rate"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// CurrencyRates - the base currency and the rates of the currencies prices can be shown in
+type CurrencyRates struct {
+	Base  string          `json:"base"`
+	Rates []*ExchangeRate `json:"rates"`
+}
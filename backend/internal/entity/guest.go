@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Guest is an anonymous checkout session keyed by an unverified email, claimed by the account with
+// that email once its owner redeems the claim token emailed to them
+type Guest struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Email     string        `bson:"email" json:"email"`
+	ClaimedBy bson.ObjectID `bson:"claimed_by,omitempty" json:"claimedBy,omitempty"`
+	ClaimedAt *time.Time    `bson:"claimed_at,omitempty" json:"claimedAt,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"createdAt"`
+}
+
+// GuestClaimMessage is what a Notifier delivers to the account email to prove it before guests are claimed
+type GuestClaimMessage struct {
+	User   *User    `json:"user"`
+	Guests []*Guest `json:"guests"`
+	Token  string   `json:"token"`
+}
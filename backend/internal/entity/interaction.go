@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -13,28 +14,50 @@ const (
 	InteractionLike     InteractionType = "like"
 	InteractionPurchase InteractionType = "purchase"
 	InteractionCart     InteractionType = "cart"
+	InteractionReview   InteractionType = "review"
+	// InteractionSearchClick - a click on a search result, carries the search, its query and the position clicked
+	InteractionSearchClick InteractionType = "search_click"
+	// InteractionCompare - the product was compared side by side with the ComparedWith products
+	InteractionCompare InteractionType = "compare"
 )
 
 type Interaction struct {
 	ID        bson.ObjectID   `bson:"_id,omitempty" json:"id"`
-	UserID    bson.ObjectID   `bson:"user_id" json:"userID"`
+	UserID    bson.ObjectID   `bson:"user_id,omitempty" json:"userID"`
+	GuestID   bson.ObjectID   `bson:"guest_id,omitempty" json:"guestID,omitempty"`
 	ProductID bson.ObjectID   `bson:"product_id" json:"productID"`
+	SKU       string          `bson:"sku,omitempty" json:"sku,omitempty"`
 	Type      InteractionType `bson:"type" json:"type"`
-	Weight    float64         `bson:"weight" json:"weight"` // view: 1, like: 3, cart: 5, purchase: 10
-	Timestamp time.Time       `bson:"timestamp" json:"timestamp"`
+	Weight    float64         `bson:"weight" json:"weight"` // view: 1, like: 3, cart: 5, purchase: 10, review: up to 6 by rating, search click: 1, compare: 2
+	SearchID  bson.ObjectID   `bson:"search_id,omitempty" json:"searchID,omitempty"`
+	Query     string          `bson:"query,omitempty" json:"query,omitempty"`
+	Position  int             `bson:"position,omitempty" json:"position,omitempty"`
+	// ComparedWith - the other products of a comparison
+	ComparedWith []bson.ObjectID `bson:"compared_with,omitempty" json:"comparedWith,omitempty"`
+	Timestamp    time.Time       `bson:"timestamp" json:"timestamp"`
 }
 
 type Purchase struct {
-	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id"`
-	UserID    bson.ObjectID  `bson:"user_id" json:"userID"`
-	Products  []PurchaseItem `bson:"products" json:"products"`
-	Total     float64        `bson:"total" json:"total"`
-	Status    string         `bson:"status" json:"status"`
-	CreatedAt time.Time      `bson:"created_at" json:"createdAt"`
+	ID         bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID     bson.ObjectID  `bson:"user_id,omitempty" json:"userID"`
+	GuestID    bson.ObjectID  `bson:"guest_id,omitempty" json:"guestID,omitempty"`
+	GuestEmail string         `bson:"guest_email,omitempty" json:"guestEmail,omitempty"`
+	Products   []PurchaseItem `bson:"products" json:"products"`
+	// Total and item prices are in the base currency. The order was placed in Currency at ExchangeRate
+	// (units of Currency per base unit), Paid is what the customer was charged in it
+	Total        money.Amount `bson:"total" json:"total"`
+	Currency     string       `bson:"currency" json:"currency"`
+	ExchangeRate string       `bson:"exchange_rate" json:"exchangeRate"`
+	Paid         money.Money  `bson:"paid" json:"paid"`
+	Status       string       `bson:"status" json:"status"`
+	CreatedAt    time.Time    `bson:"created_at" json:"createdAt"`
 }
 
 type PurchaseItem struct {
 	ProductID bson.ObjectID `bson:"product_id" json:"productID"`
+	SKU       string        `bson:"sku,omitempty" json:"sku,omitempty"`
 	Quantity  int           `bson:"quantity" json:"quantity"`
-	Price     float64       `bson:"price" json:"price"`
+	Price     money.Amount  `bson:"price" json:"price"`
+	// RegularPrice - the price before the sale the item was bought on, zero when it wasn't on sale
+	RegularPrice money.Amount `bson:"regular_price,omitempty" json:"regularPrice,omitempty"`
 }
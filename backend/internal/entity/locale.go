@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Translation - the text of a product or category in a locale other than the default one, the
+// default text is kept in the Name and Description of the product. Categories have no description
+type Translation struct {
+	Locale      string `bson:"locale" json:"locale"`
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// Translations - at most one translation per locale
+type Translations []Translation
+
+func (t Translations) Find(locale string) (Translation, bool) {
+	i := slices.IndexFunc(t, func(tr Translation) bool { return tr.Locale == locale })
+	if i < 0 {
+		return Translation{}, false
+	}
+	return t[i], true
+}
+
+// Localize returns the name and description in the first locale of chain that translates them, each
+// field falling back on its own, and the locale of the name. Text no locale of the chain translates
+// stays in the default locale, reported as ""
+func (t Translations) Localize(chain []string, name, description string) (string, string, string) {
+	locale := ""
+	nameDone, descriptionDone := false, false
+	for _, l := range chain {
+		tr, ok := t.Find(l)
+		if !ok {
+			continue
+		}
+		if !nameDone && tr.Name != "" {
+			name, locale, nameDone = tr.Name, l, true
+		}
+		if !descriptionDone && tr.Description != "" {
+			description, descriptionDone = tr.Description, true
+		}
+	}
+	return name, description, locale
+}
+
+// Missing lists the locales without a translation or, when the default text has a description,
+// whose translation lacks one
+func (t Translations) Missing(locales []string, hasDescription bool) []string {
+	var missing []string
+	for _, l := range locales {
+		tr, ok := t.Find(l)
+		if !ok || tr.Name == "" || (hasDescription && tr.Description == "") {
+			missing = append(missing, l)
+		}
+	}
+	return missing
+}
+
+// MissingTranslations - a product or category with the locales it still needs translating to
+type MissingTranslations struct {
+	ID      bson.ObjectID `json:"id"`
+	Name    string        `json:"name"`
+	Locales []string      `json:"locales"`
+}
+
+// Locales - the default locale of the catalog and the locales it can be translated to
+type Locales struct {
+	Default   string   `json:"default"`
+	Supported []string `json:"supported"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "errors"
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// PageRequest - Cursor is the opaque NextCursor of the previous page, empty for the first page
+type PageRequest struct {
+	Cursor string
+	Limit  int
+}
+
+type PageInfo struct {
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
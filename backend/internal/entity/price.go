@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PriceChange - a price set on a product, or on one of its variants when SKU is given. The product price
+// of a product with variants is its lowest variant price
+type PriceChange struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID bson.ObjectID `bson:"product_id" json:"productID"`
+	SKU       string        `bson:"sku" json:"sku,omitempty"`
+	Price     money.Amount  `bson:"price" json:"price"`
+	// PreviousPrice - nil for the first recorded price
+	PreviousPrice *money.Amount `bson:"previous_price,omitempty" json:"previousPrice,omitempty"`
+	ChangedAt     time.Time     `bson:"changed_at" json:"changedAt"`
+	// Alerted - price watches have been checked against the change
+	Alerted bool `bson:"alerted" json:"-"`
+}
+
+// PricePoint - a price of a product at a point in time
+type PricePoint struct {
+	At           time.Time    `json:"at"`
+	Price        money.Amount `json:"price"`
+	DisplayPrice *money.Money `json:"displayPrice,omitempty"`
+}
+
+// PriceHistory - a price over a period, ready for a step chart: the first point is the price at Since,
+// each following one a change and the last one the current price
+type PriceHistory struct {
+	ProductID bson.ObjectID `json:"productID"`
+	SKU       string        `json:"sku,omitempty"`
+	Since     time.Time     `json:"since"`
+	Current   PricePoint    `json:"current"`
+	Lowest    PricePoint    `json:"lowest"`
+	Highest   PricePoint    `json:"highest"`
+	Points    []PricePoint  `json:"points"`
+}
+
+// PriceWatch - a user waiting for a product (or variant) to get cheaper. Without a TargetPrice every drop
+// is reported, with one only drops to or below it. ReferencePrice is the last price the watch has seen
+type PriceWatch struct {
+	ID             bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         bson.ObjectID `bson:"user_id" json:"userID"`
+	ProductID      bson.ObjectID `bson:"product_id" json:"productID"`
+	SKU            string        `bson:"sku" json:"sku,omitempty"`
+	TargetPrice    *money.Amount `bson:"target_price,omitempty" json:"targetPrice,omitempty"`
+	ReferencePrice money.Amount  `bson:"reference_price" json:"referencePrice"`
+	NotifiedAt     *time.Time    `bson:"notified_at,omitempty" json:"notifiedAt,omitempty"`
+	CreatedAt      time.Time     `bson:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time     `bson:"updated_at" json:"updatedAt"`
+}
+
+// PriceAlertMessage is what a Notifier delivers when a watched price drops
+type PriceAlertMessage struct {
+	User     *User        `json:"user"`
+	Product  *Product     `json:"product"`
+	Watch    *PriceWatch  `json:"watch"`
+	OldPrice money.Amount `json:"oldPrice"`
+	NewPrice money.Amount `json:"newPrice"`
+}
+
+const (
+	PriceAlertFromWishlist = "wishlist"
+	PriceAlertFromLike     = "like"
+)
+
+// PriceAlertCandidate - a wishlisted or liked product the user doesn't watch yet
+type PriceAlertCandidate struct {
+	Product *Product `json:"product"`
+	Source  string   `json:"source"` // wishlist or like
+}
+
+type PriceAlertRun struct {
+	Changes    int `json:"changes"`
+	AlertsSent int `json:"alertsSent"`
+	// Failed - alerts that couldn't be delivered, logged and not retried for the same change
+	Failed int `json:"failed"`
+}
@@ -3,20 +3,92 @@ package entity
 import (
 	"time"
 
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Product struct {
 	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	ExternalSKU string        `bson:"external_sku,omitempty" json:"externalSku,omitempty"`
 	Name        string        `bson:"name" json:"name"`
 	Description string        `bson:"description" json:"description"`
-	Category    string        `bson:"category" json:"category"`
-	Price       float64       `bson:"price" json:"price"`
-	ImageURL    string        `bson:"image_url" json:"imageUrl"`
-	Stock       int           `bson:"stock" json:"stock"`
-	Tags        []string      `bson:"tags" json:"tags"`
-	Rating      float64       `bson:"rating" json:"rating"`
-	ReviewCount int           `bson:"review_count" json:"reviewCount"`
-	CreatedAt   time.Time     `bson:"created_at" json:"createdAt"`
-	UpdatedAt   time.Time     `bson:"updated_at" json:"updatedAt"`
+	// Translations - Name and Description in other locales, managed apart from the other fields
+	Translations Translations `bson:"translations,omitempty" json:"translations,omitempty"`
+	// Locale - the locale Name is shown in, set when a request reads the product translated
+	Locale   string       `bson:"-" json:"locale,omitempty"`
+	Category string       `bson:"category" json:"category"`
+	Price    money.Amount `bson:"price" json:"price"`
+	// DisplayPrice - Price in the currency the request asked for, never stored
+	DisplayPrice *money.Money `bson:"-" json:"displayPrice,omitempty"`
+	// Sales - scheduled sale windows, managed apart from the other fields
+	Sales []SaleWindow `bson:"sales,omitempty" json:"sales,omitempty"`
+	// SalePrice - the lowest price of the sales in effect, with SaleEndsAt the end of that sale.
+	// Both are computed on read, never stored
+	SalePrice        *money.Amount `bson:"-" json:"salePrice,omitempty"`
+	DisplaySalePrice *money.Money  `bson:"-" json:"displaySalePrice,omitempty"`
+	SaleEndsAt       *time.Time    `bson:"-" json:"saleEndsAt,omitempty"`
+	// CurrentPrice - the effective price when prices were last refreshed, stored so that search can
+	// filter and sort on sale prices. Kept by the use case as sales start and end
+	CurrentPrice money.Amount   `bson:"current_price" json:"-"`
+	ImageURL     string         `bson:"image_url" json:"imageUrl"`
+	Images       []ProductImage `bson:"images,omitempty" json:"images,omitempty"`
+	Stock        int            `bson:"stock" json:"stock"`
+	Tags         []string       `bson:"tags" json:"tags"`
+	// Attributes - values of the attributes the category defines
+	Attributes  AttributeValues  `bson:"attributes" json:"attributes,omitempty"`
+	Rating      float64          `bson:"rating" json:"rating"`
+	ReviewCount int              `bson:"review_count" json:"reviewCount"`
+	Options     []ProductOption  `bson:"options" json:"options,omitempty"`
+	Variants    []ProductVariant `bson:"variants" json:"variants,omitempty"`
+	ArchivedAt  *time.Time       `bson:"archived_at,omitempty" json:"archivedAt,omitempty"`
+	CreatedAt   time.Time        `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time        `bson:"updated_at" json:"updatedAt"`
+}
+
+// ProductImage is an uploaded image. Images are kept in display order and the first one
+// is mirrored to Product.ImageURL
+type ProductImage struct {
+	ID           string    `bson:"id" json:"id"`
+	URL          string    `bson:"url" json:"url"`
+	ThumbnailURL string    `bson:"thumbnail_url" json:"thumbnailUrl"`
+	Key          string    `bson:"key" json:"-"`
+	ThumbnailKey string    `bson:"thumbnail_key" json:"-"`
+	ContentType  string    `bson:"content_type" json:"contentType"`
+	Size         int64     `bson:"size" json:"size"`
+	Width        int       `bson:"width" json:"width"`
+	Height       int       `bson:"height" json:"height"`
+	UploadedAt   time.Time `bson:"uploaded_at" json:"uploadedAt"`
+}
+
+// ProductOption is a variant axis such as size or colour
+type ProductOption struct {
+	Name   string   `bson:"name" json:"name"`
+	Values []string `bson:"values" json:"values"`
+}
+
+// ProductVariant is a sellable SKU. When a product has variants, its Price is the
+// lowest variant price and its Stock the total over all variants
+type ProductVariant struct {
+	SKU     string            `bson:"sku" json:"sku"`
+	Options map[string]string `bson:"options" json:"options"`
+	Price   money.Amount      `bson:"price" json:"price"`
+	// DisplayPrice - Price in the currency the request asked for, never stored
+	DisplayPrice *money.Money `bson:"-" json:"displayPrice,omitempty"`
+	// SalePrice - computed on read like Product.SalePrice
+	SalePrice        *money.Amount `bson:"-" json:"salePrice,omitempty"`
+	DisplaySalePrice *money.Money  `bson:"-" json:"displaySalePrice,omitempty"`
+	// CurrentPrice - stored like Product.CurrentPrice
+	CurrentPrice money.Amount `bson:"current_price" json:"-"`
+	Stock        int          `bson:"stock" json:"stock"`
+	ImageURL     string       `bson:"image_url,omitempty" json:"imageUrl,omitempty"`
+}
+
+// Variant finds a variant by SKU
+func (p *Product) Variant(sku string) (*ProductVariant, bool) {
+	for i := range p.Variants {
+		if p.Variants[i].SKU == sku {
+			return &p.Variants[i], true
+		}
+	}
+	return nil, false
 }
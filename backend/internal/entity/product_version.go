@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProductChange - what an admin did to a product
+type ProductChange string
+
+const (
+	ProductCreated    ProductChange = "create"
+	ProductUpdated    ProductChange = "update"
+	ProductImported   ProductChange = "import"
+	ProductArchived   ProductChange = "archive"
+	ProductRestored   ProductChange = "restore"
+	ProductRolledBack ProductChange = "rollback"
+)
+
+// ProductVersion records one admin change of a product. Versions are numbered from 1 per product,
+// Snapshot is the product as it was right after the change
+type ProductVersion struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID bson.ObjectID `bson:"product_id" json:"productId"`
+	Version   int           `bson:"version" json:"version"`
+	Change    ProductChange `bson:"change" json:"change"`
+	UserID    bson.ObjectID `bson:"user_id,omitempty" json:"userId,omitempty"`
+	Diff      []FieldChange `bson:"diff" json:"diff"`
+	// RolledBackTo - the version a rollback restored
+	RolledBackTo int       `bson:"rolled_back_to,omitempty" json:"rolledBackTo,omitempty"`
+	Snapshot     *Product  `bson:"snapshot" json:"snapshot"`
+	CreatedAt    time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// FieldChange - old and new value of a product field, named as in the API
+type FieldChange struct {
+	Field string      `bson:"field" json:"field"`
+	Old   interface{} `bson:"old" json:"old"`
+	New   interface{} `bson:"new" json:"new"`
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ReviewSort string
+
+const (
+	ReviewSortNewest  ReviewSort = "newest"
+	ReviewSortHelpful ReviewSort = "helpful"
+)
+
+// Review - one review per user and product. VerifiedPurchase is set when the author bought the product
+type Review struct {
+	ID               bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	ProductID        bson.ObjectID   `bson:"product_id" json:"productID"`
+	UserID           bson.ObjectID   `bson:"user_id" json:"userID"`
+	Rating           int             `bson:"rating" json:"rating"`
+	Title            string          `bson:"title" json:"title"`
+	Body             string          `bson:"body" json:"body"`
+	VerifiedPurchase bool            `bson:"verified_purchase" json:"verifiedPurchase"`
+	HelpfulCount     int             `bson:"helpful_count" json:"helpfulCount"`
+	HelpfulVoters    []bson.ObjectID `bson:"helpful_voters" json:"-"`
+	CreatedAt        time.Time       `bson:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time       `bson:"updated_at" json:"updatedAt"`
+}
+
+// RatingSummary - aggregate of all reviews of a product
+type RatingSummary struct {
+	Average float64 `bson:"average" json:"average"`
+	Count   int     `bson:"count" json:"count"`
+}
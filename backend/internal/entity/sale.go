@@ -0,0 +1,129 @@
+package entity
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SaleWindow - a sale price in effect from StartsAt until EndsAt. A window either sets a fixed Price,
+// only on products without variants, or takes PercentOff the regular price of the product or of
+// every variant. Category windows are percentage only and apply to the whole subtree
+type SaleWindow struct {
+	ID         bson.ObjectID `bson:"id" json:"id"`
+	Name       string        `bson:"name,omitempty" json:"name,omitempty"`
+	Price      *money.Amount `bson:"price,omitempty" json:"price,omitempty"`
+	PercentOff float64       `bson:"percent_off,omitempty" json:"percentOff,omitempty"`
+	StartsAt   time.Time     `bson:"starts_at" json:"startsAt"`
+	EndsAt     time.Time     `bson:"ends_at" json:"endsAt"`
+}
+
+// ActiveAt reports whether the sale is in effect at t; the end is exclusive
+func (w *SaleWindow) ActiveAt(t time.Time) bool {
+	return !t.Before(w.StartsAt) && t.Before(w.EndsAt)
+}
+
+// Apply returns the sale price of a regular price, never above it
+func (w *SaleWindow) Apply(price money.Amount) money.Amount {
+	if w.Price != nil {
+		return min(*w.Price, price)
+	}
+	off := money.Amount(math.Round(float64(price) * w.PercentOff / 100))
+	return price - off
+}
+
+// ApplySales sets the sale prices of the product and its variants from the windows in effect at t,
+// the lowest price wins. Sale prices are computed on every read, SetCurrentPrices stores them for search
+func (p *Product) ApplySales(windows []SaleWindow, t time.Time) {
+	p.SalePrice = nil
+	p.SaleEndsAt = nil
+	// Variants may be shared with a cache or a search index
+	p.Variants = slices.Clone(p.Variants)
+	for i := range p.Variants {
+		p.Variants[i].SalePrice = nil
+	}
+
+	var active []SaleWindow
+	for _, w := range windows {
+		if w.ActiveAt(t) {
+			active = append(active, w)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	if len(p.Variants) == 0 {
+		if price, endsAt := lowestSalePrice(active, p.Price); price < p.Price {
+			p.SalePrice, p.SaleEndsAt = &price, &endsAt
+		}
+		return
+	}
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		price, endsAt := lowestSalePrice(active, v.Price)
+		if price >= v.Price {
+			continue
+		}
+		v.SalePrice = &price
+		// The product price is the lowest variant price, and so is its sale price
+		if price < p.Price && (p.SalePrice == nil || price < *p.SalePrice) {
+			p.SalePrice, p.SaleEndsAt = &price, &endsAt
+		}
+	}
+}
+
+// lowestSalePrice returns the lowest price the windows give and when the window giving it ends
+func lowestSalePrice(windows []SaleWindow, price money.Amount) (money.Amount, time.Time) {
+	lowest, endsAt := price, time.Time{}
+	for i := range windows {
+		if p := windows[i].Apply(price); p < lowest {
+			lowest, endsAt = p, windows[i].EndsAt
+		}
+	}
+	return lowest, endsAt
+}
+
+// EffectivePrice is the price the product sells at now, the sale price while a sale is in effect
+func (p *Product) EffectivePrice() money.Amount {
+	if p.SalePrice != nil {
+		return *p.SalePrice
+	}
+	return p.Price
+}
+
+func (v *ProductVariant) EffectivePrice() money.Amount {
+	if v.SalePrice != nil {
+		return *v.SalePrice
+	}
+	return v.Price
+}
+
+// SetCurrentPrices stores the effective prices of the product and its variants, with their sales applied,
+// as their current prices. Reports whether any current price changed
+func (p *Product) SetCurrentPrices() bool {
+	changed := p.CurrentPrice != p.EffectivePrice()
+	p.CurrentPrice = p.EffectivePrice()
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		changed = changed || v.CurrentPrice != v.EffectivePrice()
+		v.CurrentPrice = v.EffectivePrice()
+	}
+	return changed
+}
+
+// ListedPrice is the price search lists the product at: the sale price once sales are applied, the stored
+// current price otherwise. Products stored before current prices were kept fall back to the regular price
+func (p *Product) ListedPrice() money.Amount {
+	switch {
+	case p.SalePrice != nil:
+		return *p.SalePrice
+	case p.CurrentPrice > 0:
+		return p.CurrentPrice
+	default:
+		return p.Price
+	}
+}
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"slices"
+
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PriceFacetBoundaries - lower bounds of the price facet buckets in the base currency,
+// everything above the last one is a single bucket
+var PriceFacetBoundaries = []money.Amount{0, 2500, 5000, 10000, 25000, 50000, 100000}
+
+// TagFacetLimit - how many of the most common tags the tag facet lists
+const TagFacetLimit = 20
+
+type SearchSort string
+
+const (
+	SortRelevance SearchSort = "relevance"
+	SortPriceAsc  SearchSort = "price_asc"
+	SortPriceDesc SearchSort = "price_desc"
+	SortRating    SearchSort = "rating"
+	SortNewest    SearchSort = "newest"
+)
+
+// ProductSearchParams - filters are combined with AND; Categories and Tags match any of the given values
+type ProductSearchParams struct {
+	Query      string        `json:"query"`
+	Categories []string      `json:"categories"`
+	Tags       []string      `json:"tags"`
+	MinPrice   *money.Amount `json:"minPrice,omitempty"`
+	MaxPrice   *money.Amount `json:"maxPrice,omitempty"`
+	MinRating  float64       `json:"minRating"`
+	InStock    bool          `json:"inStock"`
+	// Attributes - filters on category attributes, all of them must match
+	Attributes []AttributeFilter `json:"attributes,omitempty"`
+	Sort       SearchSort        `json:"sort"`
+	Page       PageRequest       `json:"page"`
+	// FuzzyTerms - catalog words close to misspelled query words, matched in addition to the query
+	FuzzyTerms []string `json:"-"`
+	// SynonymTerms - synonyms of query words, matched like the query words themselves
+	SynonymTerms []string `json:"-"`
+	// Boosts - relevance multipliers of merchandising rules, only used when ranking by relevance
+	Boosts []SearchBoost `json:"-"`
+	// ExcludeIDs - products left out of the results, e.g. because they are pinned in front of them
+	ExcludeIDs []bson.ObjectID `json:"-"`
+	// AttributeFacets - keys of the attributes to count values of
+	AttributeFacets []string `json:"-"`
+	// Untracked - the query isn't logged for suggestions, used by admin previews
+	Untracked bool `json:"-"`
+}
+
+// Accepts reports whether the product passes the filters, the query isn't considered
+func (params ProductSearchParams) Accepts(p *Product) bool {
+	switch {
+	case len(params.Categories) > 0 && !slices.Contains(params.Categories, p.Category):
+		return false
+	case len(params.Tags) > 0 && !slices.ContainsFunc(p.Tags, func(t string) bool { return slices.Contains(params.Tags, t) }):
+		return false
+	case params.MinPrice != nil && p.ListedPrice() < *params.MinPrice:
+		return false
+	case params.MaxPrice != nil && p.ListedPrice() > *params.MaxPrice:
+		return false
+	case params.MinRating > 0 && p.Rating < params.MinRating:
+		return false
+	case params.InStock && p.Stock <= 0:
+		return false
+	case slices.Contains(params.ExcludeIDs, p.ID):
+		return false
+	}
+	for _, f := range params.Attributes {
+		if !f.Matches(p.Attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+type ProductSearchResult struct {
+	Products []*Product   `json:"products"`
+	Facets   SearchFacets `json:"facets"`
+	Page     PageInfo     `json:"page"`
+	// FuzzyTerms - corrections that were searched for misspelled query words
+	FuzzyTerms []string `json:"fuzzyTerms,omitempty"`
+	// DidYouMean - a corrected query, offered when nothing was found
+	DidYouMean string `json:"didYouMean,omitempty"`
+	// SynonymTerms - synonyms that were searched in addition to the query
+	SynonymTerms []string `json:"synonymTerms,omitempty"`
+	// Pinned - products placed in front of the results by merchandising rules
+	Pinned []bson.ObjectID `json:"pinned,omitempty"`
+}
+
+type SearchFacets struct {
+	Categories   []FacetCount  `json:"categories"`
+	PriceBuckets []PriceBucket `json:"priceBuckets"`
+	Tags         []FacetCount  `json:"tags"`
+	// Attributes - in the order of the attribute schema, only attributes found in the results
+	Attributes []AttributeFacet `json:"attributes,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `bson:"_id" json:"value"`
+	Count int    `bson:"count" json:"count"`
+}
+
+// PriceBucket - Max is nil for the open-ended top bucket
+type PriceBucket struct {
+	Min   money.Amount  `json:"min"`
+	Max   *money.Amount `json:"max,omitempty"`
+	Count int           `json:"count"`
+}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SearchLog - one search request with its total result count. Query is normalized, NextPage marks
+// requests for further pages of a search, which the reports leave out. ProductIDs are the products
+// the request returned in page order, ClickedPositions the result positions already counted as clicks
+type SearchLog struct {
+	ID               bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Query            string          `bson:"query" json:"query"`
+	UserID           bson.ObjectID   `bson:"user_id,omitempty" json:"userID,omitempty"`
+	GuestID          bson.ObjectID   `bson:"guest_id,omitempty" json:"guestID,omitempty"`
+	Results          int             `bson:"results" json:"results"`
+	LatencyMs        float64         `bson:"latency_ms" json:"latencyMs"`
+	NextPage         bool            `bson:"next_page" json:"nextPage"`
+	Clicks           int             `bson:"clicks" json:"clicks"`
+	ClickPositionSum int             `bson:"click_position_sum" json:"-"`
+	ProductIDs       []bson.ObjectID `bson:"product_ids,omitempty" json:"-"`
+	ClickedPositions []int           `bson:"clicked_positions,omitempty" json:"-"`
+	CreatedAt        time.Time       `bson:"created_at" json:"createdAt"`
+}
+
+// SearchQueryStats - how a single query performed over a period
+type SearchQueryStats struct {
+	Query           string    `bson:"_id" json:"query"`
+	Searches        int       `bson:"searches" json:"searches"`
+	AvgResults      float64   `bson:"avg_results" json:"avgResults"`
+	ClickedSearches int       `bson:"clicked" json:"clickedSearches"`
+	CTR             float64   `bson:"-" json:"ctr"`
+	LastSearchedAt  time.Time `bson:"last_searched_at" json:"lastSearchedAt"`
+}
+
+// SearchStats - search totals over a period. CTR is the share of searches with at least one result click,
+// click positions count from 1
+type SearchStats struct {
+	Since              time.Time `json:"since"`
+	Searches           int       `json:"searches"`
+	ZeroResultSearches int       `json:"zeroResultSearches"`
+	ZeroResultRate     float64   `json:"zeroResultRate"`
+	ClickedSearches    int       `json:"clickedSearches"`
+	CTR                float64   `json:"ctr"`
+	Clicks             int       `json:"clicks"`
+	AvgClickPosition   float64   `json:"avgClickPosition"`
+	AvgLatencyMs       float64   `json:"avgLatencyMs"`
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SynonymSet - interchangeable search terms, a query containing one of them also matches the others.
+// Terms are lowercased words or short phrases
+type SynonymSet struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Terms     []string      `bson:"terms" json:"terms"`
+	CreatedAt time.Time     `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updatedAt"`
+}
+
+// SearchRule - merchandising for a set of queries. Pinned products lead the first page in the given order,
+// boosted products rank higher and buried products lower among relevance-ranked results.
+// A rule is active between ActiveFrom and ActiveUntil, either of them may be open
+type SearchRule struct {
+	ID          bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string          `bson:"name" json:"name"`
+	Queries     []string        `bson:"queries" json:"queries"`
+	Pins        []bson.ObjectID `bson:"pins" json:"pins"`
+	Boost       ProductMatch    `bson:"boost" json:"boost"`
+	BoostFactor float64         `bson:"boost_factor" json:"boostFactor"`
+	Bury        ProductMatch    `bson:"bury" json:"bury"`
+	ActiveFrom  *time.Time      `bson:"active_from,omitempty" json:"activeFrom,omitempty"`
+	ActiveUntil *time.Time      `bson:"active_until,omitempty" json:"activeUntil,omitempty"`
+	CreatedAt   time.Time       `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time       `bson:"updated_at" json:"updatedAt"`
+}
+
+// ProductMatch selects products by ID, tag or category, a product matching any of them is selected
+type ProductMatch struct {
+	ProductIDs []bson.ObjectID `bson:"product_ids" json:"productIDs"`
+	Tags       []string        `bson:"tags" json:"tags"`
+	Categories []string        `bson:"categories" json:"categories"`
+}
+
+func (m ProductMatch) Empty() bool {
+	return len(m.ProductIDs) == 0 && len(m.Tags) == 0 && len(m.Categories) == 0
+}
+
+// Matches reports whether the product is selected
+func (m ProductMatch) Matches(p *Product) bool {
+	return slices.Contains(m.ProductIDs, p.ID) || slices.Contains(m.Categories, p.Category) ||
+		slices.ContainsFunc(p.Tags, func(t string) bool { return slices.Contains(m.Tags, t) })
+}
+
+// SearchBoost multiplies the relevance of matching products by Factor, a factor below 1 buries them
+type SearchBoost struct {
+	Match  ProductMatch
+	Factor float64
+}
+
+// SearchPreview - the same search with and without synonyms and merchandising rules, with the rules that applied
+type SearchPreview struct {
+	WithRules    *ProductSearchResult `json:"withRules"`
+	WithoutRules *ProductSearchResult `json:"withoutRules"`
+	Rules        []*SearchRule        `json:"rules"`
+}
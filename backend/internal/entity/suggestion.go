@@ -0,0 +1,24 @@
+package entity
+
+type SuggestionType string
+
+const (
+	SuggestionProduct  SuggestionType = "product"
+	SuggestionCategory SuggestionType = "category"
+	SuggestionQuery    SuggestionType = "query"
+)
+
+// Suggestion completes a search box prefix. ProductID is set for products, CategorySlug for categories
+type Suggestion struct {
+	Text         string         `json:"text"`
+	Type         SuggestionType `json:"type"`
+	ProductID    string         `json:"productId,omitempty"`
+	CategorySlug string         `json:"categorySlug,omitempty"`
+	Score        float64        `json:"score"`
+}
+
+// SuggestionTerm - a normalized phrase whose prefixes complete to the suggestion
+type SuggestionTerm struct {
+	Term       string
+	Suggestion Suggestion
+}
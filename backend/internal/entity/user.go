@@ -3,6 +3,7 @@ package entity
 import (
 	"time"
 
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -23,7 +24,13 @@ type UserPreferences struct {
 	PriceRange PriceRange `bson:"price_range" json:"priceRange"`
 }
 
+// PriceRange - bounds in the base currency
 type PriceRange struct {
-	Min float64 `bson:"min" json:"min"`
-	Max float64 `bson:"max" json:"max"`
+	Min money.Amount `bson:"min" json:"min"`
+	Max money.Amount `bson:"max" json:"max"`
+}
+
+// Contains reports whether the price is within the range, a zero Max leaves it open above
+func (r PriceRange) Contains(price money.Amount) bool {
+	return price >= r.Min && (r.Max == 0 || price <= r.Max)
 }
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Wishlist struct {
+	ID         bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID     bson.ObjectID  `bson:"user_id" json:"userID"`
+	Name       string         `bson:"name" json:"name"`
+	IsDefault  bool           `bson:"is_default" json:"isDefault"`
+	IsPublic   bool           `bson:"is_public" json:"isPublic"`
+	ShareToken string         `bson:"share_token,omitempty" json:"shareToken,omitempty"`
+	Items      []WishlistItem `bson:"items" json:"items"`
+	CreatedAt  time.Time      `bson:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time      `bson:"updated_at" json:"updatedAt"`
+}
+
+type WishlistItem struct {
+	ProductID bson.ObjectID `bson:"product_id" json:"productID"`
+	AddedAt   time.Time     `bson:"added_at" json:"addedAt"`
+}
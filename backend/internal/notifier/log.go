@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.uber.org/zap"
+)
+
+// LogNotifier writes notifications to the application log instead of delivering them,
+// it's the default until an email/push provider is configured
+type LogNotifier struct {
+	l *zap.SugaredLogger
+}
+
+func NewLogNotifier(l *zap.SugaredLogger) *LogNotifier {
+	return &LogNotifier{l: l}
+}
+
+func (n *LogNotifier) SendCartReminder(ctx context.Context, message *entity.CartReminderMessage) error {
+	productIDs := make([]string, 0, len(message.Products))
+	for _, p := range message.Products {
+		productIDs = append(productIDs, p.ID.Hex())
+	}
+	recommendedIDs := make([]string, 0, len(message.Recommendations))
+	for _, p := range message.Recommendations {
+		recommendedIDs = append(recommendedIDs, p.ID.Hex())
+	}
+
+	n.l.Infow("Cart reminder",
+		"user_id", message.User.ID.Hex(),
+		"email", message.User.Email,
+		"products", productIDs,
+		"recommendations", recommendedIDs,
+	)
+	return nil
+}
+
+func (n *LogNotifier) SendPriceAlert(ctx context.Context, message *entity.PriceAlertMessage) error {
+	n.l.Infow("Price alert",
+		"user_id", message.User.ID.Hex(),
+		"email", message.User.Email,
+		"product_id", message.Product.ID.Hex(),
+		"sku", message.Watch.SKU,
+		"old_price", message.OldPrice.String(),
+		"new_price", message.NewPrice.String(),
+	)
+	return nil
+}
+
+func (n *LogNotifier) SendGuestClaim(ctx context.Context, message *entity.GuestClaimMessage) error {
+	guestIDs := make([]string, 0, len(message.Guests))
+	for _, g := range message.Guests {
+		guestIDs = append(guestIDs, g.ID.Hex())
+	}
+
+	n.l.Infow("Guest claim",
+		"user_id", message.User.ID.Hex(),
+		"email", message.User.Email,
+		"guests", guestIDs,
+		"token", message.Token,
+	)
+	return nil
+}
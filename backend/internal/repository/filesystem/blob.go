@@ -0,0 +1,71 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore keeps blobs as files under a root directory, keys are slash separated relative paths
+type BlobStore struct {
+	root    string
+	baseURL string
+}
+
+func NewBlobStore(root, baseURL string) (*BlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &BlobStore{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put writes the blob to a temporary file first, so readers never see a partial file
+func (s *BlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	name := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Get opens the blob, errors match fs.ErrNotExist for missing keys
+func (s *BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(s.path(key))
+}
+
+func (s *BlobStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *BlobStore) URL(key string) string {
+	return s.baseURL + "/" + strings.TrimPrefix(path.Clean("/"+key), "/")
+}
+
+// path maps a key into the root directory; cleaning it as an absolute path drops any ".." segments
+func (s *BlobStore) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
@@ -0,0 +1,115 @@
+package fulltext
+
+import (
+	"strings"
+
+	"github.com/m4rk1sov/ecommerce/pkg/fuzzy"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "for": true, "in": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// analyze splits text into lowercased, stemmed terms without stop words
+func analyze(text string) []string {
+	words := fuzzy.Tokenize(text)
+	terms := words[:0]
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		terms = append(terms, stem(w))
+	}
+	return terms
+}
+
+// stem strips common English plural and verb endings so "batteries" meets "battery" and
+// "charging", "charged" and "charges" meet "charge". It is deliberately light: documents and
+// queries go through the same rules, so it only has to be consistent, not linguistically exact
+func stem(w string) string {
+	w = stripSuffix(w)
+	// A final silent "e" goes too, the endings above have already removed it from "charging"
+	if n := len(w); n > 4 && w[n-1] == 'e' {
+		return w[:n-1]
+	}
+	return w
+}
+
+func stripSuffix(w string) string {
+	n := len(w)
+	switch {
+	case n > 4 && strings.HasSuffix(w, "ies"):
+		return w[:n-3] + "y"
+	case n > 4 && strings.HasSuffix(w, "sses"):
+		return w[:n-2]
+	case n > 4 && (strings.HasSuffix(w, "shes") || strings.HasSuffix(w, "ches") ||
+		strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "zes")):
+		return w[:n-2]
+	case n > 3 && strings.HasSuffix(w, "s") &&
+		!strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return w[:n-1]
+	case n > 5 && strings.HasSuffix(w, "ing"):
+		return trimDoubled(w[:n-3])
+	case n > 4 && strings.HasSuffix(w, "ed"):
+		return trimDoubled(w[:n-2])
+	}
+	return w
+}
+
+// trimDoubled undoes consonant doubling: "shipp" -> "ship"
+func trimDoubled(w string) string {
+	n := len(w)
+	if n > 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeiouls", rune(w[n-1])) {
+		return w[:n-1]
+	}
+	return w
+}
+
+// query - a parsed search query. Like Mongo $text: any term may match, every phrase
+// must appear as is and documents with an excluded term are dropped
+type query struct {
+	terms    []string
+	phrases  [][]string
+	excluded []string
+}
+
+func (q query) empty() bool {
+	return len(q.terms) == 0 && len(q.phrases) == 0
+}
+
+// parseQuery reads plain words, "quoted phrases" and -excluded words
+func parseQuery(s string) query {
+	var q query
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t")
+		switch {
+		case s == "":
+		case s[0] == '"':
+			end := strings.IndexByte(s[1:], '"')
+			phrase := s[1:]
+			if end >= 0 {
+				phrase, s = s[1:end+1], s[end+2:]
+			} else {
+				s = ""
+			}
+			if terms := analyze(phrase); len(terms) > 0 {
+				q.phrases = append(q.phrases, terms)
+				q.terms = append(q.terms, terms...)
+			}
+		default:
+			word := s
+			if i := strings.IndexAny(s, " \t"); i >= 0 {
+				word, s = s[:i], s[i:]
+			} else {
+				s = ""
+			}
+			if strings.HasPrefix(word, "-") {
+				q.excluded = append(q.excluded, analyze(word[1:])...)
+				continue
+			}
+			q.terms = append(q.terms, analyze(word)...)
+		}
+	}
+	return q
+}
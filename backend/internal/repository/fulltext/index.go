@@ -0,0 +1,256 @@
+package fulltext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// compactAfter - journal records after which the journal is folded into a new snapshot
+const compactAfter = 1000
+
+// field weights, a name match counts three times a description match
+var fieldWeights = [...]float64{
+	fieldName:        3,
+	fieldTags:        2,
+	fieldCategory:    1.5,
+	fieldDescription: 1,
+}
+
+const (
+	fieldName = iota
+	fieldTags
+	fieldCategory
+	fieldDescription
+	numFields
+)
+
+// document - an indexed product with its analyzed fields
+type document struct {
+	product *entity.Product
+	fields  [numFields][]string
+	// translated - the analyzed translations of the name and description
+	translated []translatedText
+	// length - weighted number of terms, used for BM25 length normalization
+	length float64
+}
+
+// translatedText - a translated field. A product reads in one locale at a time, so translations are
+// matched like the field they translate but don't lengthen the document
+type translatedText struct {
+	field int
+	terms []string
+}
+
+// texts returns every analyzed text of the document, fields first
+func (d *document) texts() [][]string {
+	texts := make([][]string, 0, numFields+len(d.translated))
+	texts = append(texts, d.fields[:]...)
+	for _, t := range d.translated {
+		texts = append(texts, t.terms)
+	}
+	return texts
+}
+
+// Index is an embedded full-text product index with BM25 ranking. The inverted index lives in
+// memory; the indexed products are persisted in dir as a snapshot plus a journal of later
+// changes, both replayed on Open. An Index is safe for concurrent use
+type Index struct {
+	dir string
+
+	mu             sync.RWMutex
+	journal        *os.File
+	journalRecords int
+	docs           map[bson.ObjectID]*document
+	// postings - term -> document -> weighted term frequency
+	postings    map[string]map[bson.ObjectID]float64
+	totalLength float64
+}
+
+// Open loads the index stored in dir, creating dir when needed
+func Open(dir string) (*Index, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	idx := &Index{
+		dir:      dir,
+		docs:     make(map[bson.ObjectID]*document),
+		postings: make(map[string]map[bson.ObjectID]float64),
+	}
+
+	apply := func(r record) error {
+		switch r.Op {
+		case opIndex:
+			idx.put(r.Product)
+		case opDelete:
+			idx.remove(r.ID)
+		}
+		return nil
+	}
+	if err := readRecords(filepath.Join(dir, snapshotFile), apply); err != nil {
+		return nil, err
+	}
+	if err := readRecords(filepath.Join(dir, journalFile), func(r record) error {
+		idx.journalRecords++
+		return apply(r)
+	}); err != nil {
+		return nil, err
+	}
+
+	// A torn tail is dropped on replay; rewriting the snapshot keeps new records from landing after it
+	if err := idx.compact(); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.journal.Close()
+}
+
+// Len - number of indexed products
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Index adds or replaces the product
+func (idx *Index) Index(ctx context.Context, product *entity.Product) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	p := *product
+	if err := idx.appendJournal(record{Op: opIndex, ID: p.ID, Product: &p}); err != nil {
+		return err
+	}
+	idx.put(&p)
+	return nil
+}
+
+// Delete removes the product, unknown IDs are ignored
+func (idx *Index) Delete(ctx context.Context, id bson.ObjectID) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.docs[id]; !ok {
+		return nil
+	}
+	if err := idx.appendJournal(record{Op: opDelete, ID: id}); err != nil {
+		return err
+	}
+	idx.remove(id)
+	return nil
+}
+
+// Replace swaps the whole index for the given products
+func (idx *Index) Replace(ctx context.Context, products []*entity.Product) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.docs = make(map[bson.ObjectID]*document, len(products))
+	idx.postings = make(map[string]map[bson.ObjectID]float64)
+	idx.totalLength = 0
+	for _, product := range products {
+		p := *product
+		idx.put(&p)
+	}
+	return idx.compact()
+}
+
+func (idx *Index) appendJournal(r record) error {
+	if err := writeRecord(idx.journal, r); err != nil {
+		return err
+	}
+	idx.journalRecords++
+	if idx.journalRecords >= compactAfter {
+		return idx.compact()
+	}
+	return nil
+}
+
+// compact writes the current documents as the snapshot and starts an empty journal
+func (idx *Index) compact() error {
+	products := make([]*entity.Product, 0, len(idx.docs))
+	for _, d := range idx.docs {
+		products = append(products, d.product)
+	}
+	if err := writeSnapshot(idx.dir, products); err != nil {
+		return err
+	}
+
+	if idx.journal != nil {
+		if err := idx.journal.Close(); err != nil {
+			return err
+		}
+	}
+	journal, err := os.OpenFile(filepath.Join(idx.dir, journalFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	idx.journal = journal
+	idx.journalRecords = 0
+	return nil
+}
+
+func (idx *Index) put(p *entity.Product) {
+	idx.remove(p.ID)
+
+	d := &document{product: p}
+	d.fields[fieldName] = analyze(p.Name)
+	d.fields[fieldTags] = analyze(strings.Join(p.Tags, " "))
+	d.fields[fieldCategory] = analyze(p.Category)
+	d.fields[fieldDescription] = analyze(p.Description)
+
+	for _, tr := range p.Translations {
+		d.translated = append(d.translated,
+			translatedText{field: fieldName, terms: analyze(tr.Name)},
+			translatedText{field: fieldDescription, terms: analyze(tr.Description)},
+		)
+	}
+
+	for f, terms := range d.fields {
+		d.length += fieldWeights[f] * float64(len(terms))
+		idx.post(p.ID, terms, fieldWeights[f])
+	}
+	for _, t := range d.translated {
+		idx.post(p.ID, t.terms, fieldWeights[t.field])
+	}
+
+	idx.docs[p.ID] = d
+	idx.totalLength += d.length
+}
+
+func (idx *Index) post(id bson.ObjectID, terms []string, weight float64) {
+	for _, t := range terms {
+		if idx.postings[t] == nil {
+			idx.postings[t] = make(map[bson.ObjectID]float64)
+		}
+		idx.postings[t][id] += weight
+	}
+}
+
+func (idx *Index) remove(id bson.ObjectID) {
+	d, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, terms := range d.texts() {
+		for _, t := range terms {
+			delete(idx.postings[t], id)
+			if len(idx.postings[t]) == 0 {
+				delete(idx.postings, t)
+			}
+		}
+	}
+	delete(idx.docs, id)
+	idx.totalLength -= d.length
+}
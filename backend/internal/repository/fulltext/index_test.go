@@ -0,0 +1,149 @@
+package fulltext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func newProduct(name, description string) *entity.Product {
+	return &entity.Product{
+		ID:          bson.NewObjectID(),
+		Name:        name,
+		Description: description,
+		Category:    "Accessories",
+		Tags:        []string{},
+	}
+}
+
+func indexedNames(idx *Index) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	names := make([]string, 0, len(idx.docs))
+	for _, d := range idx.docs {
+		names = append(names, d.product.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func encodeRecord(t *testing.T, r record) []byte {
+	t.Helper()
+	data, err := bson.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal record: %v", err)
+	}
+	return data
+}
+
+func TestOpenReplaysTornJournal(t *testing.T) {
+	ctx := context.Background()
+	appended := newProduct("Appended Mouse", "")
+
+	tests := []struct {
+		name string
+		tail func(t *testing.T) []byte
+		want []string
+	}{
+		{
+			name: "clean journal",
+			tail: func(t *testing.T) []byte { return nil },
+			want: []string{"Kept Keyboard"},
+		},
+		{
+			name: "complete record at the end",
+			tail: func(t *testing.T) []byte {
+				return encodeRecord(t, record{Op: opIndex, ID: appended.ID, Product: appended})
+			},
+			want: []string{"Appended Mouse", "Kept Keyboard"},
+		},
+		{
+			name: "torn size prefix",
+			tail: func(t *testing.T) []byte {
+				return encodeRecord(t, record{Op: opIndex, ID: appended.ID, Product: appended})[:2]
+			},
+			want: []string{"Kept Keyboard"},
+		},
+		{
+			name: "torn record body",
+			tail: func(t *testing.T) []byte {
+				data := encodeRecord(t, record{Op: opIndex, ID: appended.ID, Product: appended})
+				return data[:len(data)/2]
+			},
+			want: []string{"Kept Keyboard"},
+		},
+		{
+			name: "torn delete",
+			tail: func(t *testing.T) []byte {
+				return encodeRecord(t, record{Op: opDelete, ID: bson.NewObjectID()})[:7]
+			},
+			want: []string{"Kept Keyboard"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			idx, err := Open(dir)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			kept, deleted := newProduct("Kept Keyboard", ""), newProduct("Deleted Cable", "")
+			for _, p := range []*entity.Product{kept, deleted} {
+				if err := idx.Index(ctx, p); err != nil {
+					t.Fatalf("Index: %v", err)
+				}
+			}
+			if err := idx.Delete(ctx, deleted.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := idx.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			// A crash in the middle of a journal write leaves the tail behind
+			journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				t.Fatalf("open journal: %v", err)
+			}
+			if _, err := journal.Write(tt.tail(t)); err != nil {
+				t.Fatalf("write tail: %v", err)
+			}
+			if err := journal.Close(); err != nil {
+				t.Fatalf("close journal: %v", err)
+			}
+
+			idx, err = Open(dir)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			if got := indexedNames(idx); !slices.Equal(got, tt.want) {
+				t.Fatalf("after replay got %q, want %q", got, tt.want)
+			}
+
+			// Writes after the replay must not land behind the dropped tail
+			later := newProduct("Later Monitor", "")
+			if err := idx.Index(ctx, later); err != nil {
+				t.Fatalf("Index after replay: %v", err)
+			}
+			if err := idx.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			idx, err = Open(dir)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			defer func() { _ = idx.Close() }()
+
+			want := append(slices.Clone(tt.want), "Later Monitor")
+			slices.Sort(want)
+			if got := indexedNames(idx); !slices.Equal(got, want) {
+				t.Errorf("after a later write got %q, want %q", got, want)
+			}
+		})
+	}
+}
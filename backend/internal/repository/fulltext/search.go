@@ -0,0 +1,332 @@
+package fulltext
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// fuzzyBoost - a match through a typo correction scores half of an exact match
+	fuzzyBoost = 0.5
+
+	// minTermLength - shorter single-word queries match terms starting with them
+	minTermLength = 3
+)
+
+// hit - a matching document with its relevance score
+type hit struct {
+	doc   *document
+	score float64
+}
+
+// Search ranks products with BM25 over name, tags, category and description, translations included,
+// applies the filters, computes facets over all matches and returns the requested page
+func (idx *Index) Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	hits := idx.match(params)
+	key := sortKeyFor(params.Sort, strings.TrimSpace(params.Query) != "")
+	sortHits(hits, key)
+
+	result := &entity.ProductSearchResult{
+		Products: []*entity.Product{},
+		Facets:   facets(hits, params.AttributeFacets),
+		Page:     entity.PageInfo{Total: len(hits)},
+	}
+
+	start := 0
+	if params.Page.Cursor != "" {
+		c, err := decodeCursor(params.Page.Cursor, key)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(hits), func(i int) bool {
+			return key.compare(c, cursorOf(hits[i], key)) < 0
+		})
+	}
+
+	end := min(start+params.Page.Limit, len(hits))
+	for _, h := range hits[start:end] {
+		p := *h.doc.product
+		result.Products = append(result.Products, &p)
+	}
+	if end < len(hits) {
+		next, err := encodeCursor(cursorOf(hits[end-1], key))
+		if err != nil {
+			return nil, err
+		}
+		result.Page.NextCursor = next
+		result.Page.HasMore = true
+	}
+	return result, nil
+}
+
+// match finds and scores the documents for the query and filters. Without a query every product matches
+func (idx *Index) match(params entity.ProductSearchParams) []hit {
+	var hits []hit
+	if strings.TrimSpace(params.Query) == "" {
+		for _, d := range idx.docs {
+			if params.Accepts(d.product) {
+				hits = append(hits, hit{doc: d})
+			}
+		}
+		return hits
+	}
+
+	q := parseQuery(params.Query)
+	if q.empty() {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for _, t := range q.terms {
+		weights[t] = 1
+	}
+	if len(q.terms) == 1 && len(q.phrases) == 0 && utf8.RuneCountInString(q.terms[0]) < minTermLength {
+		// Too short to be a word, treat it as the start of one
+		for t := range idx.postings {
+			if strings.HasPrefix(t, q.terms[0]) {
+				weights[t] = 1
+			}
+		}
+	}
+	for _, t := range analyze(strings.Join(params.SynonymTerms, " ")) {
+		weights[t] = 1
+	}
+	for _, t := range analyze(strings.Join(params.FuzzyTerms, " ")) {
+		if _, ok := weights[t]; !ok {
+			weights[t] = fuzzyBoost
+		}
+	}
+
+	scores := make(map[bson.ObjectID]float64)
+	n := float64(len(idx.docs))
+	avgLength := idx.totalLength / max(n, 1)
+	for t, weight := range weights {
+		postings := idx.postings[t]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			norm := bm25K1 * (1 - bm25B + bm25B*idx.docs[id].length/max(avgLength, 1))
+			scores[id] += weight * idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	for id, score := range scores {
+		d := idx.docs[id]
+		if !containsPhrases(d, q.phrases) || containsAny(d, q.excluded) || !params.Accepts(d.product) {
+			continue
+		}
+		for _, boost := range params.Boosts {
+			if boost.Match.Matches(d.product) {
+				score *= boost.Factor
+			}
+		}
+		hits = append(hits, hit{doc: d, score: score})
+	}
+	return hits
+}
+
+func containsPhrases(d *document, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		found := false
+		for _, terms := range d.texts() {
+			for i := 0; i+len(phrase) <= len(terms) && !found; i++ {
+				found = slices.Equal(terms[i:i+len(phrase)], phrase)
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(d *document, excluded []string) bool {
+	for _, terms := range d.texts() {
+		for _, t := range terms {
+			if slices.Contains(excluded, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func facets(hits []hit, attributeKeys []string) entity.SearchFacets {
+	categories := make(map[string]int)
+	tags := make(map[string]int)
+	attributes := make(map[string]map[string]int, len(attributeKeys))
+	for _, key := range attributeKeys {
+		attributes[key] = make(map[string]int)
+	}
+	buckets := make([]int, len(entity.PriceFacetBoundaries))
+	for _, h := range hits {
+		p := h.doc.product
+		categories[p.Category]++
+		for _, t := range p.Tags {
+			tags[t]++
+		}
+		for key, v := range p.Attributes {
+			if counts, ok := attributes[key]; ok {
+				counts[entity.FormatAttributeValue(v)]++
+			}
+		}
+		// The last boundary opens the top bucket
+		i := sort.Search(len(entity.PriceFacetBoundaries), func(i int) bool {
+			return entity.PriceFacetBoundaries[i] > p.ListedPrice()
+		})
+		if i > 0 {
+			buckets[i-1]++
+		}
+	}
+
+	result := entity.SearchFacets{
+		Categories: countsByFrequency(categories, 0),
+		Tags:       countsByFrequency(tags, entity.TagFacetLimit),
+	}
+	last := len(entity.PriceFacetBoundaries) - 1
+	for i, count := range buckets {
+		if count == 0 {
+			continue
+		}
+		bucket := entity.PriceBucket{Min: entity.PriceFacetBoundaries[i], Count: count}
+		if i < last {
+			upper := entity.PriceFacetBoundaries[i+1]
+			bucket.Max = &upper
+		}
+		result.PriceBuckets = append(result.PriceBuckets, bucket)
+	}
+	for _, key := range attributeKeys {
+		if len(attributes[key]) > 0 {
+			result.Attributes = append(result.Attributes, entity.AttributeFacet{
+				Key:    key,
+				Values: countsByFrequency(attributes[key], entity.AttributeFacetLimit),
+			})
+		}
+	}
+	return result
+}
+
+// countsByFrequency sorts facet values by count, most frequent first; limit 0 keeps all
+func countsByFrequency(counts map[string]int, limit int) []entity.FacetCount {
+	facet := make([]entity.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facet = append(facet, entity.FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facet, func(i, j int) bool {
+		if facet[i].Count != facet[j].Count {
+			return facet[i].Count > facet[j].Count
+		}
+		return facet[i].Value < facet[j].Value
+	})
+	if limit > 0 && len(facet) > limit {
+		facet = facet[:limit]
+	}
+	return facet
+}
+
+// sortKey - like the Mongo repository, ties are broken by ID in the same direction
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+func sortKeyFor(s entity.SearchSort, hasQuery bool) sortKey {
+	switch s {
+	case entity.SortPriceAsc:
+		return sortKey{field: "price"}
+	case entity.SortPriceDesc:
+		return sortKey{field: "price", desc: true}
+	case entity.SortNewest:
+		return sortKey{field: "created_at", desc: true}
+	case entity.SortRelevance:
+		if hasQuery {
+			return sortKey{field: "score", desc: true}
+		}
+		// Relevance without a query falls back to the best rated products
+		return sortKey{field: "rating", desc: true}
+	default:
+		return sortKey{field: "rating", desc: true}
+	}
+}
+
+// pageCursor - the sort position of the last item of a page
+type pageCursor struct {
+	Field string  `json:"f"`
+	Value float64 `json:"v,omitempty"`
+	Nanos int64   `json:"t,omitempty"`
+	ID    string  `json:"id"`
+}
+
+func cursorOf(h hit, key sortKey) pageCursor {
+	p := h.doc.product
+	c := pageCursor{Field: key.field, ID: p.ID.Hex()}
+	switch key.field {
+	case "score":
+		c.Value = h.score
+	case "price":
+		c.Value = float64(p.ListedPrice())
+	case "rating":
+		c.Value = p.Rating
+	case "created_at":
+		c.Nanos = p.CreatedAt.UnixNano()
+	}
+	return c
+}
+
+// compare orders two positions in the key's direction
+func (k sortKey) compare(a, b pageCursor) int {
+	c := cmp.Compare(a.Value, b.Value)
+	if k.field == "created_at" {
+		c = cmp.Compare(a.Nanos, b.Nanos)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if k.desc {
+		return -c
+	}
+	return c
+}
+
+func sortHits(hits []hit, key sortKey) {
+	sort.Slice(hits, func(i, j int) bool {
+		return key.compare(cursorOf(hits[i], key), cursorOf(hits[j], key)) < 0
+	})
+}
+
+func encodeCursor(c pageCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(token string, key sortKey) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, entity.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Field != key.field {
+		return c, entity.ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,148 @@
+package fulltext
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+)
+
+func TestSearchRanksWithBM25(t *testing.T) {
+	ctx := context.Background()
+	idx, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = idx.Close() }()
+
+	for _, p := range []*entity.Product{
+		newProduct("Mechanical Keyboard", "RGB backlight"),
+		newProduct("Desk Mat", "Fits a keyboard and a mouse"),
+		newProduct("Wireless Mouse", ""),
+		newProduct("Wireless Headphones", ""),
+		newProduct("USB Cable", ""),
+		newProduct("USB Cable Organizer With Velcro Straps And Clips", ""),
+	} {
+		if err := idx.Index(ctx, p); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		fuzzyTerms []string
+		want       []string
+	}{
+		{
+			name:  "name match ranks above description match",
+			query: "keyboard",
+			want:  []string{"Mechanical Keyboard", "Desk Mat"},
+		},
+		{
+			name:  "more matched terms rank first",
+			query: "wireless mouse",
+			want:  []string{"Wireless Mouse", "Wireless Headphones", "Desk Mat"},
+		},
+		{
+			name:  "shorter document ranks first",
+			query: "cable",
+			want:  []string{"USB Cable", "USB Cable Organizer With Velcro Straps And Clips"},
+		},
+		{
+			name:  "stemmed terms match",
+			query: "headphone",
+			want:  []string{"Wireless Headphones"},
+		},
+		{
+			name:       "fuzzy corrections rank below exact matches",
+			query:      "mouse keybord",
+			fuzzyTerms: []string{"keyboard"},
+			want:       []string{"Wireless Mouse", "Desk Mat", "Mechanical Keyboard"},
+		},
+		{
+			name:  "excluded terms drop documents",
+			query: "wireless -headphones",
+			want:  []string{"Wireless Mouse"},
+		},
+		{
+			name:  "no match",
+			query: "monitor",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		result, err := idx.Search(ctx, entity.ProductSearchParams{
+			Query:      tt.query,
+			FuzzyTerms: tt.fuzzyTerms,
+			Sort:       entity.SortRelevance,
+			Page:       entity.PageRequest{Limit: 10},
+		})
+		if err != nil {
+			t.Fatalf("%s: Search: %v", tt.name, err)
+		}
+		got := []string{}
+		for _, p := range result.Products {
+			got = append(got, p.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Search(%q) = %q, want %q", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFiltersAndSortsOnCurrentPrices(t *testing.T) {
+	ctx := context.Background()
+	idx, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = idx.Close() }()
+
+	onSale := newProduct("Sale Lamp", "")
+	onSale.Price, onSale.CurrentPrice = 10000, 4000
+	regular := newProduct("Regular Lamp", "")
+	regular.Price, regular.CurrentPrice = 6000, 6000
+	// Stored before current prices were kept
+	legacy := newProduct("Legacy Lamp", "")
+	legacy.Price = 5000
+	for _, p := range []*entity.Product{onSale, regular, legacy} {
+		if err := idx.Index(ctx, p); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	maxPrice := money.Amount(5000)
+	tests := []struct {
+		name   string
+		params entity.ProductSearchParams
+		want   []string
+	}{
+		{
+			name:   "max price matches the sale price",
+			params: entity.ProductSearchParams{MaxPrice: &maxPrice, Sort: entity.SortPriceAsc},
+			want:   []string{"Sale Lamp", "Legacy Lamp"},
+		},
+		{
+			name:   "price sort uses the sale price",
+			params: entity.ProductSearchParams{Sort: entity.SortPriceDesc},
+			want:   []string{"Regular Lamp", "Legacy Lamp", "Sale Lamp"},
+		},
+	}
+	for _, tt := range tests {
+		tt.params.Page = entity.PageRequest{Limit: 10}
+		result, err := idx.Search(ctx, tt.params)
+		if err != nil {
+			t.Fatalf("%s: Search: %v", tt.name, err)
+		}
+		got := []string{}
+		for _, p := range result.Products {
+			got = append(got, p.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package fulltext
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	snapshotFile = "snapshot.bson"
+	journalFile  = "journal.bson"
+
+	opIndex  = "index"
+	opDelete = "delete"
+)
+
+// record - one entry of the snapshot or the journal, stored as consecutive BSON documents
+type record struct {
+	Op      string          `bson:"op"`
+	ID      bson.ObjectID   `bson:"id"`
+	Product *entity.Product `bson:"product,omitempty"`
+}
+
+// readRecords decodes every record of the file, a missing file has none. A torn record at the
+// end of the journal (a crash in the middle of a write) is dropped
+func readRecords(path string, fn func(record) error) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for len(data) >= 4 {
+		size := int(binary.LittleEndian.Uint32(data))
+		if size < 5 || size > len(data) {
+			return nil
+		}
+		var r record
+		if err := bson.Unmarshal(data[:size], &r); err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func writeRecord(w io.Writer, r record) error {
+	data, err := bson.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeSnapshot replaces the snapshot atomically: a temp file is written, synced and renamed over it
+func writeSnapshot(dir string, products []*entity.Product) error {
+	tmp, err := os.CreateTemp(dir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	for _, p := range products {
+		if err := writeRecord(tmp, record{Op: opIndex, ID: p.ID, Product: p}); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, snapshotFile))
+}
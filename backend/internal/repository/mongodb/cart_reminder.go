@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type CartReminderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCartReminderRepository(db *mongo.Database) *CartReminderRepository {
+	return &CartReminderRepository{
+		collection: db.Collection("cart_reminders"),
+	}
+}
+
+func (r *CartReminderRepository) Create(ctx context.Context, reminder *entity.CartReminder) error {
+	reminder.SentAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, reminder)
+	if err != nil {
+		return err
+	}
+
+	reminder.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// ExistsSince reports whether the user was already reminded after the given time
+func (r *CartReminderRepository) ExistsSince(ctx context.Context, userID bson.ObjectID, since time.Time) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"sent_at": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *CartReminderRepository) GetUnrecovered(ctx context.Context, sentAfter time.Time) ([]*entity.CartReminder, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"recovered": false,
+		"sent_at":   bson.M{"$gte": sentAfter},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var reminders []*entity.CartReminder
+	if err := cursor.All(ctx, &reminders); err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+func (r *CartReminderRepository) MarkRecovered(ctx context.Context, id bson.ObjectID, purchase *entity.Purchase) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"recovered":       true,
+			"recovered_at":    purchase.CreatedAt,
+			"purchase_id":     purchase.ID,
+			"recovered_total": purchase.Total,
+		}},
+	)
+	return err
+}
+
+func (r *CartReminderRepository) GetStats(ctx context.Context, since time.Time) (*entity.CartRecoveryStats, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"sent_at": bson.M{"$gte": since}}},
+		{"$group": bson.M{
+			"_id":       nil,
+			"sent":      bson.M{"$sum": 1},
+			"recovered": bson.M{"$sum": bson.M{"$cond": []interface{}{"$recovered", 1, 0}}},
+			"revenue":   bson.M{"$sum": "$recovered_total"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	stats := &entity.CartRecoveryStats{Since: since}
+	if cursor.Next(ctx) {
+		var result struct {
+			Sent      int          `bson:"sent"`
+			Recovered int          `bson:"recovered"`
+			Revenue   money.Amount `bson:"revenue"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		stats.RemindersSent = result.Sent
+		stats.Recovered = result.Recovered
+		stats.RecoveredRevenue = result.Revenue
+	}
+
+	if stats.RemindersSent > 0 {
+		stats.ConversionRate = float64(stats.Recovered) / float64(stats.RemindersSent)
+	}
+	return stats, cursor.Err()
+}
@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CategoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCategoryRepository(db *mongo.Database) *CategoryRepository {
+	return &CategoryRepository{
+		collection: db.Collection("categories"),
+	}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category *entity.Category) error {
+	category.CreatedAt = time.Now()
+	category.UpdatedAt = time.Now()
+	if category.Ancestors == nil {
+		category.Ancestors = []bson.ObjectID{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, category)
+	if err != nil {
+		return err
+	}
+
+	category.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.Category, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*entity.Category, error) {
+	return r.findOne(ctx, bson.M{"slug": slug})
+}
+
+func (r *CategoryRepository) GetByName(ctx context.Context, name string) (*entity.Category, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+// GetAll returns every category in display order
+func (r *CategoryRepository) GetAll(ctx context.Context) ([]*entity.Category, error) {
+	return r.find(ctx, bson.M{})
+}
+
+// GetDescendants returns the whole subtree below the category, in display order
+func (r *CategoryRepository) GetDescendants(ctx context.Context, id bson.ObjectID) ([]*entity.Category, error) {
+	return r.find(ctx, bson.M{"ancestors": id})
+}
+
+// Update replaces the category, a nil ParentID moves it to the root
+func (r *CategoryRepository) Update(ctx context.Context, category *entity.Category) error {
+	category.UpdatedAt = time.Now()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": category.ID}, category)
+	return err
+}
+
+// SetAttributes replaces the category's own attribute schema
+func (r *CategoryRepository) SetAttributes(ctx context.Context, id bson.ObjectID, attributes []entity.AttributeDef) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"attributes": attributes, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetTranslations replaces the translations of the category name
+func (r *CategoryRepository) SetTranslations(ctx context.Context, id bson.ObjectID, translations entity.Translations) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"translations": translations, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetSales replaces the sale windows of the category
+func (r *CategoryRepository) SetSales(ctx context.Context, id bson.ObjectID, sales []entity.SaleWindow) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"sales": sales, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// FindWithActiveSales returns the categories with a sale window in effect at t
+func (r *CategoryRepository) FindWithActiveSales(ctx context.Context, t time.Time) ([]*entity.Category, error) {
+	return r.find(ctx, bson.M{"sales": bson.M{"$elemMatch": bson.M{
+		"starts_at": bson.M{"$lte": t},
+		"ends_at":   bson.M{"$gt": t},
+	}}})
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *CategoryRepository) findOne(ctx context.Context, filter bson.M) (*entity.Category, error) {
+	var category entity.Category
+	err := r.collection.FindOne(ctx, filter).Decode(&category)
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) find(ctx context.Context, filter bson.M) ([]*entity.Category, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var categories []*entity.Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
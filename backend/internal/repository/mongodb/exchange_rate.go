@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ExchangeRateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExchangeRateRepository(db *mongo.Database) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		collection: db.Collection("exchange_rates"),
+	}
+}
+
+// GetAll returns every stored rate ordered by currency code
+func (r *ExchangeRateRepository) GetAll(ctx context.Context) ([]*entity.ExchangeRate, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	rates := []*entity.ExchangeRate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Upsert stores the rates, replacing those already set for the same currencies
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rates []*entity.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(rates))
+	for i, rate := range rates {
+		rate.UpdatedAt = time.Now()
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": rate.Currency}).
+			SetReplacement(rate).
+			SetUpsert(true)
+	}
+	_, err := r.collection.BulkWrite(ctx, models)
+	return err
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, currency string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": currency})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
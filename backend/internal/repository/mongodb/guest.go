@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type GuestRepository struct {
+	collection *mongo.Collection
+}
+
+func NewGuestRepository(db *mongo.Database) *GuestRepository {
+	return &GuestRepository{
+		collection: db.Collection("guests"),
+	}
+}
+
+func (r *GuestRepository) Create(ctx context.Context, guest *entity.Guest) error {
+	guest.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, guest)
+	if err != nil {
+		return err
+	}
+
+	guest.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *GuestRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.Guest, error) {
+	var guest entity.Guest
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&guest); err != nil {
+		return nil, err
+	}
+	return &guest, nil
+}
+
+func (r *GuestRepository) GetUnclaimedByEmail(ctx context.Context, email string) ([]*entity.Guest, error) {
+	filter := bson.M{
+		"email":      email,
+		"claimed_by": bson.M{"$exists": false},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var guests []*entity.Guest
+	if err := cursor.All(ctx, &guests); err != nil {
+		return nil, err
+	}
+	return guests, nil
+}
+
+func (r *GuestRepository) MarkClaimed(ctx context.Context, id, userID bson.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"claimed_by": userID, "claimed_at": time.Now()}},
+	)
+	return err
+}
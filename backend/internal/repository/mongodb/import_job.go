@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ImportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImportJobRepository(db *mongo.Database) *ImportJobRepository {
+	return &ImportJobRepository{
+		collection: db.Collection("import_jobs"),
+	}
+}
+
+func (r *ImportJobRepository) Create(ctx context.Context, job *entity.ImportJob) error {
+	job.CreatedAt = time.Now()
+	if job.Errors == nil {
+		job.Errors = []entity.ImportRowError{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	job.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *ImportJobRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.ImportJob, error) {
+	var job entity.ImportJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update saves the job progress
+func (r *ImportJobRepository) Update(ctx context.Context, job *entity.ImportJob) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+// FailUnfinished marks pending and running jobs as failed, returns how many were marked
+func (r *ImportJobRepository) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"status": bson.M{"$in": []entity.ImportStatus{entity.ImportPending, entity.ImportRunning}}},
+		bson.M{"$set": bson.M{"status": entity.ImportFailed, "error": reason, "finished_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -58,6 +58,20 @@ func (r *InteractionRepository) GetUserInteractions(ctx context.Context, userID
 	return interactions, nil
 }
 
+// GetUserProductIDs returns the distinct products the user interacted with in the given way
+func (r *InteractionRepository) GetUserProductIDs(
+	ctx context.Context,
+	userID bson.ObjectID,
+	interactionType entity.InteractionType,
+) ([]bson.ObjectID, error) {
+	var ids []bson.ObjectID
+	filter := bson.M{"user_id": userID, "type": interactionType}
+	if err := r.interactions.Distinct(ctx, "product_id", filter).Decode(&ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (r *InteractionRepository) GetProductInteractions(ctx context.Context, productID bson.ObjectID, limit int) ([]*entity.Interaction, error) {
 	opts := options.Find().
 		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
@@ -81,9 +95,86 @@ func (r *InteractionRepository) GetProductInteractions(ctx context.Context, prod
 	return interactions, nil
 }
 
-func (r *InteractionRepository) GetUserPurchaseHistory(ctx context.Context, userID bson.ObjectID) ([]*entity.Purchase, error) {
-	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
-	cursor, err := r.purchases.Find(ctx, bson.M{"user_id": userID}, opts)
+var (
+	purchaseHistoryKey    = sortKey{field: "created_at", desc: true}
+	interactionHistoryKey = sortKey{field: "timestamp", desc: true}
+)
+
+func purchaseSortValue(p *entity.Purchase) (interface{}, bson.ObjectID) {
+	return p.CreatedAt, p.ID
+}
+
+func (r *InteractionRepository) GetUserInteractionHistory(
+	ctx context.Context,
+	userID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.Interaction, entity.PageInfo, error) {
+	return findPage(ctx, r.interactions, bson.M{"user_id": userID}, interactionHistoryKey, page,
+		func(i *entity.Interaction) (interface{}, bson.ObjectID) {
+			return i.Timestamp, i.ID
+		})
+}
+
+func (r *InteractionRepository) GetUserPurchaseHistory(
+	ctx context.Context,
+	userID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.Purchase, entity.PageInfo, error) {
+	return findPage(ctx, r.purchases, bson.M{"user_id": userID}, purchaseHistoryKey, page, purchaseSortValue)
+}
+
+func (r *InteractionRepository) GetGuestPurchaseHistory(
+	ctx context.Context,
+	guestID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.Purchase, entity.PageInfo, error) {
+	return findPage(ctx, r.purchases, bson.M{"guest_id": guestID}, purchaseHistoryKey, page, purchaseSortValue)
+}
+
+// AssignGuestActivity attaches the guest's purchases and interactions to a user, guest_id is kept for audit
+func (r *InteractionRepository) AssignGuestActivity(ctx context.Context, guestID, userID bson.ObjectID) error {
+	filter := bson.M{"guest_id": guestID, "user_id": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"user_id": userID}}
+
+	if _, err := r.purchases.UpdateMany(ctx, filter, update); err != nil {
+		return err
+	}
+	_, err := r.interactions.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// FindAbandonedCarts groups cart interactions since the given time that are older than window
+// and have no purchase of the same product by the same user within window
+func (r *InteractionRepository) FindAbandonedCarts(ctx context.Context, since time.Time, window time.Duration) ([]*entity.AbandonedCart, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"type":      entity.InteractionCart,
+			"user_id":   bson.M{"$exists": true},
+			"timestamp": bson.M{"$gte": since, "$lte": time.Now().Add(-window)},
+		}},
+		{"$lookup": bson.M{
+			"from": "purchases",
+			"let":  bson.M{"uid": "$user_id", "pid": "$product_id", "ts": "$timestamp"},
+			"pipeline": []bson.M{
+				{"$match": bson.M{"$expr": bson.M{"$and": []bson.M{
+					{"$eq": []interface{}{"$user_id", "$$uid"}},
+					{"$gte": []interface{}{"$created_at", "$$ts"}},
+					{"$lte": []interface{}{"$created_at", bson.M{"$add": []interface{}{"$$ts", window.Milliseconds()}}}},
+					{"$in": []interface{}{"$$pid", "$products.product_id"}},
+				}}}},
+				{"$limit": 1},
+			},
+			"as": "purchases",
+		}},
+		{"$match": bson.M{"purchases": bson.M{"$size": 0}}},
+		{"$group": bson.M{
+			"_id":          "$user_id",
+			"product_ids":  bson.M{"$addToSet": "$product_id"},
+			"last_cart_at": bson.M{"$max": "$timestamp"},
+		}},
+	}
+
+	cursor, err := r.interactions.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -94,11 +185,32 @@ func (r *InteractionRepository) GetUserPurchaseHistory(ctx context.Context, user
 		}
 	}(cursor, ctx)
 
-	var purchases []*entity.Purchase
-	if err := cursor.All(ctx, &purchases); err != nil {
+	var carts []*entity.AbandonedCart
+	if err := cursor.All(ctx, &carts); err != nil {
 		return nil, err
 	}
-	return purchases, nil
+	return carts, nil
+}
+
+// FindPurchaseContaining returns the earliest purchase after the given time with any of the products
+func (r *InteractionRepository) FindPurchaseContaining(
+	ctx context.Context,
+	userID bson.ObjectID,
+	productIDs []bson.ObjectID,
+	after time.Time,
+) (*entity.Purchase, error) {
+	filter := bson.M{
+		"user_id":             userID,
+		"created_at":          bson.M{"$gte": after},
+		"products.product_id": bson.M{"$in": productIDs},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	var purchase entity.Purchase
+	if err := r.purchases.FindOne(ctx, filter, opts).Decode(&purchase); err != nil {
+		return nil, err
+	}
+	return &purchase, nil
 }
 
 func (r *InteractionRepository) CreatePurchase(ctx context.Context, purchase *entity.Purchase) error {
@@ -147,3 +259,39 @@ func (r *InteractionRepository) GetInteractionCounts(ctx context.Context, produc
 
 	return counts, nil
 }
+
+// GetProductPopularity sums interaction weights per product since the given time
+func (r *InteractionRepository) GetProductPopularity(ctx context.Context, since time.Time) (map[bson.ObjectID]float64, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"timestamp": bson.M{"$gte": since}}},
+		{"$group": bson.M{
+			"_id":    "$product_id",
+			"weight": bson.M{"$sum": "$weight"},
+		}},
+	}
+
+	cursor, err := r.interactions.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	popularity := make(map[bson.ObjectID]float64)
+	for cursor.Next(ctx) {
+		var result struct {
+			ID     bson.ObjectID `bson:"_id"`
+			Weight float64       `bson:"weight"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		popularity[result.ID] = result.Weight
+	}
+
+	return popularity, cursor.Err()
+}
@@ -0,0 +1,148 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// sortKey - keyset pages are ordered by a single field with _id as tiebreaker in the same direction
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+func (k sortKey) sort() bson.D {
+	dir := 1
+	if k.desc {
+		dir = -1
+	}
+	if k.field == "_id" {
+		return bson.D{{Key: "_id", Value: dir}}
+	}
+	return bson.D{{Key: k.field, Value: dir}, {Key: "_id", Value: dir}}
+}
+
+// after selects documents that come after the cursor in this sort order
+func (k sortKey) after(c *pageCursor) bson.M {
+	op := "$gt"
+	if k.desc {
+		op = "$lt"
+	}
+	if k.field == "_id" {
+		return bson.M{"_id": bson.M{op: c.ID}}
+	}
+	return bson.M{"$or": []bson.M{
+		{k.field: bson.M{op: c.Value}},
+		{k.field: c.Value, "_id": bson.M{op: c.ID}},
+	}}
+}
+
+type pageCursor struct {
+	Field string        `bson:"f"`
+	Value bson.RawValue `bson:"v"`
+	ID    bson.ObjectID `bson:"id"`
+}
+
+func encodeCursor(field string, value interface{}, id bson.ObjectID) (string, error) {
+	data, err := bson.Marshal(struct {
+		Field string        `bson:"f"`
+		Value interface{}   `bson:"v"`
+		ID    bson.ObjectID `bson:"id"`
+	}{field, value, id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor rejects malformed cursors and cursors issued for another sort order
+func decodeCursor(token string, key sortKey) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, entity.ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := bson.Unmarshal(data, &c); err != nil || c.Field != key.field {
+		return nil, entity.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// withCursor narrows filter to the documents after the page cursor
+func withCursor(filter bson.M, key sortKey, token string) (bson.M, error) {
+	if token == "" {
+		return filter, nil
+	}
+	c, err := decodeCursor(token, key)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"$and": []bson.M{filter, key.after(c)}}, nil
+}
+
+// finishPage trims the extra look-ahead item and builds the next cursor from the last item kept
+func finishPage[T any](items []T, limit int, key sortKey, sortValue func(T) (interface{}, bson.ObjectID)) ([]T, entity.PageInfo, error) {
+	var info entity.PageInfo
+	if len(items) <= limit {
+		return items, info, nil
+	}
+
+	items = items[:limit]
+	value, id := sortValue(items[len(items)-1])
+	next, err := encodeCursor(key.field, value, id)
+	if err != nil {
+		return nil, info, err
+	}
+
+	info.HasMore = true
+	info.NextCursor = next
+	return items, info, nil
+}
+
+// findPage runs a keyset paginated Find over filter, with the total count of filter
+func findPage[T any](
+	ctx context.Context,
+	collection *mongo.Collection,
+	filter bson.M,
+	key sortKey,
+	page entity.PageRequest,
+	sortValue func(T) (interface{}, bson.ObjectID),
+) ([]T, entity.PageInfo, error) {
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+
+	query, err := withCursor(filter, key, page.Cursor)
+	if err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+
+	opts := options.Find().SetSort(key.sort()).SetLimit(int64(page.Limit + 1))
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var items []T
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+
+	items, info, err := finishPage(items, page.Limit, key, sortValue)
+	info.Total = int(total)
+	return items, info, err
+}
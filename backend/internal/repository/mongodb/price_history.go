@@ -0,0 +1,111 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type PriceHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPriceHistoryRepository(db *mongo.Database) *PriceHistoryRepository {
+	return &PriceHistoryRepository{
+		collection: db.Collection("price_history"),
+	}
+}
+
+func (r *PriceHistoryRepository) Create(ctx context.Context, changes []*entity.PriceChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(changes))
+	for i, change := range changes {
+		docs[i] = change
+	}
+	result, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range result.InsertedIDs {
+		changes[i].ID = id.(bson.ObjectID)
+	}
+	return nil
+}
+
+// GetByProduct returns the price changes of the product (or variant) since the given time, oldest first
+func (r *PriceHistoryRepository) GetByProduct(
+	ctx context.Context,
+	productID bson.ObjectID,
+	sku string,
+	since time.Time,
+) ([]*entity.PriceChange, error) {
+	filter := bson.M{"product_id": productID, "sku": sku, "changed_at": bson.M{"$gte": since}}
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}})
+	return r.find(ctx, filter, opts)
+}
+
+// GetLastBefore returns the price in effect at the given time
+func (r *PriceHistoryRepository) GetLastBefore(
+	ctx context.Context,
+	productID bson.ObjectID,
+	sku string,
+	before time.Time,
+) (*entity.PriceChange, error) {
+	var change entity.PriceChange
+	opts := options.FindOne().SetSort(bson.D{{Key: "changed_at", Value: -1}, {Key: "_id", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{
+		"product_id": productID,
+		"sku":        sku,
+		"changed_at": bson.M{"$lt": before},
+	}, opts).Decode(&change)
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetPending returns changes not yet checked against price watches, oldest first
+func (r *PriceHistoryRepository) GetPending(ctx context.Context, limit int) ([]*entity.PriceChange, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "changed_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return r.find(ctx, bson.M{"alerted": false}, opts)
+}
+
+func (r *PriceHistoryRepository) MarkAlerted(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"alerted": true}})
+	return err
+}
+
+func (r *PriceHistoryRepository) DeleteByProduct(ctx context.Context, productID bson.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"product_id": productID})
+	return err
+}
+
+func (r *PriceHistoryRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptionsBuilder) ([]*entity.PriceChange, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var changes []*entity.PriceChange
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
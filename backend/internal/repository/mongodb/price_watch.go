@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type PriceWatchRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPriceWatchRepository(db *mongo.Database) *PriceWatchRepository {
+	return &PriceWatchRepository{
+		collection: db.Collection("price_watches"),
+	}
+}
+
+// Create inserts the watch; the unique (user_id, product_id, sku) index rejects a second watch of the same price
+func (r *PriceWatchRepository) Create(ctx context.Context, watch *entity.PriceWatch) error {
+	watch.CreatedAt = time.Now()
+	watch.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, watch)
+	if err != nil {
+		return err
+	}
+
+	watch.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *PriceWatchRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.PriceWatch, error) {
+	var watch entity.PriceWatch
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&watch)
+	if err != nil {
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (r *PriceWatchRepository) Update(ctx context.Context, watch *entity.PriceWatch) error {
+	watch.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": watch.ID}, watch)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *PriceWatchRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ListByUser pages through the user's watches, newest first
+func (r *PriceWatchRepository) ListByUser(
+	ctx context.Context,
+	userID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.PriceWatch, entity.PageInfo, error) {
+	key := sortKey{field: "created_at", desc: true}
+	return findPage(ctx, r.collection, bson.M{"user_id": userID}, key, page,
+		func(w *entity.PriceWatch) (interface{}, bson.ObjectID) {
+			return w.CreatedAt, w.ID
+		})
+}
+
+func (r *PriceWatchRepository) GetByUser(ctx context.Context, userID bson.ObjectID) ([]*entity.PriceWatch, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// FindByProduct returns the watches of the product price, or of a variant when sku is given
+func (r *PriceWatchRepository) FindByProduct(ctx context.Context, productID bson.ObjectID, sku string) ([]*entity.PriceWatch, error) {
+	return r.find(ctx, bson.M{"product_id": productID, "sku": sku})
+}
+
+// SetReference records the last price the watch has seen, and when it was notified if it was
+func (r *PriceWatchRepository) SetReference(ctx context.Context, id bson.ObjectID, price money.Amount, notifiedAt *time.Time) error {
+	set := bson.M{"reference_price": price}
+	if notifiedAt != nil {
+		set["notified_at"] = *notifiedAt
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (r *PriceWatchRepository) DeleteByProduct(ctx context.Context, productID bson.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"product_id": productID})
+	return err
+}
+
+func (r *PriceWatchRepository) find(ctx context.Context, filter bson.M) ([]*entity.PriceWatch, error) {
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var watches []*entity.PriceWatch
+	if err := cursor.All(ctx, &watches); err != nil {
+		return nil, err
+	}
+	return watches, nil
+}
@@ -3,9 +3,14 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -43,6 +48,26 @@ func (r *ProductRepository) GetByID(ctx context.Context, id bson.ObjectID) (*ent
 	return &product, nil
 }
 
+// GetByIDs loads the active products among ids in one query, in no particular order
+func (r *ProductRepository) GetByIDs(ctx context.Context, ids []bson.ObjectID) ([]*entity.Product, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "archived_at": notArchived})
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var products []*entity.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
 func (r *ProductRepository) Update(ctx context.Context, product *entity.Product) error {
 	product.UpdatedAt = time.Now()
 
@@ -59,9 +84,401 @@ func (r *ProductRepository) Delete(ctx context.Context, id bson.ObjectID) error
 	return err
 }
 
-func (r *ProductRepository) List(ctx context.Context, limit, offset int) ([]*entity.Product, error) {
-	opts := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset))
+// AddImage appends the image in one update, concurrent uploads don't overwrite each other. The first
+// image also becomes the primary image URL. Returns the product with the image
+func (r *ProductRepository) AddImage(ctx context.Context, id bson.ObjectID, image entity.ProductImage) (*entity.Product, error) {
+	images := bson.M{"$ifNull": bson.A{"$images", bson.A{}}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"images": bson.M{"$concatArrays": bson.A{images, bson.M{"$literal": bson.A{image}}}},
+		"image_url": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$size": images}, 0}},
+			"$image_url",
+			bson.M{"$literal": image.URL},
+		}},
+		"updated_at": time.Now(),
+	}}}
+
+	var product entity.Product
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&product)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// RemoveImage takes the image out of the product in one update. The primary image URL follows the
+// first remaining image; without images one set by hand is kept, one of an uploaded image cleared.
+// Returns the product as it was before, mongo.ErrNoDocuments when it has no such image
+func (r *ProductRepository) RemoveImage(ctx context.Context, id bson.ObjectID, imageID string) (*entity.Product, error) {
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"image_urls": "$images.url",
+			"images": bson.M{"$filter": bson.M{
+				"input": "$images",
+				"cond":  bson.M{"$ne": bson.A{"$$this.id", imageID}},
+			}},
+			"updated_at": time.Now(),
+		}},
+		bson.M{"$set": bson.M{"image_url": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$size": "$images"}, 0}},
+			bson.M{"$arrayElemAt": bson.A{"$images.url", 0}},
+			bson.M{"$cond": bson.A{bson.M{"$in": bson.A{"$image_url", "$image_urls"}}, "", "$image_url"}},
+		}}}},
+		bson.M{"$unset": "image_urls"},
+	}
+
+	var previous entity.Product
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "images.id": imageID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// ReorderImages stores the images, at least one, in the new order with the first one as the primary
+// image URL. It only applies while the product has exactly these images, mongo.ErrNoDocuments is returned
+// when images were added or removed meanwhile
+func (r *ProductRepository) ReorderImages(ctx context.Context, id bson.ObjectID, images []entity.ProductImage) error {
+	ids := make([]string, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+	}
+	filter := bson.M{"_id": id, "images": bson.M{"$size": len(images)}, "images.id": bson.M{"$all": ids}}
+	set := bson.M{"images": images, "image_url": images[0].URL, "updated_at": time.Now()}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetSales replaces the sale windows of the product
+func (r *ProductRepository) SetSales(ctx context.Context, id bson.ObjectID, sales []entity.SaleWindow) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"sales": sales, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetTranslations replaces the translations of the product
+func (r *ProductRepository) SetTranslations(ctx context.Context, id bson.ObjectID, translations entity.Translations) error {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"translations": translations, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AdjustStock adds delta to the stock of the product and, for a sku, of its variant in one update.
+// A decrement only applies to an active product while both have enough stock, otherwise nothing
+// changes and mongo.ErrNoDocuments is returned
+func (r *ProductRepository) AdjustStock(ctx context.Context, id bson.ObjectID, sku string, delta int) error {
+	filter := bson.M{"_id": id}
+	update := bson.M{"stock": delta}
+	opts := options.UpdateOne()
+	if delta < 0 {
+		filter["archived_at"] = notArchived
+		filter["stock"] = bson.M{"$gte": -delta}
+	}
+	if sku != "" {
+		variant := bson.M{"sku": sku}
+		if delta < 0 {
+			variant["stock"] = bson.M{"$gte": -delta}
+		}
+		filter["variants"] = bson.M{"$elemMatch": variant}
+		update["variants.$[v].stock"] = delta
+		opts.SetArrayFilters([]interface{}{bson.M{"v.sku": sku}})
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": update}, opts)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetCurrentPrices stores the current prices of the product and its variants. Prices edited since
+// the product was read are left alone, mongo.ErrNoDocuments is returned then
+func (r *ProductRepository) SetCurrentPrices(ctx context.Context, product *entity.Product) error {
+	filter := bson.M{"_id": product.ID, "price": product.Price}
+	set := bson.M{"current_price": product.CurrentPrice}
+	opts := options.UpdateOne()
+	if len(product.Variants) > 0 {
+		arrayFilters := make([]interface{}, 0, len(product.Variants))
+		for i, v := range product.Variants {
+			name := "v" + strconv.Itoa(i)
+			set["variants.$["+name+"].current_price"] = v.CurrentPrice
+			arrayFilters = append(arrayFilters, bson.M{name + ".sku": v.SKU, name + ".price": v.Price})
+		}
+		opts.SetArrayFilters(arrayFilters)
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set}, opts)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ForEachRepriceCandidate streams the active products whose current prices may be out of date at t:
+// those with a sale window in effect or in one of the given categories, and those whose current
+// prices differ from their regular prices, whose sales may have ended
+func (r *ProductRepository) ForEachRepriceCandidate(
+	ctx context.Context,
+	t time.Time,
+	categories []string,
+	fn func(*entity.Product) error,
+) error {
+	candidates := bson.A{
+		bson.M{"sales": bson.M{"$elemMatch": bson.M{
+			"starts_at": bson.M{"$lte": t},
+			"ends_at":   bson.M{"$gt": t},
+		}}},
+		// Also true for products stored before current prices were kept
+		bson.M{"$expr": bson.M{"$ne": bson.A{"$current_price", "$price"}}},
+		bson.M{"$expr": bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$variants", bson.A{}}},
+			"in":    bson.M{"$ne": bson.A{"$$this.current_price", "$$this.price"}},
+		}}}}},
+	}
+	if len(categories) > 0 {
+		candidates = append(candidates, bson.M{"category": bson.M{"$in": categories}})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"archived_at": notArchived, "$or": candidates}, opts)
+	if err != nil {
+		return err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	for cursor.Next(ctx) {
+		var product entity.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// RenameCategory moves all products of a category to its new name, returns the IDs of the moved products
+func (r *ProductRepository) RenameCategory(ctx context.Context, from, to string) ([]bson.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"category": from}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var moved []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &moved); err != nil {
+		return nil, err
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]bson.ObjectID, len(moved))
+	for i, m := range moved {
+		ids[i] = m.ID
+	}
+	_, err = r.collection.UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "category": from},
+		bson.M{"$set": bson.M{"category": to, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *ProductRepository) CountByCategory(ctx context.Context, category string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"category": category})
+}
+
+// GetCategories returns the distinct category names used by products
+func (r *ProductRepository) GetCategories(ctx context.Context) ([]string, error) {
+	var categories []string
+	if err := r.collection.Distinct(ctx, "category", bson.M{}).Decode(&categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// UpdateRating stores the review aggregates of the product
+func (r *ProductRepository) UpdateRating(ctx context.Context, id bson.ObjectID, summary *entity.RatingSummary) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"rating":       summary.Average,
+			"review_count": summary.Count,
+		}},
+	)
+	return err
+}
+
+// UpsertByExternalSKU creates the product or overwrites the catalog fields of the product with the
+// same external SKU and loads the stored document into product. Returns the product as it was
+// before the write, nil when it was created. Ratings and creation time of existing products are kept.
+// Without variants the product's options and variants are left out: only products that have none
+// are matched, a product with variants makes the upsert fail with a duplicate key error
+func (r *ProductRepository) UpsertByExternalSKU(ctx context.Context, product *entity.Product, variants bool) (*entity.Product, error) {
+	now := time.Now()
+
+	filter := bson.M{"external_sku": product.ExternalSKU}
+	set := bson.M{
+		"name":        product.Name,
+		"description": product.Description,
+		"category":    product.Category,
+		"price":       product.Price,
+		"image_url":   product.ImageURL,
+		"stock":       product.Stock,
+		"tags":        product.Tags,
+		"attributes":  product.Attributes,
+		"updated_at":  now,
+	}
+	if variants {
+		set["options"] = product.Options
+		set["variants"] = product.Variants
+	} else {
+		// Parent price and stock of a product with variants follow its variants, they can't be overwritten
+		filter["variants.0"] = bson.M{"$exists": false}
+	}
+
+	var previous entity.Product
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{
+			"$set": set,
+			"$setOnInsert": bson.M{
+				"rating":       0.0,
+				"review_count": 0,
+				"created_at":   now,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(&previous)
+	created := errors.Is(err, mongo.ErrNoDocuments)
+	if err != nil && !created {
+		return nil, err
+	}
+
+	if err := r.collection.FindOne(ctx, bson.M{"external_sku": product.ExternalSKU}).Decode(product); err != nil {
+		return nil, err
+	}
+	if created {
+		return nil, nil
+	}
+	return &previous, nil
+}
+
+// ForEach streams the whole catalog in _id order without loading it into memory
+func (r *ProductRepository) ForEach(ctx context.Context, fn func(*entity.Product) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
 	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	for cursor.Next(ctx) {
+		var product entity.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Archive soft deletes the product, it stays readable by ID but drops out of listings
+func (r *ProductRepository) Archive(ctx context.Context, id bson.ObjectID) error {
+	now := time.Now()
+	return r.setArchived(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"archived_at": now, "updated_at": now}})
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id bson.ObjectID) error {
+	return r.setArchived(ctx, bson.M{"_id": id}, bson.M{
+		"$unset": bson.M{"archived_at": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	})
+}
+
+func (r *ProductRepository) setArchived(ctx context.Context, filter, update bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ListArchived lists archived products, most recently archived first
+func (r *ProductRepository) ListArchived(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error) {
+	return findPage(ctx, r.collection, bson.M{"archived_at": bson.M{"$exists": true}}, archivedListKey, page,
+		func(p *entity.Product) (interface{}, bson.ObjectID) {
+			return *p.ArchivedAt, p.ID
+		})
+}
+
+// FindArchivedBefore returns products archived before the given time
+func (r *ProductRepository) FindArchivedBefore(ctx context.Context, before time.Time) ([]*entity.Product, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"archived_at": bson.M{"$lt": before}})
 	if err != nil {
 		return nil, err
 	}
@@ -79,23 +496,340 @@ func (r *ProductRepository) List(ctx context.Context, limit, offset int) ([]*ent
 	return products, nil
 }
 
-func (r *ProductRepository) Search(ctx context.Context, query string, category string, limit int) ([]*entity.Product, error) {
-	filter := bson.M{}
+// notArchived - listings, search and recommendation queries only see active products
+var notArchived = bson.M{"$exists": false}
+
+var (
+	// productListKey - the catalog is listed newest first
+	productListKey  = sortKey{field: "created_at", desc: true}
+	archivedListKey = sortKey{field: "archived_at", desc: true}
+)
+
+func (r *ProductRepository) List(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error) {
+	return findPage(ctx, r.collection, bson.M{"archived_at": notArchived}, productListKey, page, productSortValue("created_at"))
+}
+
+// ListMissingTranslations lists active products, newest first, that lack a translation to one of
+// the locales or, having a description, a translated description
+func (r *ProductRepository) ListMissingTranslations(ctx context.Context, locales []string, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error) {
+	missing := bson.A{}
+	for _, l := range locales {
+		missing = append(missing,
+			bson.M{"translations.locale": bson.M{"$ne": l}},
+			bson.M{
+				"description":  bson.M{"$nin": bson.A{nil, ""}},
+				"translations": bson.M{"$elemMatch": bson.M{"locale": l, "description": bson.M{"$in": bson.A{nil, ""}}}},
+			},
+		)
+	}
+	filter := bson.M{"archived_at": notArchived, "$or": missing}
+	return findPage(ctx, r.collection, filter, productListKey, page, productSortValue("created_at"))
+}
+
+const (
+	openPriceBucket = "open"
+
+	// minTextQueryLength - shorter single-term queries use a prefix regex instead of the text index
+	minTextQueryLength = 3
+)
+
+// Search filters products and computes facet counts in a single aggregation
+func (r *ProductRepository) Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error) {
+	textSearch := usesTextSearch(params)
+	key := searchSortKey(params.Sort, textSearch)
+
+	var productStages []bson.M
+	if textSearch {
+		productStages = append(productStages, bson.M{"$addFields": bson.M{"score": bson.M{"$meta": "textScore"}}})
+	}
+	if key.field == "score" && len(params.Boosts) > 0 {
+		factors := []interface{}{"$score"}
+		for _, boost := range params.Boosts {
+			factors = append(factors, bson.M{"$cond": bson.A{matchExpr(boost.Match), boost.Factor, 1}})
+		}
+		productStages = append(productStages, bson.M{"$set": bson.M{"score": bson.M{"$multiply": factors}}})
+	}
+	if params.Page.Cursor != "" {
+		c, err := decodeCursor(params.Page.Cursor, key)
+		if err != nil {
+			return nil, err
+		}
+		productStages = append(productStages, bson.M{"$match": key.after(c)})
+	}
+	productStages = append(productStages,
+		bson.M{"$sort": key.sort()},
+		bson.M{"$limit": params.Page.Limit + 1},
+	)
+
+	facetStages := bson.M{
+		"products":   productStages,
+		"total":      []bson.M{{"$count": "count"}},
+		"categories": []bson.M{{"$sortByCount": "$category"}},
+		"price_buckets": []bson.M{{"$bucket": bson.M{
+			"groupBy":    "$current_price",
+			"boundaries": entity.PriceFacetBoundaries,
+			"default":    openPriceBucket,
+			"output":     bson.M{"count": bson.M{"$sum": 1}},
+		}}},
+		"tags": []bson.M{
+			{"$unwind": "$tags"},
+			{"$sortByCount": "$tags"},
+			{"$limit": entity.TagFacetLimit},
+		},
+	}
+	if len(params.AttributeFacets) > 0 {
+		facetStages["attributes"] = []bson.M{
+			{"$project": bson.M{"attribute": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$attributes", bson.M{}}}}}},
+			{"$unwind": "$attribute"},
+			{"$match": bson.M{"attribute.k": bson.M{"$in": params.AttributeFacets}}},
+			{"$sortByCount": bson.M{"key": "$attribute.k", "value": "$attribute.v"}},
+		}
+	}
+	pipeline := []bson.M{
+		{"$match": buildSearchFilter(params)},
+		{"$facet": facetStages},
+	}
 
-	if query != "" {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var facets struct {
+		Products []*searchHit `bson:"products"`
+		Total    []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		Categories   []entity.FacetCount `bson:"categories"`
+		PriceBuckets []struct {
+			ID    interface{} `bson:"_id"`
+			Count int         `bson:"count"`
+		} `bson:"price_buckets"`
+		Tags       []entity.FacetCount `bson:"tags"`
+		Attributes []struct {
+			ID struct {
+				Key   string      `bson:"key"`
+				Value interface{} `bson:"value"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		} `bson:"attributes"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&facets); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	hits, page, err := finishPage(facets.Products, params.Page.Limit, key, func(h *searchHit) (interface{}, bson.ObjectID) {
+		if key.field == "score" {
+			return h.Score, h.ID
+		}
+		return productSortValue(key.field)(&h.Product)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.ProductSearchResult{
+		Products: make([]*entity.Product, 0, len(hits)),
+		Facets: entity.SearchFacets{
+			Categories: facets.Categories,
+			Tags:       facets.Tags,
+		},
+		Page: page,
+	}
+	for _, h := range hits {
+		result.Products = append(result.Products, &h.Product)
+	}
+	if len(facets.Total) > 0 {
+		result.Page.Total = facets.Total[0].Count
+	}
+
+	for _, b := range facets.PriceBuckets {
+		bucket := entity.PriceBucket{Count: b.Count}
+		if lower, ok := b.ID.(int64); ok {
+			bucket.Min = money.Amount(lower)
+			for i, boundary := range entity.PriceFacetBoundaries[:len(entity.PriceFacetBoundaries)-1] {
+				if boundary == bucket.Min {
+					upper := entity.PriceFacetBoundaries[i+1]
+					bucket.Max = &upper
+				}
+			}
+		} else {
+			bucket.Min = entity.PriceFacetBoundaries[len(entity.PriceFacetBoundaries)-1]
+		}
+		result.Facets.PriceBuckets = append(result.Facets.PriceBuckets, bucket)
+	}
+
+	// Counts come most frequent first, the facet of a key is cut at its limit
+	byKey := make(map[string][]entity.FacetCount)
+	for _, a := range facets.Attributes {
+		if len(byKey[a.ID.Key]) < entity.AttributeFacetLimit {
+			value := entity.FacetCount{Value: entity.FormatAttributeValue(a.ID.Value), Count: a.Count}
+			byKey[a.ID.Key] = append(byKey[a.ID.Key], value)
+		}
+	}
+	for _, key := range params.AttributeFacets {
+		if values, ok := byKey[key]; ok {
+			result.Facets.Attributes = append(result.Facets.Attributes, entity.AttributeFacet{Key: key, Values: values})
+		}
+	}
+
+	return result, nil
+}
+
+func buildSearchFilter(params entity.ProductSearchParams) bson.M {
+	filter := bson.M{"archived_at": notArchived}
+
+	if usesTextSearch(params) {
+		// $text understands "exact phrases" and -negated terms natively. Fuzzy terms are extra
+		// alternatives: they only stand in for words that match nothing, so exact matches score higher
+		search := params.Query
+		for _, terms := range [][]string{params.SynonymTerms, params.FuzzyTerms} {
+			if len(terms) > 0 {
+				search += " " + strings.Join(terms, " ")
+			}
+		}
+		filter["$text"] = bson.M{"$search": search}
+	} else if query := strings.TrimSpace(params.Query); query != "" {
+		prefix := bson.M{"$regex": `\b` + regexp.QuoteMeta(query), "$options": "i"}
 		filter["$or"] = []bson.M{
-			{"name": bson.M{"$regex": query, "$options": "i"}},
-			{"description": bson.M{"$regex": query, "$options": "i"}},
-			{"tags": bson.M{"$regex": query, "$options": "i"}},
+			{"name": prefix},
+			{"translations.name": prefix},
+			{"tags": prefix},
 		}
 	}
 
-	if category != "" {
-		filter["category"] = category
+	if len(params.Categories) > 0 {
+		filter["category"] = bson.M{"$in": params.Categories}
+	}
+	if len(params.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": params.Tags}
 	}
 
+	// Sale prices are filtered on as stored by the last price refresh
+	price := bson.M{}
+	if params.MinPrice != nil {
+		price["$gte"] = *params.MinPrice
+	}
+	if params.MaxPrice != nil {
+		price["$lte"] = *params.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["current_price"] = price
+	}
+
+	if params.MinRating > 0 {
+		filter["rating"] = bson.M{"$gte": params.MinRating}
+	}
+	if params.InStock {
+		filter["stock"] = bson.M{"$gt": 0}
+	}
+	if len(params.ExcludeIDs) > 0 {
+		filter["_id"] = bson.M{"$nin": params.ExcludeIDs}
+	}
+	for _, f := range params.Attributes {
+		cond := bson.M{}
+		if len(f.Values) > 0 {
+			cond["$in"] = f.Values
+		}
+		if f.Min != nil {
+			cond["$gte"] = *f.Min
+		}
+		if f.Max != nil {
+			cond["$lte"] = *f.Max
+		}
+		if len(cond) == 0 {
+			cond["$exists"] = true
+		}
+		filter["attributes."+f.Key] = cond
+	}
+
+	return filter
+}
+
+// matchExpr - an aggregation expression that is true for products selected by m
+func matchExpr(m entity.ProductMatch) bson.M {
+	or := bson.A{}
+	if len(m.ProductIDs) > 0 {
+		or = append(or, bson.M{"$in": bson.A{"$_id", m.ProductIDs}})
+	}
+	if len(m.Categories) > 0 {
+		or = append(or, bson.M{"$in": bson.A{"$category", m.Categories}})
+	}
+	if len(m.Tags) > 0 {
+		shared := bson.M{"$setIntersection": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}, m.Tags}}
+		or = append(or, bson.M{"$gt": bson.A{bson.M{"$size": shared}, 0}})
+	}
+	return bson.M{"$or": or}
+}
+
+// usesTextSearch - the text index matches whole (stemmed) words, so very short single-term
+// queries are treated as word prefixes and matched with a regex instead, unless they have synonyms
+func usesTextSearch(params entity.ProductSearchParams) bool {
+	query := strings.TrimSpace(params.Query)
+	if query == "" {
+		return false
+	}
+	if len(params.SynonymTerms) > 0 || strings.ContainsAny(query, " \"-") {
+		return true
+	}
+	return utf8.RuneCountInString(query) >= minTextQueryLength
+}
+
+// searchHit - a product with its text score, used to build relevance cursors
+type searchHit struct {
+	entity.Product `bson:",inline"`
+	Score          float64 `bson:"score"`
+}
+
+func searchSortKey(sort entity.SearchSort, textSearch bool) sortKey {
+	switch sort {
+	case entity.SortPriceAsc:
+		return sortKey{field: "current_price"}
+	case entity.SortPriceDesc:
+		return sortKey{field: "current_price", desc: true}
+	case entity.SortNewest:
+		return sortKey{field: "created_at", desc: true}
+	case entity.SortRelevance:
+		if textSearch {
+			return sortKey{field: "score", desc: true}
+		}
+		// Relevance without a text score falls back to the best rated products
+		return sortKey{field: "rating", desc: true}
+	default:
+		return sortKey{field: "rating", desc: true}
+	}
+}
+
+// productSortValue returns the value of a product sort field for page cursors
+func productSortValue(field string) func(*entity.Product) (interface{}, bson.ObjectID) {
+	return func(p *entity.Product) (interface{}, bson.ObjectID) {
+		switch field {
+		case "current_price":
+			return p.CurrentPrice, p.ID
+		case "rating":
+			return p.Rating, p.ID
+		case "created_at":
+			return p.CreatedAt, p.ID
+		default:
+			return nil, p.ID
+		}
+	}
+}
+
+func (r *ProductRepository) GetByCategory(ctx context.Context, category string, limit int) ([]*entity.Product, error) {
 	opts := options.Find().SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.collection.Find(ctx, bson.M{"category": category, "archived_at": notArchived}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -110,12 +844,22 @@ func (r *ProductRepository) Search(ctx context.Context, query string, category s
 	if err := cursor.All(ctx, &products); err != nil {
 		return nil, err
 	}
-	return products, err
+	return products, nil
 }
 
-func (r *ProductRepository) GetByCategory(ctx context.Context, category string, limit int) ([]*entity.Product, error) {
-	opts := options.Find().SetLimit(int64(limit))
-	cursor, err := r.collection.Find(ctx, bson.M{"category": category}, opts)
+// FindOnSale returns active products with a sale window in effect at t or in one of the given
+// categories, up to limit
+func (r *ProductRepository) FindOnSale(ctx context.Context, t time.Time, categories []string, limit int) ([]*entity.Product, error) {
+	onSale := bson.A{bson.M{"sales": bson.M{"$elemMatch": bson.M{
+		"starts_at": bson.M{"$lte": t},
+		"ends_at":   bson.M{"$gt": t},
+	}}}}
+	if len(categories) > 0 {
+		onSale = append(onSale, bson.M{"category": bson.M{"$in": categories}})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"archived_at": notArchived, "$or": onSale}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +882,7 @@ func (r *ProductRepository) GetPopular(ctx context.Context, limit int) ([]*entit
 		SetSort(bson.D{{Key: "rating", Value: -1}, {Key: "review_count", Value: -1}}).
 		SetLimit(int64(limit))
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, bson.M{"archived_at": notArchived}, opts)
 	if err != nil {
 		return nil, err
 	}
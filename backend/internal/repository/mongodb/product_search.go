@@ -0,0 +1,36 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProductSearchIndex serves searches straight from the products collection and its text index.
+// The collection is the index, so writes need no syncing
+type ProductSearchIndex struct {
+	repo *ProductRepository
+}
+
+func NewProductSearchIndex(repo *ProductRepository) *ProductSearchIndex {
+	return &ProductSearchIndex{
+		repo: repo,
+	}
+}
+
+func (i *ProductSearchIndex) Index(ctx context.Context, product *entity.Product) error {
+	return nil
+}
+
+func (i *ProductSearchIndex) Delete(ctx context.Context, id bson.ObjectID) error {
+	return nil
+}
+
+func (i *ProductSearchIndex) Replace(ctx context.Context, products []*entity.Product) error {
+	return nil
+}
+
+func (i *ProductSearchIndex) Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error) {
+	return i.repo.Search(ctx, params)
+}
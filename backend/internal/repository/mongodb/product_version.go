@@ -0,0 +1,79 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ProductVersionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProductVersionRepository(db *mongo.Database) *ProductVersionRepository {
+	return &ProductVersionRepository{
+		collection: db.Collection("product_versions"),
+	}
+}
+
+// Create inserts the version; the unique (product_id, version) index rejects concurrent writers of the same number
+func (r *ProductVersionRepository) Create(ctx context.Context, version *entity.ProductVersion) error {
+	version.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	version.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// GetLatestNumber returns the highest version number of the product, 0 when it has no history
+func (r *ProductVersionRepository) GetLatestNumber(ctx context.Context, productID bson.ObjectID) (int, error) {
+	var latest entity.ProductVersion
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"version": 1})
+
+	err := r.collection.FindOne(ctx, bson.M{"product_id": productID}, opts).Decode(&latest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return latest.Version, nil
+}
+
+func (r *ProductVersionRepository) GetByNumber(ctx context.Context, productID bson.ObjectID, number int) (*entity.ProductVersion, error) {
+	var version entity.ProductVersion
+	err := r.collection.FindOne(ctx, bson.M{"product_id": productID, "version": number}).Decode(&version)
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetByProduct pages through the history of a product, newest version first
+func (r *ProductVersionRepository) GetByProduct(
+	ctx context.Context,
+	productID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.ProductVersion, entity.PageInfo, error) {
+	key := sortKey{field: "version", desc: true}
+	return findPage(ctx, r.collection, bson.M{"product_id": productID}, key, page,
+		func(v *entity.ProductVersion) (interface{}, bson.ObjectID) {
+			return v.Version, v.ID
+		})
+}
+
+func (r *ProductVersionRepository) DeleteByProduct(ctx context.Context, productID bson.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"product_id": productID})
+	return err
+}
@@ -0,0 +1,152 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ReviewRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReviewRepository(db *mongo.Database) *ReviewRepository {
+	return &ReviewRepository{
+		collection: db.Collection("reviews"),
+	}
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, review *entity.Review) error {
+	review.CreatedAt = time.Now()
+	review.UpdatedAt = time.Now()
+	if review.HelpfulVoters == nil {
+		review.HelpfulVoters = []bson.ObjectID{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, review)
+	if err != nil {
+		return err
+	}
+
+	review.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.Review, error) {
+	var review entity.Review
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// Update saves the editable fields; votes are managed through AddHelpfulVote/RemoveHelpfulVote
+func (r *ReviewRepository) Update(ctx context.Context, review *entity.Review) error {
+	review.UpdatedAt = time.Now()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": review.ID},
+		bson.M{"$set": bson.M{
+			"rating":            review.Rating,
+			"title":             review.Title,
+			"body":              review.Body,
+			"verified_purchase": review.VerifiedPurchase,
+			"updated_at":        review.UpdatedAt,
+		}},
+	)
+	return err
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *ReviewRepository) GetByProduct(
+	ctx context.Context,
+	productID bson.ObjectID,
+	sort entity.ReviewSort,
+	page entity.PageRequest,
+) ([]*entity.Review, entity.PageInfo, error) {
+	key := sortKey{field: "created_at", desc: true}
+	if sort == entity.ReviewSortHelpful {
+		key = sortKey{field: "helpful_count", desc: true}
+	}
+
+	return findPage(ctx, r.collection, bson.M{"product_id": productID}, key, page,
+		func(rv *entity.Review) (interface{}, bson.ObjectID) {
+			if key.field == "helpful_count" {
+				return rv.HelpfulCount, rv.ID
+			}
+			return rv.CreatedAt, rv.ID
+		})
+}
+
+// AddHelpfulVote counts the user's vote once, reports whether it was added
+func (r *ReviewRepository) AddHelpfulVote(ctx context.Context, id, userID bson.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "helpful_voters": bson.M{"$ne": userID}},
+		bson.M{
+			"$push": bson.M{"helpful_voters": userID},
+			"$inc":  bson.M{"helpful_count": 1},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RemoveHelpfulVote takes the user's vote back, reports whether there was one
+func (r *ReviewRepository) RemoveHelpfulVote(ctx context.Context, id, userID bson.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "helpful_voters": userID},
+		bson.M{
+			"$pull": bson.M{"helpful_voters": userID},
+			"$inc":  bson.M{"helpful_count": -1},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// GetRatingSummary averages all ratings of the product, zero values when it has no reviews
+func (r *ReviewRepository) GetRatingSummary(ctx context.Context, productID bson.ObjectID) (*entity.RatingSummary, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"product_id": productID}},
+		{"$group": bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$rating"},
+			"count":   bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	summary := &entity.RatingSummary{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(summary); err != nil {
+			return nil, err
+		}
+	}
+	return summary, cursor.Err()
+}
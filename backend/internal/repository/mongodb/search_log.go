@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type SearchLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchLogRepository(db *mongo.Database) *SearchLogRepository {
+	return &SearchLogRepository{
+		collection: db.Collection("search_logs"),
+	}
+}
+
+func (r *SearchLogRepository) Create(ctx context.Context, log *entity.SearchLog) error {
+	log.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, log)
+	if err != nil {
+		return err
+	}
+
+	log.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *SearchLogRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.SearchLog, error) {
+	var log entity.SearchLog
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&log)
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// AddClick counts a click on the result at position, once per position. Reports whether it was counted
+func (r *SearchLogRepository) AddClick(ctx context.Context, id bson.ObjectID, position int) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "clicked_positions": bson.M{"$ne": position}}, bson.M{
+		"$inc":  bson.M{"clicks": 1, "click_position_sum": position},
+		"$push": bson.M{"clicked_positions": position},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// GetStats sums up the searches since the given time, further pages excluded
+func (r *SearchLogRepository) GetStats(ctx context.Context, since time.Time) (*entity.SearchStats, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": bson.M{"$gte": since}, "next_page": false}},
+		{"$group": bson.M{
+			"_id":                nil,
+			"searches":           bson.M{"$sum": 1},
+			"zero_results":       bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$results", 0}}, 1, 0}}},
+			"clicked":            bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$clicks", 0}}, 1, 0}}},
+			"clicks":             bson.M{"$sum": "$clicks"},
+			"click_position_sum": bson.M{"$sum": "$click_position_sum"},
+			"latency":            bson.M{"$avg": "$latency_ms"},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	stats := &entity.SearchStats{Since: since}
+	if cursor.Next(ctx) {
+		var result struct {
+			Searches         int     `bson:"searches"`
+			ZeroResults      int     `bson:"zero_results"`
+			Clicked          int     `bson:"clicked"`
+			Clicks           int     `bson:"clicks"`
+			ClickPositionSum int     `bson:"click_position_sum"`
+			Latency          float64 `bson:"latency"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		stats.Searches = result.Searches
+		stats.ZeroResultSearches = result.ZeroResults
+		stats.ClickedSearches = result.Clicked
+		stats.Clicks = result.Clicks
+		stats.AvgLatencyMs = result.Latency
+		if result.Clicks > 0 {
+			stats.AvgClickPosition = float64(result.ClickPositionSum) / float64(result.Clicks)
+		}
+	}
+
+	if stats.Searches > 0 {
+		stats.ZeroResultRate = float64(stats.ZeroResultSearches) / float64(stats.Searches)
+		stats.CTR = float64(stats.ClickedSearches) / float64(stats.Searches)
+	}
+	return stats, cursor.Err()
+}
+
+// TopQueries returns the most searched queries since the given time
+func (r *SearchLogRepository) TopQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error) {
+	return r.queryStats(ctx, bson.M{"created_at": bson.M{"$gte": since}, "next_page": false}, limit)
+}
+
+// ZeroResultQueries returns the most searched queries that found nothing since the given time
+func (r *SearchLogRepository) ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error) {
+	return r.queryStats(ctx, bson.M{"created_at": bson.M{"$gte": since}, "next_page": false, "results": 0}, limit)
+}
+
+func (r *SearchLogRepository) queryStats(ctx context.Context, match bson.M, limit int) ([]*entity.SearchQueryStats, error) {
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":              "$query",
+			"searches":         bson.M{"$sum": 1},
+			"avg_results":      bson.M{"$avg": "$results"},
+			"clicked":          bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$gt": []interface{}{"$clicks", 0}}, 1, 0}}},
+			"last_searched_at": bson.M{"$max": "$created_at"},
+		}},
+		{"$sort": bson.D{{Key: "searches", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": limit},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	stats := []*entity.SearchQueryStats{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		s.CTR = float64(s.ClickedSearches) / float64(s.Searches)
+	}
+	return stats, nil
+}
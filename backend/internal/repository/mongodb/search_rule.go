@@ -0,0 +1,106 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SearchRuleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSearchRuleRepository(db *mongo.Database) *SearchRuleRepository {
+	return &SearchRuleRepository{
+		collection: db.Collection("search_rules"),
+	}
+}
+
+func (r *SearchRuleRepository) Create(ctx context.Context, rule *entity.SearchRule) error {
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, rule)
+	if err != nil {
+		return err
+	}
+
+	rule.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *SearchRuleRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.SearchRule, error) {
+	var rule entity.SearchRule
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *SearchRuleRepository) Update(ctx context.Context, rule *entity.SearchRule) error {
+	rule.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *SearchRuleRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// List pages through all rules, active or not, newest first
+func (r *SearchRuleRepository) List(ctx context.Context, page entity.PageRequest) ([]*entity.SearchRule, entity.PageInfo, error) {
+	key := sortKey{field: "created_at", desc: true}
+	return findPage(ctx, r.collection, bson.M{}, key, page,
+		func(rule *entity.SearchRule) (interface{}, bson.ObjectID) {
+			return rule.CreatedAt, rule.ID
+		})
+}
+
+// FindActive returns the rules for the normalized query that are active at the given time, oldest first
+func (r *SearchRuleRepository) FindActive(ctx context.Context, query string, at time.Time) ([]*entity.SearchRule, error) {
+	filter := bson.M{
+		"queries": query,
+		"$and": []bson.M{
+			{"$or": []bson.M{{"active_from": nil}, {"active_from": bson.M{"$lte": at}}}},
+			{"$or": []bson.M{{"active_until": nil}, {"active_until": bson.M{"$gt": at}}}},
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var rules []*entity.SearchRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,96 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type SynonymRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSynonymRepository(db *mongo.Database) *SynonymRepository {
+	return &SynonymRepository{
+		collection: db.Collection("search_synonyms"),
+	}
+}
+
+func (r *SynonymRepository) Create(ctx context.Context, set *entity.SynonymSet) error {
+	set.CreatedAt = time.Now()
+	set.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, set)
+	if err != nil {
+		return err
+	}
+
+	set.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *SynonymRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.SynonymSet, error) {
+	var set entity.SynonymSet
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&set)
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (r *SynonymRepository) Update(ctx context.Context, set *entity.SynonymSet) error {
+	set.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": set.ID}, set)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *SynonymRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// List pages through the synonym sets, newest first
+func (r *SynonymRepository) List(ctx context.Context, page entity.PageRequest) ([]*entity.SynonymSet, entity.PageInfo, error) {
+	key := sortKey{field: "created_at", desc: true}
+	return findPage(ctx, r.collection, bson.M{}, key, page,
+		func(s *entity.SynonymSet) (interface{}, bson.ObjectID) {
+			return s.CreatedAt, s.ID
+		})
+}
+
+// FindByTerms returns the sets containing any of the terms
+func (r *SynonymRepository) FindByTerms(ctx context.Context, terms []string) ([]*entity.SynonymSet, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"terms": bson.M{"$in": terms}})
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var sets []*entity.SynonymSet
+	if err := cursor.All(ctx, &sets); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
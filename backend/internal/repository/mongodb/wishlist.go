@@ -0,0 +1,145 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type WishlistRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWishlistRepository(db *mongo.Database) *WishlistRepository {
+	return &WishlistRepository{
+		collection: db.Collection("wishlists"),
+	}
+}
+
+func (r *WishlistRepository) Create(ctx context.Context, wishlist *entity.Wishlist) error {
+	wishlist.CreatedAt = time.Now()
+	wishlist.UpdatedAt = time.Now()
+	if wishlist.Items == nil {
+		wishlist.Items = []entity.WishlistItem{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, wishlist)
+	if err != nil {
+		return err
+	}
+
+	wishlist.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *WishlistRepository) GetByID(ctx context.Context, id bson.ObjectID) (*entity.Wishlist, error) {
+	var wishlist entity.Wishlist
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&wishlist)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *WishlistRepository) GetByShareToken(ctx context.Context, token string) (*entity.Wishlist, error) {
+	var wishlist entity.Wishlist
+	err := r.collection.FindOne(ctx, bson.M{"share_token": token, "is_public": true}).Decode(&wishlist)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *WishlistRepository) GetDefault(ctx context.Context, userID bson.ObjectID) (*entity.Wishlist, error) {
+	var wishlist entity.Wishlist
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID, "is_default": true}).Decode(&wishlist)
+	if err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
+}
+
+func (r *WishlistRepository) GetUserWishlists(ctx context.Context, userID bson.ObjectID) ([]*entity.Wishlist, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		closeErr := cursor.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(cursor, ctx)
+
+	var wishlists []*entity.Wishlist
+	if err := cursor.All(ctx, &wishlists); err != nil {
+		return nil, err
+	}
+	return wishlists, nil
+}
+
+// Update saves list metadata; items are managed through AddItem/RemoveItem. An empty share token
+// is removed rather than stored, share tokens are unique among the lists that have one
+func (r *WishlistRepository) Update(ctx context.Context, wishlist *entity.Wishlist) error {
+	wishlist.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"name":       wishlist.Name,
+		"is_public":  wishlist.IsPublic,
+		"updated_at": wishlist.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if wishlist.ShareToken != "" {
+		set["share_token"] = wishlist.ShareToken
+	} else {
+		update["$unset"] = bson.M{"share_token": ""}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": wishlist.ID}, update)
+	return err
+}
+
+func (r *WishlistRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddItem pushes the item unless the product is already on the list, reports whether it was added
+func (r *WishlistRepository) AddItem(ctx context.Context, id bson.ObjectID, item entity.WishlistItem) (bool, error) {
+	item.AddedAt = time.Now()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "items.product_id": bson.M{"$ne": item.ProductID}},
+		bson.M{
+			"$push": bson.M{"items": item},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RemoveItem pulls the product from the list, reports whether it was present
+func (r *WishlistRepository) RemoveItem(ctx context.Context, id, productID bson.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "items.product_id": productID},
+		bson.M{
+			"$pull": bson.M{"items": bson.M{"product_id": productID}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -9,6 +9,9 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// comparedWithWeight - how much one comparison of two products adds to their similarity
+const comparedWithWeight = 5.0
+
 type GraphRepository struct {
 	driver neo4j.DriverWithContext
 }
@@ -52,6 +55,68 @@ func (r *GraphRepository) CreateUserProductRelation(
 	return err
 }
 
+// MergeUsers re-points every INTERACTED edge of fromID onto toID (summing weights and counts) and removes the old node
+func (r *GraphRepository) MergeUsers(ctx context.Context, fromID, toID bson.ObjectID) error {
+	var err error
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func(session neo4j.SessionWithContext, ctx context.Context) {
+		closeErr := session.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(session, ctx)
+
+	mergeQuery := `
+		MATCH (from:User {id: $fromID})-[old:INTERACTED]->(p:Product)
+		MERGE (to:User {id: $toID})
+		MERGE (to)-[r:INTERACTED {type: old.type}]->(p)
+		ON CREATE SET r.weight = old.weight, r.count = old.count, r.created_at = old.created_at, r.updated_at = timestamp()
+		ON MATCH SET r.weight = r.weight + old.weight, r.count = r.count + old.count, r.updated_at = timestamp()
+		DELETE old
+	`
+	deleteQuery := `
+		MATCH (from:User {id: $fromID})
+		DETACH DELETE from
+	`
+	params := map[string]interface{}{
+		"fromID": fromID.Hex(),
+		"toID":   toID.Hex(),
+	}
+
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		if _, err := tx.Run(ctx, mergeQuery, params); err != nil {
+			return nil, err
+		}
+		_, err := tx.Run(ctx, deleteQuery, params)
+		return nil, err
+	})
+
+	return err
+}
+
+// DeleteProduct removes the product node together with every relationship pointing to it
+func (r *GraphRepository) DeleteProduct(ctx context.Context, productID bson.ObjectID) error {
+	var err error
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func(session neo4j.SessionWithContext, ctx context.Context) {
+		closeErr := session.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(session, ctx)
+
+	query := `
+		MATCH (p:Product {id: $productID})
+		DETACH DELETE p
+	`
+
+	_, err = session.Run(ctx, query, map[string]interface{}{
+		"productID": productID.Hex(),
+	})
+
+	return err
+}
+
 func (r *GraphRepository) GetUserProductRelations(ctx context.Context, userID bson.ObjectID) ([]entity.Interaction, error) {
 	var err error
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
@@ -254,18 +319,27 @@ func (r *GraphRepository) GetSimilarProducts(
 		}
 	}(session, ctx)
 
+	// Products customers compared with this one are substitutes, each comparison counts like
+	// a shared interaction of comparedWithWeight
 	query := `
-        MATCH (p1:Product {id: $productID})<-[r1:INTERACTED]-(u:User)-[r2:INTERACTED]->(p2:Product)
-        WHERE p1 <> p2
-        WITH p2, SUM(r1.weight * r2.weight) AS similarity
+        CALL {
+            MATCH (p1:Product {id: $productID})<-[r1:INTERACTED]-(u:User)-[r2:INTERACTED]->(p2:Product)
+            WHERE p1 <> p2
+            RETURN p2, r1.weight * r2.weight AS score
+            UNION ALL
+            MATCH (:Product {id: $productID})-[c:COMPARED_WITH]-(p2:Product)
+            RETURN p2, c.count * $comparedWithWeight AS score
+        }
+        WITH p2, SUM(score) AS similarity
         RETURN p2.id AS productID, similarity
         ORDER BY similarity DESC
         LIMIT $limit
     `
 
 	result, err := session.Run(ctx, query, map[string]interface{}{
-		"productID": productID.Hex(),
-		"limit":     limit,
+		"productID":          productID.Hex(),
+		"limit":              limit,
+		"comparedWithWeight": comparedWithWeight,
 	})
 	if err != nil {
 		return nil, err
@@ -282,6 +356,41 @@ func (r *GraphRepository) GetSimilarProducts(
 	return productIDs, result.Err()
 }
 
+// RecordComparison links every pair of the products with an undirected COMPARED_WITH edge,
+// counting how often the pair was compared
+func (r *GraphRepository) RecordComparison(ctx context.Context, productIDs []bson.ObjectID) error {
+	var err error
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer func(session neo4j.SessionWithContext, ctx context.Context) {
+		closeErr := session.Close(ctx)
+		if closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+	}(session, ctx)
+
+	// Pairs are stored once, from the lower ID to the higher one
+	query := `
+		UNWIND $productIDs AS id1
+		UNWIND $productIDs AS id2
+		WITH id1, id2 WHERE id1 < id2
+		MERGE (p1:Product {id: id1})
+		MERGE (p2:Product {id: id2})
+		MERGE (p1)-[c:COMPARED_WITH]->(p2)
+		ON CREATE SET c.count = 1, c.created_at = timestamp()
+		ON MATCH SET c.count = c.count + 1, c.updated_at = timestamp()
+	`
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.Hex()
+	}
+	_, err = session.Run(ctx, query, map[string]interface{}{
+		"productIDs": ids,
+	})
+
+	return err
+}
+
 func (r *GraphRepository) CalculateUserSimilarity(
 	ctx context.Context,
 	userID1, userID2 bson.ObjectID,
@@ -31,8 +31,11 @@ func (r *CacheRepository) Set(ctx context.Context, key string, value string, ttl
 	return r.client.Set(ctx, key, value, time.Duration(ttl)*time.Second).Err()
 }
 
-func (r *CacheRepository) Delete(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+func (r *CacheRepository) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
 }
 
 func (r *CacheRepository) IncrementCounter(ctx context.Context, key string) (int64, error) {
@@ -52,6 +55,25 @@ func (r *CacheRepository) GetTopN(ctx context.Context, key string, n int) ([]str
 	return r.client.ZRevRange(ctx, key, 0, int64(n-1)).Result()
 }
 
+// AddToSet adds members to a set and refreshes its TTL
+func (r *CacheRepository) AddToSet(ctx context.Context, key string, ttl int, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, m := range members {
+		values[i] = m
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.SAdd(ctx, key, values...)
+	pipe.Expire(ctx, key, time.Duration(ttl)*time.Second)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetSetMembers returns all members of the set, empty when the key doesn't exist
+func (r *CacheRepository) GetSetMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
 // Cache keys helper
 func RecommendationCacheKey(userID string) string {
 	return fmt.Sprintf("rec:user:%s", userID)
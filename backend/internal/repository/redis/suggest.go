@@ -0,0 +1,333 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/redis/go-redis/v9"
+)
+
+// Each source keeps two sorted sets over the same members: a lexicographic index (all scores 0)
+// for prefix ranges and a score set for popularity. Members are "term\x00type\x00ref\x00text".
+// Short prefixes match too many terms to rank on read, each one also has a set of its most popular
+// members, kept under a generation that is swapped in whole when the sets are rebuilt
+const (
+	suggestCatalogLexKey   = "suggest:catalog:lex"
+	suggestCatalogScoreKey = "suggest:catalog:score"
+	suggestCatalogPrefix   = "suggest:catalog:prefix"
+	suggestQueryLexKey     = "suggest:queries:lex"
+	suggestQueryScoreKey   = "suggest:queries:score"
+	suggestQueryPrefix     = "suggest:queries:prefix"
+
+	suggestMemberSeparator = "\x00"
+
+	// suggestPrefixRunes - prefixes up to this length have a ranked set, longer ones match few enough terms to scan
+	suggestPrefixRunes = 12
+	// suggestPrefixTop - members kept per ranked prefix set
+	suggestPrefixTop = 100
+	suggestBatchSize = 1000
+)
+
+// suggestSource - the keys of one suggestion source
+type suggestSource struct {
+	lex, score, prefix string
+}
+
+var suggestSources = []suggestSource{
+	{suggestCatalogLexKey, suggestCatalogScoreKey, suggestCatalogPrefix},
+	{suggestQueryLexKey, suggestQueryScoreKey, suggestQueryPrefix},
+}
+
+type SuggestRepository struct {
+	client *redis.Client
+}
+
+func NewSuggestRepository(client *redis.Client) *SuggestRepository {
+	return &SuggestRepository{
+		client: client,
+	}
+}
+
+// ReplaceCatalog swaps in a freshly built product and category index. The sets are written
+// under temporary keys and renamed, readers never see a half-built index
+func (r *SuggestRepository) ReplaceCatalog(ctx context.Context, terms []entity.SuggestionTerm) error {
+	lexTmp, scoreTmp := suggestCatalogLexKey+":tmp", suggestCatalogScoreKey+":tmp"
+	if err := r.client.Del(ctx, lexTmp, scoreTmp).Err(); err != nil {
+		return err
+	}
+
+	ranked := make(prefixSets)
+	for start := 0; start < len(terms); start += suggestBatchSize {
+		end := min(start+suggestBatchSize, len(terms))
+		lex := make([]redis.Z, 0, end-start)
+		scores := make([]redis.Z, 0, end-start)
+		for _, t := range terms[start:end] {
+			member := encodeSuggestion(t.Term, t.Suggestion)
+			lex = append(lex, redis.Z{Member: member})
+			scores = append(scores, redis.Z{Score: t.Suggestion.Score, Member: member})
+			ranked.add(t.Term, redis.Z{Score: t.Suggestion.Score, Member: member})
+		}
+
+		pipe := r.client.Pipeline()
+		pipe.ZAdd(ctx, lexTmp, lex...)
+		pipe.ZAdd(ctx, scoreTmp, scores...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	pipe := r.client.TxPipeline()
+	if len(terms) == 0 {
+		pipe.Del(ctx, suggestCatalogLexKey, suggestCatalogScoreKey)
+	} else {
+		pipe.Rename(ctx, lexTmp, suggestCatalogLexKey)
+		pipe.Rename(ctx, scoreTmp, suggestCatalogScoreKey)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	return r.replacePrefixSets(ctx, suggestCatalogPrefix, ranked)
+}
+
+// IncrementQuery counts one more search for the normalized query. The ranked prefix sets
+// count it too, they are capped again on the next TrimQueries
+func (r *SuggestRepository) IncrementQuery(ctx context.Context, query string) error {
+	member := encodeSuggestion(query, entity.Suggestion{Text: query, Type: entity.SuggestionQuery})
+	generation, err := r.generation(ctx, suggestQueryPrefix)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.ZAddNX(ctx, suggestQueryLexKey, redis.Z{Member: member})
+	pipe.ZIncrBy(ctx, suggestQueryScoreKey, 1, member)
+	for _, prefix := range suggestPrefixes(query) {
+		pipe.ZIncrBy(ctx, prefixSetKey(suggestQueryPrefix, generation, prefix), 1, member)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TrimQueries keeps the keep most frequent queries and rebuilds their ranked prefix sets
+func (r *SuggestRepository) TrimQueries(ctx context.Context, keep int) error {
+	stale, err := r.client.ZRange(ctx, suggestQueryScoreKey, 0, int64(-keep-1)).Result()
+	if err != nil {
+		return err
+	}
+
+	if len(stale) > 0 {
+		members := make([]interface{}, len(stale))
+		for i, m := range stale {
+			members[i] = m
+		}
+		pipe := r.client.TxPipeline()
+		pipe.ZRem(ctx, suggestQueryLexKey, members...)
+		pipe.ZRem(ctx, suggestQueryScoreKey, members...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	queries, err := r.client.ZRangeWithScores(ctx, suggestQueryScoreKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	ranked := make(prefixSets)
+	for _, q := range queries {
+		member, _ := q.Member.(string)
+		term, _, _ := strings.Cut(member, suggestMemberSeparator)
+		ranked.add(term, q)
+	}
+	return r.replacePrefixSets(ctx, suggestQueryPrefix, ranked)
+}
+
+// FindByPrefix returns up to scan candidates per source whose term starts with prefix, with their
+// scores. Short prefixes return the most popular candidates, longer ones the first in term order
+func (r *SuggestRepository) FindByPrefix(ctx context.Context, prefix string, scan int) ([]entity.Suggestion, error) {
+	var suggestions []entity.Suggestion
+	for _, source := range suggestSources {
+		var (
+			candidates []redis.Z
+			err        error
+		)
+		if utf8.RuneCountInString(prefix) <= suggestPrefixRunes {
+			candidates, err = r.findRanked(ctx, source, prefix, scan)
+		} else {
+			candidates, err = r.scanByLex(ctx, source, prefix, scan)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range candidates {
+			member, _ := c.Member.(string)
+			s, ok := decodeSuggestion(member)
+			if !ok {
+				continue
+			}
+			s.Score = c.Score
+			suggestions = append(suggestions, s)
+		}
+	}
+	return suggestions, nil
+}
+
+func (r *SuggestRepository) findRanked(ctx context.Context, source suggestSource, prefix string, limit int) ([]redis.Z, error) {
+	generation, err := r.generation(ctx, source.prefix)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.ZRevRangeWithScores(ctx, prefixSetKey(source.prefix, generation, prefix), 0, int64(limit-1)).Result()
+}
+
+func (r *SuggestRepository) scanByLex(ctx context.Context, source suggestSource, prefix string, limit int) ([]redis.Z, error) {
+	members, err := r.client.ZRangeByLex(ctx, source.lex, &redis.ZRangeBy{
+		Min:   "[" + prefix,
+		Max:   "[" + prefix + "\xff",
+		Count: int64(limit),
+	}).Result()
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+
+	scores, err := r.client.ZMScore(ctx, source.score, members...).Result()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]redis.Z, len(members))
+	for i, member := range members {
+		candidates[i] = redis.Z{Score: scores[i], Member: member}
+	}
+	return candidates, nil
+}
+
+// replacePrefixSets writes the ranked sets under a new generation, makes it current and drops the
+// sets of the previous one
+func (r *SuggestRepository) replacePrefixSets(ctx context.Context, base string, ranked prefixSets) error {
+	previous, err := r.generation(ctx, base)
+	if err != nil {
+		return err
+	}
+	next, err := r.client.Incr(ctx, base+":seq").Result()
+	if err != nil {
+		return err
+	}
+	generation := strconv.FormatInt(next, 10)
+
+	pipe := r.client.Pipeline()
+	queued := 0
+	for prefix, members := range ranked {
+		pipe.ZAdd(ctx, prefixSetKey(base, generation, prefix), topMembers(members)...)
+		if queued++; queued == suggestBatchSize {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			queued = 0
+		}
+	}
+	if queued > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := r.client.Set(ctx, base+":gen", generation, 0).Err(); err != nil {
+		return err
+	}
+	return r.dropGeneration(ctx, base, previous)
+}
+
+// generation returns the current generation of the ranked sets, "0" before the first rebuild
+func (r *SuggestRepository) generation(ctx context.Context, base string) (string, error) {
+	generation, err := r.client.Get(ctx, base+":gen").Result()
+	if errors.Is(err, redis.Nil) {
+		return "0", nil
+	}
+	return generation, err
+}
+
+func (r *SuggestRepository) dropGeneration(ctx context.Context, base, generation string) error {
+	iter := r.client.Scan(ctx, 0, base+":"+generation+":*", suggestBatchSize).Iterator()
+	keys := make([]string, 0, suggestBatchSize)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == suggestBatchSize {
+			if err := r.client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.client.Unlink(ctx, keys...).Err()
+	}
+	return nil
+}
+
+// prefixSets - the members of each ranked prefix set while it is built, at most twice the kept top
+type prefixSets map[string][]redis.Z
+
+func (p prefixSets) add(term string, member redis.Z) {
+	for _, prefix := range suggestPrefixes(term) {
+		p[prefix] = append(p[prefix], member)
+		if len(p[prefix]) >= 2*suggestPrefixTop {
+			p[prefix] = topMembers(p[prefix])
+		}
+	}
+}
+
+// topMembers sorts the members by score, highest first, and keeps the first suggestPrefixTop
+func topMembers(members []redis.Z) []redis.Z {
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Score > members[j].Score
+	})
+	return members[:min(len(members), suggestPrefixTop)]
+}
+
+// suggestPrefixes lists the prefixes of the term that have a ranked set, the shortest one rune long
+func suggestPrefixes(term string) []string {
+	var prefixes []string
+	for i, r := range term {
+		if len(prefixes) == suggestPrefixRunes {
+			break
+		}
+		prefixes = append(prefixes, term[:i+utf8.RuneLen(r)])
+	}
+	return prefixes
+}
+
+func prefixSetKey(base, generation, prefix string) string {
+	return base + ":" + generation + ":" + prefix
+}
+
+func encodeSuggestion(term string, s entity.Suggestion) string {
+	ref := s.ProductID
+	if s.Type == entity.SuggestionCategory {
+		ref = s.CategorySlug
+	}
+	return strings.Join([]string{term, string(s.Type), ref, s.Text}, suggestMemberSeparator)
+}
+
+func decodeSuggestion(member string) (entity.Suggestion, bool) {
+	parts := strings.SplitN(member, suggestMemberSeparator, 4)
+	if len(parts) != 4 {
+		return entity.Suggestion{}, false
+	}
+
+	s := entity.Suggestion{Type: entity.SuggestionType(parts[1]), Text: parts[3]}
+	switch s.Type {
+	case entity.SuggestionProduct:
+		s.ProductID = parts[2]
+	case entity.SuggestionCategory:
+		s.CategorySlug = parts[2]
+	}
+	return s, true
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func productCacheKey(id bson.ObjectID) string {
+	return fmt.Sprintf("product:%s", id.Hex())
+}
+
+// productListVersionKey is bumped on every catalog write, list pages cached under an older version are never read again
+func productListVersionKey() string {
+	return "products:list:version"
+}
+
+func productListCacheKey(version, cursor string, limit int) string {
+	return fmt.Sprintf("products:list:v%s:%s:%d", version, cursor, limit)
+}
+
+func userRecCacheKey(userID bson.ObjectID) string {
+	return fmt.Sprintf("rec:user:%s", userID.Hex())
+}
+
+func productRecCacheKey(productID bson.ObjectID) string {
+	return fmt.Sprintf("rec:product:%s", productID.Hex())
+}
+
+// productUserRecRefsKey holds the IDs of users whose cached recommendations include this product
+func productUserRecRefsKey(productID bson.ObjectID) string {
+	return fmt.Sprintf("rec:user:refs:%s", productID.Hex())
+}
+
+// productRecRefsKey holds the IDs of products whose cached "related products" include this product
+func productRecRefsKey(productID bson.ObjectID) string {
+	return fmt.Sprintf("rec:product:refs:%s", productID.Hex())
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+type CartRecoveryUseCase struct {
+	interactionRepo     InteractionRepository
+	reminderRepo        CartReminderRepository
+	userRepo            UserRepository
+	productRepo         ProductRepository
+	recommender         RecommendationEngine
+	notifier            Notifier
+	l                   *zap.SugaredLogger
+	abandonWindow       time.Duration
+	lookback            time.Duration
+	attributionWindow   time.Duration
+	recommendationCount int
+}
+
+func NewCartRecoveryUseCase(
+	interactionRepo InteractionRepository,
+	reminderRepo CartReminderRepository,
+	userRepo UserRepository,
+	productRepo ProductRepository,
+	recommender RecommendationEngine,
+	notifier Notifier,
+	l *zap.SugaredLogger,
+	abandonWindow, lookback, attributionWindow time.Duration,
+	recommendationCount int,
+) *CartRecoveryUseCase {
+	return &CartRecoveryUseCase{
+		interactionRepo:     interactionRepo,
+		reminderRepo:        reminderRepo,
+		userRepo:            userRepo,
+		productRepo:         productRepo,
+		recommender:         recommender,
+		notifier:            notifier,
+		l:                   l,
+		abandonWindow:       abandonWindow,
+		lookback:            lookback,
+		attributionWindow:   attributionWindow,
+		recommendationCount: recommendationCount,
+	}
+}
+
+// Run is the scheduled job: remind users about abandoned carts, then attribute recoveries
+func (uc *CartRecoveryUseCase) Run(ctx context.Context) (*entity.CartRecoveryRun, error) {
+	sent, failed, err := uc.SendReminders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	recovered, err := uc.TrackRecoveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.CartRecoveryRun{RemindersSent: sent, Failed: failed, Recovered: recovered}, nil
+}
+
+// SendReminders notifies every user with an abandoned cart who wasn't reminded since their last cart action.
+// Returns the reminders sent and the carts whose reminder failed, those are logged and retried next run
+func (uc *CartRecoveryUseCase) SendReminders(ctx context.Context) (int, int, error) {
+	carts, err := uc.interactionRepo.FindAbandonedCarts(ctx, time.Now().Add(-uc.lookback), uc.abandonWindow)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, cart := range carts {
+		reminded, err := uc.reminderRepo.ExistsSince(ctx, cart.UserID, cart.LastCartAt)
+		if err != nil {
+			return sent, failed, err
+		}
+		if reminded {
+			continue
+		}
+
+		if err := uc.remind(ctx, cart); err != nil {
+			// Keep going with the rest, one user that can't be notified doesn't stop the run
+			uc.l.Warnw("Failed to send cart reminder", "user_id", cart.UserID.Hex(), "error", err)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	return sent, failed, nil
+}
+
+// TrackRecoveries marks reminders whose products were purchased after the reminder was sent
+func (uc *CartRecoveryUseCase) TrackRecoveries(ctx context.Context) (int, error) {
+	reminders, err := uc.reminderRepo.GetUnrecovered(ctx, time.Now().Add(-uc.attributionWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, reminder := range reminders {
+		purchase, err := uc.interactionRepo.FindPurchaseContaining(ctx, reminder.UserID, reminder.ProductIDs, reminder.SentAt)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return recovered, err
+		}
+
+		if err := uc.reminderRepo.MarkRecovered(ctx, reminder.ID, purchase); err != nil {
+			return recovered, err
+		}
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+func (uc *CartRecoveryUseCase) GetStats(ctx context.Context, since time.Time) (*entity.CartRecoveryStats, error) {
+	return uc.reminderRepo.GetStats(ctx, since)
+}
+
+func (uc *CartRecoveryUseCase) remind(ctx context.Context, cart *entity.AbandonedCart) error {
+	user, err := uc.userRepo.GetByID(ctx, cart.UserID)
+	if err != nil {
+		return err
+	}
+
+	inCart := make(map[bson.ObjectID]bool, len(cart.ProductIDs))
+	var products []*entity.Product
+	for _, productID := range cart.ProductIDs {
+		inCart[productID] = true
+		product, err := uc.productRepo.GetByID(ctx, productID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		products = append(products, product)
+	}
+	if len(products) == 0 {
+		return ErrProductNotFound
+	}
+
+	message := &entity.CartReminderMessage{
+		User:            user,
+		Products:        products,
+		Recommendations: uc.recommendationsFor(ctx, products, inCart),
+	}
+	if err := uc.notifier.SendCartReminder(ctx, message); err != nil {
+		return err
+	}
+
+	return uc.reminderRepo.Create(ctx, &entity.CartReminder{
+		UserID:     cart.UserID,
+		ProductIDs: cart.ProductIDs,
+		LastCartAt: cart.LastCartAt,
+	})
+}
+
+// recommendationsFor collects "also viewed" products for the cart items, skipping what is already in the cart
+func (uc *CartRecoveryUseCase) recommendationsFor(
+	ctx context.Context,
+	products []*entity.Product,
+	exclude map[bson.ObjectID]bool,
+) []*entity.Product {
+	var recommendations []*entity.Product
+	for _, product := range products {
+		related, err := uc.recommender.GetProductRecommendations(ctx, product.ID, uc.recommendationCount)
+		if err != nil {
+			// The reminder still goes out, with fewer recommendations
+			uc.l.Warnw("Failed to get cart reminder recommendations", "product_id", product.ID.Hex(), "error", err)
+			continue
+		}
+
+		for _, r := range related {
+			if exclude[r.ID] {
+				continue
+			}
+			exclude[r.ID] = true
+			recommendations = append(recommendations, r)
+
+			if len(recommendations) >= uc.recommendationCount {
+				return recommendations
+			}
+		}
+	}
+	return recommendations
+}
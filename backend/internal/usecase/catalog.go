@@ -0,0 +1,374 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// importProgressEvery - how many rows are processed between progress saves
+	importProgressEvery = 100
+	// maxImportRowErrors - the report keeps the first errors only, Failed still counts all of them
+	maxImportRowErrors = 1000
+	maxJSONLLineSize   = 1 << 20
+
+	catalogTagSeparator = "|"
+
+	// importInterrupted - the error of jobs that were still running when the server stopped
+	importInterrupted = "import was interrupted by a server restart"
+)
+
+// catalogColumns - CSV columns in export order; imports match them by header name
+var catalogColumns = []string{"external_sku", "name", "description", "category", "price", "stock", "image_url", "tags", "attributes"}
+
+var (
+	ErrInvalidCatalogFormat = errors.New("invalid format, use csv or jsonl")
+	ErrInvalidImportFile    = errors.New("invalid import file")
+	ErrEmptyImport          = errors.New("import file has no rows")
+	ErrImportJobNotFound    = errors.New("import job not found")
+)
+
+// importRow - a parsed row, err is set when the row couldn't be parsed or validated.
+// variants is set when the format carries the product's options and variants
+type importRow struct {
+	row      int
+	product  *entity.Product
+	variants bool
+	err      error
+}
+
+type CatalogUseCase struct {
+	productRepo ProductRepository
+	productUC   *ProductUseCase
+	jobRepo     ImportJobRepository
+	l           *zap.SugaredLogger
+}
+
+func NewCatalogUseCase(productRepo ProductRepository, productUC *ProductUseCase, jobRepo ImportJobRepository, l *zap.SugaredLogger) *CatalogUseCase {
+	return &CatalogUseCase{
+		productRepo: productRepo,
+		productUC:   productUC,
+		jobRepo:     jobRepo,
+		l:           l,
+	}
+}
+
+// ParseCatalogFormat validates a format name
+func ParseCatalogFormat(format string) (entity.CatalogFormat, error) {
+	switch f := entity.CatalogFormat(strings.ToLower(strings.TrimSpace(format))); f {
+	case entity.CatalogCSV, entity.CatalogJSONL:
+		return f, nil
+	default:
+		return "", ErrInvalidCatalogFormat
+	}
+}
+
+// StartImport parses the file, saves a pending job and upserts the rows in the background on behalf of userID.
+// The returned job is a snapshot, poll GetImportJob for progress
+func (uc *CatalogUseCase) StartImport(ctx context.Context, userID bson.ObjectID, format entity.CatalogFormat, data []byte) (*entity.ImportJob, error) {
+	var (
+		rows []importRow
+		err  error
+	)
+	switch format {
+	case entity.CatalogCSV:
+		rows, err = parseCSVRows(data)
+	case entity.CatalogJSONL:
+		rows, err = parseJSONLRows(data)
+	default:
+		return nil, ErrInvalidCatalogFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	job := &entity.ImportJob{
+		UserID:    userID,
+		Format:    format,
+		Status:    entity.ImportPending,
+		TotalRows: len(rows),
+	}
+	if err := uc.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	snapshot := *job
+	// The import outlives the request that started it
+	go uc.runImport(context.WithoutCancel(ctx), job, rows)
+
+	return &snapshot, nil
+}
+
+func (uc *CatalogUseCase) GetImportJob(ctx context.Context, id bson.ObjectID) (*entity.ImportJob, error) {
+	job, err := uc.jobRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrImportJobNotFound
+	}
+	return job, err
+}
+
+// FailInterruptedImports fails the jobs a previous run of the server left pending or running,
+// their goroutines are gone and nothing would ever finish them. Call it on startup only
+func (uc *CatalogUseCase) FailInterruptedImports(ctx context.Context) (int64, error) {
+	return uc.jobRepo.FailUnfinished(ctx, importInterrupted)
+}
+
+// runImport upserts rows one by one. Invalid rows are reported and skipped,
+// any other error (e.g. the database going away) fails the whole job
+func (uc *CatalogUseCase) runImport(ctx context.Context, job *entity.ImportJob, rows []importRow) {
+	// A panic would take the server down and leave the job running forever
+	defer func() {
+		if r := recover(); r != nil {
+			uc.l.Errorw("Catalog import panicked", "job_id", job.ID.Hex(), "panic", r)
+			job.Status = entity.ImportFailed
+			job.Error = fmt.Sprintf("import stopped unexpectedly: %v", r)
+			finished := time.Now()
+			job.FinishedAt = &finished
+			_ = uc.jobRepo.Update(ctx, job)
+		}
+	}()
+
+	started := time.Now()
+	job.Status = entity.ImportRunning
+	job.StartedAt = &started
+	_ = uc.jobRepo.Update(ctx, job)
+
+	job.Status = entity.ImportCompleted
+	for _, row := range rows {
+		err := row.err
+		created := false
+		if err == nil {
+			created, err = uc.productUC.UpsertByExternalSKU(ctx, job.UserID, row.product, row.variants)
+		}
+
+		switch {
+		case err == nil && created:
+			job.Created++
+		case err == nil:
+			job.Updated++
+		case row.err != nil || errors.Is(err, ErrInvalidVariants) || errors.Is(err, ErrUnknownCategory) ||
+			errors.Is(err, ErrInvalidAttributes) || errors.Is(err, ErrVariantsNotImported) ||
+			mongo.IsDuplicateKeyError(err):
+			job.Failed++
+			if len(job.Errors) < maxImportRowErrors {
+				rowErr := entity.ImportRowError{Row: row.row, Error: err.Error()}
+				if row.product != nil {
+					rowErr.ExternalSKU = row.product.ExternalSKU
+				}
+				job.Errors = append(job.Errors, rowErr)
+			}
+		default:
+			job.Status = entity.ImportFailed
+			job.Error = fmt.Sprintf("row %d: %s", row.row, err.Error())
+		}
+		if job.Status == entity.ImportFailed {
+			break
+		}
+
+		job.Processed++
+		if job.Processed%importProgressEvery == 0 {
+			_ = uc.jobRepo.Update(ctx, job)
+		}
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	_ = uc.jobRepo.Update(ctx, job)
+}
+
+// Export streams the catalog to w, both formats can be imported back as is. Imports match products
+// by external SKU, so products created through the API without one are left out. JSONL lines are
+// full product documents, CSV has no variant columns and leaves out products with variants
+func (uc *CatalogUseCase) Export(ctx context.Context, format entity.CatalogFormat, w io.Writer) error {
+	switch format {
+	case entity.CatalogCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(catalogColumns); err != nil {
+			return err
+		}
+		err := uc.productRepo.ForEach(ctx, func(p *entity.Product) error {
+			if p.ExternalSKU == "" || len(p.Variants) > 0 {
+				return nil
+			}
+			attributes := ""
+			if len(p.Attributes) > 0 {
+				data, err := json.Marshal(p.Attributes)
+				if err != nil {
+					return err
+				}
+				attributes = string(data)
+			}
+			return cw.Write([]string{
+				p.ExternalSKU,
+				p.Name,
+				p.Description,
+				p.Category,
+				p.Price.String(),
+				strconv.Itoa(p.Stock),
+				p.ImageURL,
+				strings.Join(p.Tags, catalogTagSeparator),
+				attributes,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	case entity.CatalogJSONL:
+		enc := json.NewEncoder(w)
+		return uc.productRepo.ForEach(ctx, func(p *entity.Product) error {
+			if p.ExternalSKU == "" {
+				return nil
+			}
+			return enc.Encode(p)
+		})
+	default:
+		return ErrInvalidCatalogFormat
+	}
+}
+
+// parseCSVRows reads a CSV with a header row. external_sku, name, category and price columns are required,
+// tags are separated by "|" and attributes are a JSON object. Variants can only be imported through JSONL,
+// rows over products that have variants fail
+func parseCSVRows(data []byte) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmptyImport
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImportFile, err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"external_sku", "name", "category", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %q column", ErrInvalidImportFile, required)
+		}
+	}
+
+	var rows []importRow
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rows = append(rows, importRow{row: n, err: err})
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		product := &entity.Product{
+			ExternalSKU: field("external_sku"),
+			Name:        field("name"),
+			Description: field("description"),
+			Category:    field("category"),
+			ImageURL:    field("image_url"),
+			Tags:        []string{},
+		}
+		row := importRow{row: n, product: product}
+
+		if product.Price, err = money.ParseAmount(field("price")); err != nil {
+			row.err = fmt.Errorf("invalid price %q", field("price"))
+		} else if stock := field("stock"); stock != "" {
+			if product.Stock, err = strconv.Atoi(stock); err != nil {
+				row.err = fmt.Errorf("invalid stock %q", stock)
+			}
+		}
+		for _, tag := range strings.Split(field("tags"), catalogTagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				product.Tags = append(product.Tags, tag)
+			}
+		}
+		if attributes := field("attributes"); attributes != "" && row.err == nil {
+			if err := json.Unmarshal([]byte(attributes), &product.Attributes); err != nil {
+				row.err = fmt.Errorf("invalid attributes, expected a JSON object: %s", err.Error())
+			}
+		}
+
+		if row.err == nil {
+			row.err = validateImportProduct(product)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseJSONLRows reads one product object per line in the API's JSON shape, blank lines are skipped
+func parseJSONLRows(data []byte) ([]importRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineSize)
+
+	var rows []importRow
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		product := &entity.Product{}
+		row := importRow{row: n, product: product, variants: true}
+		if err := json.Unmarshal(line, product); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %s", err.Error())
+		} else {
+			product.ExternalSKU = strings.TrimSpace(product.ExternalSKU)
+			if product.Tags == nil {
+				product.Tags = []string{}
+			}
+			row.err = validateImportProduct(product)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImportFile, err.Error())
+	}
+	return rows, nil
+}
+
+func validateImportProduct(product *entity.Product) error {
+	switch {
+	case product.ExternalSKU == "":
+		return errors.New("externalSku is required")
+	case strings.TrimSpace(product.Name) == "":
+		return errors.New("name is required")
+	case strings.TrimSpace(product.Category) == "":
+		return errors.New("category is required")
+	case product.Price < 0:
+		return errors.New("price must not be negative")
+	case product.Stock < 0:
+		return errors.New("stock must not be negative")
+	}
+	return nil
+}
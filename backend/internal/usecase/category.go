@@ -0,0 +1,387 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	ErrCategoryExists         = errors.New("category name or slug already exists")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryHasChildren    = errors.New("category has subcategories, move or delete them first")
+	ErrCategoryInUse          = errors.New("category still has products")
+	ErrInvalidCategory        = errors.New("category name is required")
+	ErrUnknownCategory        = errors.New("unknown category")
+)
+
+type CategoryUseCase struct {
+	repo        CategoryRepository
+	productRepo ProductRepository
+	productUC   *ProductUseCase
+}
+
+func NewCategoryUseCase(repo CategoryRepository, productRepo ProductRepository, productUC *ProductUseCase) *CategoryUseCase {
+	return &CategoryUseCase{
+		repo:        repo,
+		productRepo: productRepo,
+		productUC:   productUC,
+	}
+}
+
+// GetTree returns the root categories with their subtrees, children in display order
+func (uc *CategoryUseCase) GetTree(ctx context.Context) ([]*entity.CategoryNode, error) {
+	categories, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+// GetBySlug returns the category with its subtree
+func (uc *CategoryUseCase) GetBySlug(ctx context.Context, slug string) (*entity.CategoryNode, error) {
+	category, err := uc.repo.GetBySlug(ctx, slug)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	descendants, err := uc.repo.GetDescendants(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.CategoryNode{
+		Category: category,
+		Children: buildCategoryTree(descendants, &category.ID),
+	}, nil
+}
+
+// Browse searches products of the category and all its subcategories
+func (uc *CategoryUseCase) Browse(ctx context.Context, slug string, params entity.ProductSearchParams) (*entity.Category, *entity.ProductSearchResult, error) {
+	category, err := uc.repo.GetBySlug(ctx, slug)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Search expands the category to its descendants
+	params.Categories = []string{category.Name}
+	result, err := uc.productUC.Search(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return category, result, nil
+}
+
+// Create adds a category under ParentID (or at the root); the slug defaults to the slugified name
+func (uc *CategoryUseCase) Create(ctx context.Context, category *entity.Category) error {
+	if err := prepareCategory(category); err != nil {
+		return err
+	}
+
+	category.Ancestors = []bson.ObjectID{}
+	if category.ParentID != nil {
+		parent, err := uc.getParent(ctx, *category.ParentID)
+		if err != nil {
+			return err
+		}
+		category.Ancestors = append(slices.Clone(parent.Ancestors), parent.ID)
+	}
+
+	if err := uc.repo.Create(ctx, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategoryExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Update renames, re-slugs, reorders or re-parents a category. Moving a category moves
+// its whole subtree, renaming it renames the category of its products
+func (uc *CategoryUseCase) Update(ctx context.Context, update *entity.Category) (*entity.Category, error) {
+	category, err := uc.get(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareCategory(update); err != nil {
+		return nil, err
+	}
+
+	ancestors := []bson.ObjectID{}
+	if update.ParentID != nil {
+		if *update.ParentID == category.ID {
+			return nil, ErrCategoryCycle
+		}
+		parent, err := uc.getParent(ctx, *update.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(parent.Ancestors, category.ID) {
+			return nil, ErrCategoryCycle
+		}
+		ancestors = append(slices.Clone(parent.Ancestors), parent.ID)
+	}
+
+	oldName := category.Name
+	moved := !slices.Equal(category.Ancestors, ancestors)
+
+	var descendants []*entity.Category
+	if moved {
+		if descendants, err = uc.repo.GetDescendants(ctx, category.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	category.Name = update.Name
+	category.Slug = update.Slug
+	category.Order = update.Order
+	category.ParentID = update.ParentID
+	category.Ancestors = ancestors
+	if err := uc.repo.Update(ctx, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrCategoryExists
+		}
+		return nil, err
+	}
+
+	// Rewrite the path above the moved category for every node of its subtree
+	for _, d := range descendants {
+		i := slices.Index(d.Ancestors, category.ID)
+		d.Ancestors = append(slices.Clone(ancestors), d.Ancestors[i:]...)
+		if err := uc.repo.Update(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+
+	if oldName != category.Name {
+		if err := uc.productUC.RenameCategory(ctx, oldName, category.Name); err != nil {
+			return nil, err
+		}
+	}
+	return category, nil
+}
+
+// Delete removes an empty leaf category
+func (uc *CategoryUseCase) Delete(ctx context.Context, id bson.ObjectID) error {
+	category, err := uc.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	descendants, err := uc.repo.GetDescendants(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(descendants) > 0 {
+		return ErrCategoryHasChildren
+	}
+
+	count, err := uc.productRepo.CountByCategory(ctx, category.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+
+	return uc.repo.Delete(ctx, id)
+}
+
+// Attributes returns the attribute schema products of the category carry, inherited attributes included
+func (uc *CategoryUseCase) Attributes(ctx context.Context, slug string) ([]entity.AttributeDef, error) {
+	category, err := uc.repo.GetBySlug(ctx, slug)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return attributeSchema(ctx, uc.repo, category)
+}
+
+// SetAttributes replaces the category's own attributes. Products are checked against the new schema
+// when they are next saved
+func (uc *CategoryUseCase) SetAttributes(ctx context.Context, id bson.ObjectID, attributes []entity.AttributeDef) (*entity.Category, error) {
+	category, err := uc.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareAttributeDefs(attributes); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.SetAttributes(ctx, id, attributes); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	category.Attributes = attributes
+	return category, nil
+}
+
+// AddSale schedules a percentage sale on every product of the category and its subcategories
+func (uc *CategoryUseCase) AddSale(ctx context.Context, id bson.ObjectID, sale *entity.SaleWindow) (*entity.Category, error) {
+	category, err := uc.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Products of a category have different prices, a fixed one doesn't fit them all
+	if sale.Price != nil {
+		return nil, fmt.Errorf("%w: category sales take percentOff only", ErrInvalidSale)
+	}
+	sales, err := addSaleWindow(category.Sales, sale)
+	if err != nil {
+		return nil, err
+	}
+	return uc.setSales(ctx, category, sales)
+}
+
+func (uc *CategoryUseCase) DeleteSale(ctx context.Context, id, saleID bson.ObjectID) (*entity.Category, error) {
+	category, err := uc.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := deleteSaleWindow(category.Sales, saleID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.setSales(ctx, category, sales)
+}
+
+// setSales needs no cache invalidation, category sales are applied to products on read. The current
+// prices search uses are refreshed right away instead of on the next scheduled run
+func (uc *CategoryUseCase) setSales(ctx context.Context, category *entity.Category, sales []entity.SaleWindow) (*entity.Category, error) {
+	if err := uc.repo.SetSales(ctx, category.ID, sales); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	category.Sales = sales
+	if _, err := uc.productUC.RefreshPrices(ctx); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// SyncFromProducts creates root categories for product categories missing from the tree,
+// used once to adopt the free-text categories products had before the taxonomy existed
+func (uc *CategoryUseCase) SyncFromProducts(ctx context.Context) ([]*entity.Category, error) {
+	names, err := uc.productRepo.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created := []*entity.Category{}
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		if _, err := uc.repo.GetByName(ctx, name); err == nil {
+			continue
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+
+		category := &entity.Category{Name: name}
+		if err := uc.Create(ctx, category); err != nil {
+			return nil, err
+		}
+		created = append(created, category)
+	}
+	return created, nil
+}
+
+func (uc *CategoryUseCase) get(ctx context.Context, id bson.ObjectID) (*entity.Category, error) {
+	category, err := uc.repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+func (uc *CategoryUseCase) getParent(ctx context.Context, id bson.ObjectID) (*entity.Category, error) {
+	parent, err := uc.repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrParentCategoryNotFound
+	}
+	return parent, err
+}
+
+// buildCategoryTree links categories (already in display order) to their parents, starting below root
+func buildCategoryTree(categories []*entity.Category, root *bson.ObjectID) []*entity.CategoryNode {
+	children := make(map[bson.ObjectID][]*entity.CategoryNode)
+	var roots []*entity.CategoryNode
+
+	nodes := make([]*entity.CategoryNode, len(categories))
+	for i, c := range categories {
+		nodes[i] = &entity.CategoryNode{Category: c, Children: []*entity.CategoryNode{}}
+	}
+	for _, n := range nodes {
+		if n.ParentID == nil || (root != nil && *n.ParentID == *root) {
+			roots = append(roots, n)
+			continue
+		}
+		children[*n.ParentID] = append(children[*n.ParentID], n)
+	}
+	for _, n := range nodes {
+		if c, ok := children[n.ID]; ok {
+			n.Children = c
+		}
+	}
+
+	if roots == nil {
+		roots = []*entity.CategoryNode{}
+	}
+	return roots
+}
+
+func prepareCategory(category *entity.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if category.Name == "" {
+		return ErrInvalidCategory
+	}
+
+	category.Slug = slugify(category.Slug)
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+	}
+	if category.Slug == "" {
+		return ErrInvalidCategory
+	}
+	return nil
+}
+
+// slugify lowercases letters and digits and joins the words with "-": "Home & Garden" -> "home-garden"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrUnsupportedCurrency    = errors.New("unsupported currency")
+	ErrInvalidExchangeRate    = errors.New("invalid exchange rate")
+	ErrExchangeRateNotFound   = errors.New("exchange rate not found")
+	ErrRatesFileNotConfigured = errors.New("no exchange rates file is configured")
+)
+
+// CurrencyUseCase keeps the exchange rates prices are converted with. Rates are stored in the repository
+// and served from memory; Refresh reloads them so every instance picks up changes made on another
+type CurrencyUseCase struct {
+	repo      ExchangeRateRepository
+	base      money.Currency
+	ratesFile string
+	rates     atomic.Pointer[map[string]*big.Rat]
+}
+
+func NewCurrencyUseCase(repo ExchangeRateRepository, base money.Currency, ratesFile string) *CurrencyUseCase {
+	uc := &CurrencyUseCase{
+		repo:      repo,
+		base:      base,
+		ratesFile: ratesFile,
+	}
+	uc.rates.Store(&map[string]*big.Rat{})
+	return uc
+}
+
+func (uc *CurrencyUseCase) Base() money.Currency {
+	return uc.base
+}
+
+// Quote resolves the display currency of a request. An empty code or the base currency
+// quote at rate 1, other currencies need an exchange rate
+func (uc *CurrencyUseCase) Quote(code string) (money.Quote, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" || code == uc.base.Code {
+		return money.Quote{Currency: uc.base, Rate: big.NewRat(1, 1)}, nil
+	}
+
+	currency, ok := money.LookupCurrency(code)
+	if !ok {
+		return money.Quote{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	rate, ok := (*uc.rates.Load())[code]
+	if !ok {
+		return money.Quote{}, fmt.Errorf("%w: no exchange rate for %s", ErrUnsupportedCurrency, code)
+	}
+	return money.Quote{Currency: currency, Rate: rate}, nil
+}
+
+// Refresh reloads the rates from the repository, returns how many there are
+func (uc *CurrencyUseCase) Refresh(ctx context.Context) (int, error) {
+	stored, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rates := make(map[string]*big.Rat, len(stored))
+	for _, r := range stored {
+		// A rate written around the use case is skipped rather than failing every conversion
+		rate, err := money.ParseRate(r.Rate)
+		if err != nil {
+			continue
+		}
+		rates[r.Currency] = rate
+	}
+	uc.rates.Store(&rates)
+	return len(rates), nil
+}
+
+// ListRates returns the base currency and the stored rates
+func (uc *CurrencyUseCase) ListRates(ctx context.Context) (*entity.CurrencyRates, error) {
+	rates, err := uc.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.CurrencyRates{Base: uc.base.Code, Rates: rates}, nil
+}
+
+// SetRates stores rates keyed by currency code, e.g. {"EUR": "0.92"}. Currencies not given keep their rates.
+// Nothing is stored unless every rate is valid
+func (uc *CurrencyUseCase) SetRates(ctx context.Context, rates map[string]string) (*entity.CurrencyRates, error) {
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rates given", ErrInvalidExchangeRate)
+	}
+
+	codes := make([]string, 0, len(rates))
+	for code := range rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	updates := make([]*entity.ExchangeRate, 0, len(rates))
+	for _, code := range codes {
+		currency, ok := money.LookupCurrency(code)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+		}
+		if currency.Code == uc.base.Code {
+			return nil, fmt.Errorf("%w: %s is the base currency", ErrInvalidExchangeRate, currency.Code)
+		}
+		rate, err := money.ParseRate(rates[code])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %q", ErrInvalidExchangeRate, currency.Code, rates[code])
+		}
+		updates = append(updates, &entity.ExchangeRate{
+			Currency: currency.Code,
+			Rate:     money.Quote{Currency: currency, Rate: rate}.RateString(),
+		})
+	}
+
+	if err := uc.repo.Upsert(ctx, updates); err != nil {
+		return nil, err
+	}
+	if _, err := uc.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return uc.ListRates(ctx)
+}
+
+func (uc *CurrencyUseCase) DeleteRate(ctx context.Context, code string) error {
+	err := uc.repo.Delete(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrExchangeRateNotFound
+	}
+	if err != nil {
+		return err
+	}
+	_, err = uc.Refresh(ctx)
+	return err
+}
+
+// LoadFile sets the rates from the configured JSON file, an object of currency codes to rates
+// given as numbers or strings
+func (uc *CurrencyUseCase) LoadFile(ctx context.Context) (*entity.CurrencyRates, error) {
+	if uc.ratesFile == "" {
+		return nil, ErrRatesFileNotConfigured
+	}
+
+	data, err := os.ReadFile(uc.ratesFile)
+	if err != nil {
+		return nil, err
+	}
+	var file map[string]json.Number
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidExchangeRate, uc.ratesFile, err)
+	}
+
+	rates := make(map[string]string, len(file))
+	for code, rate := range file {
+		rates[code] = rate.String()
+	}
+	return uc.SetRates(ctx, rates)
+}
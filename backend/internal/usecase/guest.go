@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidClaimToken = errors.New("invalid or expired guest claim token")
+	ErrGuestNotFound     = errors.New("guest not found")
+	// ErrGuestClaimed - the guest's activity belongs to a user now, the user has to log in
+	ErrGuestClaimed = errors.New("guest session was claimed by an account, log in to continue")
+)
+
+// guestClaimPurpose marks claim tokens, which carry no user_id or guest_id and can't authenticate requests
+const guestClaimPurpose = "guest_claim"
+
+type GuestUseCase struct {
+	repo            GuestRepository
+	interactionRepo InteractionRepository
+	graphRepo       GraphRepository
+	cacheRepo       CacheRepository
+	notifier        Notifier
+	jwtSecret       string
+	jwtExpiry       time.Duration
+	claimExpiry     time.Duration
+}
+
+func NewGuestUseCase(
+	repo GuestRepository,
+	interactionRepo InteractionRepository,
+	graphRepo GraphRepository,
+	cacheRepo CacheRepository,
+	notifier Notifier,
+	jwtSecret string,
+	jwtExpiry time.Duration,
+	claimExpiry time.Duration,
+) *GuestUseCase {
+	return &GuestUseCase{
+		repo:            repo,
+		interactionRepo: interactionRepo,
+		graphRepo:       graphRepo,
+		cacheRepo:       cacheRepo,
+		notifier:        notifier,
+		jwtSecret:       jwtSecret,
+		jwtExpiry:       jwtExpiry,
+		claimExpiry:     claimExpiry,
+	}
+}
+
+// StartSession creates a guest keyed by email and returns a guest token for it
+func (uc *GuestUseCase) StartSession(ctx context.Context, email string) (*entity.Guest, string, error) {
+	guest := &entity.Guest{Email: normalizeEmail(email)}
+	if err := uc.repo.Create(ctx, guest); err != nil {
+		return nil, "", err
+	}
+
+	token, err := uc.generateToken(guest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return guest, token, nil
+}
+
+// CheckSession accepts the guest of a guest token while it is unclaimed. Activity recorded with the
+// token of a claimed guest would stay with the guest and never reach the user
+func (uc *GuestUseCase) CheckSession(ctx context.Context, guestID bson.ObjectID) error {
+	guest, err := uc.repo.GetByID(ctx, guestID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrGuestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !guest.ClaimedBy.IsZero() {
+		return ErrGuestClaimed
+	}
+	return nil
+}
+
+// GetPurchaseHistory gets purchases made with a guest token
+func (uc *GuestUseCase) GetPurchaseHistory(ctx context.Context, guestID bson.ObjectID, page entity.PageRequest) ([]*entity.Purchase, entity.PageInfo, error) {
+	return uc.interactionRepo.GetGuestPurchaseHistory(ctx, guestID, page)
+}
+
+// RequestClaim emails the user a claim token for the unclaimed guests with the account's email.
+// Guest emails are never verified, so the activity is only merged once the token proves the user
+// reads that mailbox. Returns the number of guests the token covers, no email is sent for none
+func (uc *GuestUseCase) RequestClaim(ctx context.Context, user *entity.User) (int, error) {
+	guests, err := uc.repo.GetUnclaimedByEmail(ctx, normalizeEmail(user.Email))
+	if err != nil || len(guests) == 0 {
+		return 0, err
+	}
+
+	token, err := uc.generateClaimToken(user, guests)
+	if err != nil {
+		return 0, err
+	}
+	if err := uc.notifier.SendGuestClaim(ctx, &entity.GuestClaimMessage{User: user, Guests: guests, Token: token}); err != nil {
+		return 0, err
+	}
+	return len(guests), nil
+}
+
+// Claim redeems a claim token sent by RequestClaim: orders, interactions and graph edges of the
+// guests it covers move to the user. Guests claimed since, or started later, are left alone.
+// Returns the number of guests claimed
+func (uc *GuestUseCase) Claim(ctx context.Context, user *entity.User, token string) (int, error) {
+	guestIDs, err := uc.parseClaimToken(user, token)
+	if err != nil {
+		return 0, err
+	}
+	guests, err := uc.repo.GetUnclaimedByEmail(ctx, normalizeEmail(user.Email))
+	if err != nil {
+		return 0, err
+	}
+
+	claimed := 0
+	for _, guest := range guests {
+		if !slices.Contains(guestIDs, guest.ID.Hex()) {
+			continue
+		}
+		if err := uc.interactionRepo.AssignGuestActivity(ctx, guest.ID, user.ID); err != nil {
+			return claimed, err
+		}
+		if err := uc.graphRepo.MergeUsers(ctx, guest.ID, user.ID); err != nil {
+			return claimed, err
+		}
+		if err := uc.repo.MarkClaimed(ctx, guest.ID, user.ID); err != nil {
+			return claimed, err
+		}
+		claimed++
+	}
+
+	if claimed > 0 {
+		_ = uc.cacheRepo.Delete(ctx, userRecCacheKey(user.ID))
+	}
+
+	return claimed, nil
+}
+
+func (uc *GuestUseCase) generateToken(guest *entity.Guest) (string, error) {
+	claims := jwt.MapClaims{
+		"guest_id":    guest.ID.Hex(),
+		"guest_email": guest.Email,
+		"exp":         time.Now().Add(uc.jwtExpiry).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(uc.jwtSecret))
+}
+
+// generateClaimToken signs the guests to claim for the user and email of the account
+func (uc *GuestUseCase) generateClaimToken(user *entity.User, guests []*entity.Guest) (string, error) {
+	guestIDs := make([]string, len(guests))
+	for i, g := range guests {
+		guestIDs[i] = g.ID.Hex()
+	}
+	claims := jwt.MapClaims{
+		"purpose":     guestClaimPurpose,
+		"claim_user":  user.ID.Hex(),
+		"claim_email": normalizeEmail(user.Email),
+		"guest_ids":   guestIDs,
+		"exp":         time.Now().Add(uc.claimExpiry).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(uc.jwtSecret))
+}
+
+// parseClaimToken returns the guest IDs of a claim token issued to the user at its current email
+func (uc *GuestUseCase) parseClaimToken(user *entity.User, tokenString string) ([]string, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(uc.jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidClaimToken
+	}
+
+	purpose, _ := claims["purpose"].(string)
+	claimUser, _ := claims["claim_user"].(string)
+	claimEmail, _ := claims["claim_email"].(string)
+	if purpose != guestClaimPurpose || claimUser != user.ID.Hex() || claimEmail != normalizeEmail(user.Email) {
+		return nil, ErrInvalidClaimToken
+	}
+
+	raw, _ := claims["guest_ids"].([]interface{})
+	guestIDs := make([]string, 0, len(raw))
+	for _, id := range raw {
+		if s, ok := id.(string); ok {
+			guestIDs = append(guestIDs, s)
+		}
+	}
+	return guestIDs, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -2,65 +2,284 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"slices"
 
 	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
 )
 
 type InteractionUseCase struct {
-	repo      InteractionRepository
-	graphRepo GraphRepository
+	repo        InteractionRepository
+	graphRepo   GraphRepository
+	productRepo ProductRepository
+	products    ProductLookup
+	l           *zap.SugaredLogger
 }
 
-func NewInteractionUseCase(repo InteractionRepository, graphRepo GraphRepository) *InteractionUseCase {
+func NewInteractionUseCase(
+	repo InteractionRepository,
+	graphRepo GraphRepository,
+	productRepo ProductRepository,
+	products ProductLookup,
+	l *zap.SugaredLogger,
+) *InteractionUseCase {
 	return &InteractionUseCase{
-		repo:      repo,
-		graphRepo: graphRepo,
+		repo:        repo,
+		graphRepo:   graphRepo,
+		productRepo: productRepo,
+		products:    products,
+		l:           l,
 	}
 }
 
-func (uc *InteractionUseCase) RecordInteraction(ctx context.Context, userID, productID bson.ObjectID, interactionType entity.InteractionType) error {
-	weight := getInteractionWeight(interactionType)
+// RecordInteraction saves the interaction; sku selects a variant and is required for cart and purchase
+// of products with variants. The graph stays at the parent product level
+func (uc *InteractionUseCase) RecordInteraction(ctx context.Context, userID, productID bson.ObjectID, sku string, interactionType entity.InteractionType) error {
+	if err := uc.checkVariant(ctx, productID, sku, interactionType); err != nil {
+		return err
+	}
 
-	interaction := &entity.Interaction{
+	return uc.save(ctx, &entity.Interaction{
 		UserID:    userID,
 		ProductID: productID,
+		SKU:       sku,
 		Type:      interactionType,
-		Weight:    weight,
+		Weight:    getInteractionWeight(interactionType),
+	})
+}
+
+// RecordReview records a review as an interaction weighted by its rating,
+// so a one star review adds little to the user's affinity for the product
+func (uc *InteractionUseCase) RecordReview(ctx context.Context, userID, productID bson.ObjectID, rating int) error {
+	return uc.save(ctx, &entity.Interaction{
+		UserID:    userID,
+		ProductID: productID,
+		Type:      entity.InteractionReview,
+		Weight:    getInteractionWeight(entity.InteractionReview) * float64(rating) / maxReviewRating,
+	})
+}
+
+// RecordGuestInteraction records an interaction without a user; the graph node is keyed by the guest ID
+// so edges can be re-pointed when the guest is claimed
+func (uc *InteractionUseCase) RecordGuestInteraction(ctx context.Context, guestID, productID bson.ObjectID, sku string, interactionType entity.InteractionType) error {
+	if err := uc.checkVariant(ctx, productID, sku, interactionType); err != nil {
+		return err
 	}
 
-	// Save to MongoDB
+	return uc.save(ctx, &entity.Interaction{
+		GuestID:   guestID,
+		ProductID: productID,
+		SKU:       sku,
+		Type:      interactionType,
+		Weight:    getInteractionWeight(interactionType),
+	})
+}
+
+// RecordSearchClick records a click on the result at position of a logged search, by the user or,
+// when userID is nil, by the guest
+func (uc *InteractionUseCase) RecordSearchClick(
+	ctx context.Context,
+	userID, guestID, productID bson.ObjectID,
+	search *entity.SearchLog,
+	position int,
+) error {
+	weight := getInteractionWeight(entity.InteractionSearchClick)
+	return uc.save(ctx, &entity.Interaction{
+		UserID:    userID,
+		GuestID:   guestID,
+		ProductID: productID,
+		Type:      entity.InteractionSearchClick,
+		Weight:    weight,
+		SearchID:  search.ID,
+		Query:     search.Query,
+		Position:  position,
+	})
+}
+
+// save stores an interaction of the user or, when it has none, of the guest and adds it to the graph
+func (uc *InteractionUseCase) save(ctx context.Context, interaction *entity.Interaction) error {
 	if err := uc.repo.Create(ctx, interaction); err != nil {
 		return err
 	}
 
-	// Update graph in Neo4j
-	return uc.graphRepo.CreateUserProductRelation(ctx, userID, productID, string(interactionType), weight)
+	actorID := interaction.UserID
+	if actorID.IsZero() {
+		actorID = interaction.GuestID
+	}
+	return uc.graphRepo.CreateUserProductRelation(ctx, actorID, interaction.ProductID, string(interaction.Type), interaction.Weight)
 }
 
-// CreatePurchase records a purchase
+// RecordComparison records that the products were compared side by side, by the user or, when userID
+// is nil, by the guest: a compare interaction on each product and a COMPARED_WITH edge between
+// every pair, so products compared with each other are learned as substitutes
+func (uc *InteractionUseCase) RecordComparison(ctx context.Context, userID, guestID bson.ObjectID, productIDs []bson.ObjectID) error {
+	productIDs, err := comparisonIDs(productIDs)
+	if err != nil {
+		return err
+	}
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+	if len(products) < len(productIDs) {
+		return ErrProductNotFound
+	}
+
+	weight := getInteractionWeight(entity.InteractionCompare)
+	for _, id := range productIDs {
+		err := uc.save(ctx, &entity.Interaction{
+			UserID:    userID,
+			GuestID:   guestID,
+			ProductID: id,
+			Type:      entity.InteractionCompare,
+			Weight:    weight,
+			ComparedWith: slices.DeleteFunc(slices.Clone(productIDs), func(other bson.ObjectID) bool {
+				return other == id
+			}),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.graphRepo.RecordComparison(ctx, productIDs)
+}
+
+// PricePurchase prices the items at what their products and variants sell for now, sale prices
+// included, and sets the purchase total in the base currency. Prices sent by clients are never trusted
+func (uc *InteractionUseCase) PricePurchase(ctx context.Context, purchase *entity.Purchase) error {
+	var total money.Amount
+	for i := range purchase.Products {
+		item := &purchase.Products[i]
+		product, err := uc.products.GetByID(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+
+		price, regular := product.EffectivePrice(), product.Price
+		switch {
+		case len(product.Variants) > 0 && item.SKU == "":
+			return ErrVariantRequired
+		case item.SKU != "":
+			variant, ok := product.Variant(item.SKU)
+			if !ok {
+				return ErrVariantNotFound
+			}
+			price, regular = variant.EffectivePrice(), variant.Price
+		}
+
+		item.Price = price
+		item.RegularPrice = 0
+		if price < regular {
+			item.RegularPrice = regular
+		}
+		total += price * money.Amount(item.Quantity)
+	}
+	purchase.Total = total
+	return nil
+}
+
+// CreatePurchase places a purchase priced by PricePurchase: the items are taken out of stock, the
+// purchase is stored, then a purchase interaction is recorded for every item. Nothing is recorded
+// when an item is out of stock
 func (uc *InteractionUseCase) CreatePurchase(ctx context.Context, purchase *entity.Purchase) error {
-	return uc.repo.CreatePurchase(ctx, purchase)
+	if err := uc.products.ReserveStock(ctx, purchase.Products); err != nil {
+		return err
+	}
+	if err := uc.repo.CreatePurchase(ctx, purchase); err != nil {
+		if releaseErr := uc.products.ReleaseStock(ctx, purchase.Products); releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	// The order stands from here on, a failed interaction only loses a recommendation signal
+	weight := getInteractionWeight(entity.InteractionPurchase)
+	for _, item := range purchase.Products {
+		interaction := &entity.Interaction{
+			UserID:    purchase.UserID,
+			GuestID:   purchase.GuestID,
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Type:      entity.InteractionPurchase,
+			Weight:    weight,
+		}
+		if err := uc.save(ctx, interaction); err != nil {
+			uc.l.Errorw("Failed to record purchase interaction",
+				"purchase_id", purchase.ID.Hex(),
+				"product_id", item.ProductID.Hex(),
+				"error", err,
+			)
+		}
+	}
+	return nil
 }
 
-// GetUserPurchaseHistory gets user's purchase history
+// GetUserPurchaseHistory gets user's purchase history, newest first
 func (uc *InteractionUseCase) GetUserPurchaseHistory(
 	ctx context.Context,
 	userID bson.ObjectID,
-) ([]*entity.Purchase, error) {
-	return uc.repo.GetUserPurchaseHistory(ctx, userID)
+	page entity.PageRequest,
+) ([]*entity.Purchase, entity.PageInfo, error) {
+	return uc.repo.GetUserPurchaseHistory(ctx, userID, page)
+}
+
+// GetUserInteractionHistory gets user's interactions, newest first
+func (uc *InteractionUseCase) GetUserInteractionHistory(
+	ctx context.Context,
+	userID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.Interaction, entity.PageInfo, error) {
+	return uc.repo.GetUserInteractionHistory(ctx, userID, page)
+}
+
+func (uc *InteractionUseCase) checkVariant(ctx context.Context, productID bson.ObjectID, sku string, t entity.InteractionType) error {
+	needsVariant := t == entity.InteractionCart || t == entity.InteractionPurchase
+	if sku == "" && !needsVariant {
+		return nil
+	}
+
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(product.Variants) == 0 {
+		if sku != "" {
+			return ErrVariantNotFound
+		}
+		return nil
+	}
+	if sku == "" {
+		return ErrVariantRequired
+	}
+	if _, ok := product.Variant(sku); !ok {
+		return ErrVariantNotFound
+	}
+	return nil
 }
 
 func getInteractionWeight(t entity.InteractionType) float64 {
 	switch t {
-	case entity.InteractionView:
+	case entity.InteractionView, entity.InteractionSearchClick:
 		return 1.0
+	case entity.InteractionCompare:
+		return 2.0
 	case entity.InteractionLike:
 		return 3.0
 	case entity.InteractionCart:
 		return 5.0
 	case entity.InteractionPurchase:
 		return 10.0
+	case entity.InteractionReview:
+		return 6.0
 	default:
 		return 1.0
 	}
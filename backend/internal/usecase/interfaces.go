@@ -2,8 +2,11 @@ package usecase
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -19,36 +22,224 @@ type UserRepository interface {
 type ProductRepository interface {
 	Create(ctx context.Context, product *entity.Product) error
 	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Product, error)
+	GetByIDs(ctx context.Context, ids []bson.ObjectID) ([]*entity.Product, error)
 	Update(ctx context.Context, product *entity.Product) error
 	Delete(ctx context.Context, id bson.ObjectID) error
-	List(ctx context.Context, limit, offset int) ([]*entity.Product, error)
-	Search(ctx context.Context, query string, category string, limit int) ([]*entity.Product, error)
+	List(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error)
+	UpsertByExternalSKU(ctx context.Context, product *entity.Product, variants bool) (*entity.Product, error)
+	UpdateRating(ctx context.Context, id bson.ObjectID, summary *entity.RatingSummary) error
+	RenameCategory(ctx context.Context, from, to string) ([]bson.ObjectID, error)
+	AddImage(ctx context.Context, id bson.ObjectID, image entity.ProductImage) (*entity.Product, error)
+	RemoveImage(ctx context.Context, id bson.ObjectID, imageID string) (*entity.Product, error)
+	ReorderImages(ctx context.Context, id bson.ObjectID, images []entity.ProductImage) error
+	SetSales(ctx context.Context, id bson.ObjectID, sales []entity.SaleWindow) error
+	SetCurrentPrices(ctx context.Context, product *entity.Product) error
+	SetTranslations(ctx context.Context, id bson.ObjectID, translations entity.Translations) error
+	AdjustStock(ctx context.Context, id bson.ObjectID, sku string, delta int) error
+	ListMissingTranslations(ctx context.Context, locales []string, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error)
+	Archive(ctx context.Context, id bson.ObjectID) error
+	Restore(ctx context.Context, id bson.ObjectID) error
+	ListArchived(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error)
+	FindArchivedBefore(ctx context.Context, before time.Time) ([]*entity.Product, error)
+	CountByCategory(ctx context.Context, category string) (int64, error)
+	GetCategories(ctx context.Context) ([]string, error)
+	ForEach(ctx context.Context, fn func(*entity.Product) error) error
 	GetByCategory(ctx context.Context, category string, limit int) ([]*entity.Product, error)
 	GetPopular(ctx context.Context, limit int) ([]*entity.Product, error)
+	FindOnSale(ctx context.Context, t time.Time, categories []string, limit int) ([]*entity.Product, error)
+	ForEachRepriceCandidate(ctx context.Context, t time.Time, categories []string, fn func(*entity.Product) error) error
+}
+
+// SearchIndex answers product searches. Indexes that keep their own copy of the catalog are fed
+// every product write by ProductUseCase; archived products are deleted from them
+type SearchIndex interface {
+	Index(ctx context.Context, product *entity.Product) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	// Replace swaps the whole index for the given products
+	Replace(ctx context.Context, products []*entity.Product) error
+	Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error)
+}
+
+type SynonymRepository interface {
+	Create(ctx context.Context, set *entity.SynonymSet) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.SynonymSet, error)
+	Update(ctx context.Context, set *entity.SynonymSet) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	List(ctx context.Context, page entity.PageRequest) ([]*entity.SynonymSet, entity.PageInfo, error)
+	FindByTerms(ctx context.Context, terms []string) ([]*entity.SynonymSet, error)
+}
+
+type SearchRuleRepository interface {
+	Create(ctx context.Context, rule *entity.SearchRule) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.SearchRule, error)
+	Update(ctx context.Context, rule *entity.SearchRule) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	List(ctx context.Context, page entity.PageRequest) ([]*entity.SearchRule, entity.PageInfo, error)
+	FindActive(ctx context.Context, query string, at time.Time) ([]*entity.SearchRule, error)
+}
+
+type ExchangeRateRepository interface {
+	GetAll(ctx context.Context) ([]*entity.ExchangeRate, error)
+	Upsert(ctx context.Context, rates []*entity.ExchangeRate) error
+	Delete(ctx context.Context, currency string) error
+}
+
+type PriceHistoryRepository interface {
+	Create(ctx context.Context, changes []*entity.PriceChange) error
+	GetByProduct(ctx context.Context, productID bson.ObjectID, sku string, since time.Time) ([]*entity.PriceChange, error)
+	GetLastBefore(ctx context.Context, productID bson.ObjectID, sku string, before time.Time) (*entity.PriceChange, error)
+	GetPending(ctx context.Context, limit int) ([]*entity.PriceChange, error)
+	MarkAlerted(ctx context.Context, id bson.ObjectID) error
+	DeleteByProduct(ctx context.Context, productID bson.ObjectID) error
+}
+
+type PriceWatchRepository interface {
+	Create(ctx context.Context, watch *entity.PriceWatch) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.PriceWatch, error)
+	Update(ctx context.Context, watch *entity.PriceWatch) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	ListByUser(ctx context.Context, userID bson.ObjectID, page entity.PageRequest) ([]*entity.PriceWatch, entity.PageInfo, error)
+	GetByUser(ctx context.Context, userID bson.ObjectID) ([]*entity.PriceWatch, error)
+	FindByProduct(ctx context.Context, productID bson.ObjectID, sku string) ([]*entity.PriceWatch, error)
+	SetReference(ctx context.Context, id bson.ObjectID, price money.Amount, notifiedAt *time.Time) error
+	DeleteByProduct(ctx context.Context, productID bson.ObjectID) error
+}
+
+type ProductVersionRepository interface {
+	Create(ctx context.Context, version *entity.ProductVersion) error
+	GetLatestNumber(ctx context.Context, productID bson.ObjectID) (int, error)
+	GetByNumber(ctx context.Context, productID bson.ObjectID, number int) (*entity.ProductVersion, error)
+	GetByProduct(ctx context.Context, productID bson.ObjectID, page entity.PageRequest) ([]*entity.ProductVersion, entity.PageInfo, error)
+	DeleteByProduct(ctx context.Context, productID bson.ObjectID) error
 }
 
 type InteractionRepository interface {
 	Create(ctx context.Context, interaction *entity.Interaction) error
 	GetUserInteractions(ctx context.Context, userID bson.ObjectID, limit int) ([]*entity.Interaction, error)
+	GetUserProductIDs(ctx context.Context, userID bson.ObjectID, interactionType entity.InteractionType) ([]bson.ObjectID, error)
 	GetProductInteractions(ctx context.Context, productID bson.ObjectID, limit int) ([]*entity.Interaction, error)
-	GetUserPurchaseHistory(ctx context.Context, userID bson.ObjectID) ([]*entity.Purchase, error)
+	GetUserInteractionHistory(ctx context.Context, userID bson.ObjectID, page entity.PageRequest) ([]*entity.Interaction, entity.PageInfo, error)
+	GetUserPurchaseHistory(ctx context.Context, userID bson.ObjectID, page entity.PageRequest) ([]*entity.Purchase, entity.PageInfo, error)
 	CreatePurchase(ctx context.Context, purchase *entity.Purchase) error
 	GetInteractionCounts(ctx context.Context, productID bson.ObjectID) (map[entity.InteractionType]int, error)
+	GetGuestPurchaseHistory(ctx context.Context, guestID bson.ObjectID, page entity.PageRequest) ([]*entity.Purchase, entity.PageInfo, error)
+	AssignGuestActivity(ctx context.Context, guestID, userID bson.ObjectID) error
+	FindAbandonedCarts(ctx context.Context, since time.Time, window time.Duration) ([]*entity.AbandonedCart, error)
+	FindPurchaseContaining(ctx context.Context, userID bson.ObjectID, productIDs []bson.ObjectID, after time.Time) (*entity.Purchase, error)
+	GetProductPopularity(ctx context.Context, since time.Time) (map[bson.ObjectID]float64, error)
+}
+
+type SearchLogRepository interface {
+	Create(ctx context.Context, log *entity.SearchLog) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.SearchLog, error)
+	AddClick(ctx context.Context, id bson.ObjectID, position int) (bool, error)
+	GetStats(ctx context.Context, since time.Time) (*entity.SearchStats, error)
+	TopQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error)
+	ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error)
+}
+
+type CartReminderRepository interface {
+	Create(ctx context.Context, reminder *entity.CartReminder) error
+	ExistsSince(ctx context.Context, userID bson.ObjectID, since time.Time) (bool, error)
+	GetUnrecovered(ctx context.Context, sentAfter time.Time) ([]*entity.CartReminder, error)
+	MarkRecovered(ctx context.Context, id bson.ObjectID, purchase *entity.Purchase) error
+	GetStats(ctx context.Context, since time.Time) (*entity.CartRecoveryStats, error)
+}
+
+type GuestRepository interface {
+	Create(ctx context.Context, guest *entity.Guest) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Guest, error)
+	GetUnclaimedByEmail(ctx context.Context, email string) ([]*entity.Guest, error)
+	MarkClaimed(ctx context.Context, id, userID bson.ObjectID) error
+}
+
+type WishlistRepository interface {
+	Create(ctx context.Context, wishlist *entity.Wishlist) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Wishlist, error)
+	GetByShareToken(ctx context.Context, token string) (*entity.Wishlist, error)
+	GetDefault(ctx context.Context, userID bson.ObjectID) (*entity.Wishlist, error)
+	GetUserWishlists(ctx context.Context, userID bson.ObjectID) ([]*entity.Wishlist, error)
+	Update(ctx context.Context, wishlist *entity.Wishlist) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	AddItem(ctx context.Context, id bson.ObjectID, item entity.WishlistItem) (bool, error)
+	RemoveItem(ctx context.Context, id, productID bson.ObjectID) (bool, error)
 }
 
 type CacheRepository interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string, ttl int) error
-	Delete(ctx context.Context, key string) error
+	Delete(ctx context.Context, keys ...string) error
 	IncrementCounter(ctx context.Context, key string) (int64, error)
 	GetTopN(ctx context.Context, key string, n int) ([]string, error)
 	AddToSortedSet(ctx context.Context, key string, score float64, member string) error
+	AddToSet(ctx context.Context, key string, ttl int, members ...string) error
+	GetSetMembers(ctx context.Context, key string) ([]string, error)
+}
+
+type SuggestRepository interface {
+	ReplaceCatalog(ctx context.Context, terms []entity.SuggestionTerm) error
+	IncrementQuery(ctx context.Context, query string) error
+	TrimQueries(ctx context.Context, keep int) error
+	FindByPrefix(ctx context.Context, prefix string, scan int) ([]entity.Suggestion, error)
+}
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *entity.Category) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*entity.Category, error)
+	GetByName(ctx context.Context, name string) (*entity.Category, error)
+	GetAll(ctx context.Context) ([]*entity.Category, error)
+	GetDescendants(ctx context.Context, id bson.ObjectID) ([]*entity.Category, error)
+	Update(ctx context.Context, category *entity.Category) error
+	SetAttributes(ctx context.Context, id bson.ObjectID, attributes []entity.AttributeDef) error
+	SetSales(ctx context.Context, id bson.ObjectID, sales []entity.SaleWindow) error
+	SetTranslations(ctx context.Context, id bson.ObjectID, translations entity.Translations) error
+	FindWithActiveSales(ctx context.Context, t time.Time) ([]*entity.Category, error)
+	Delete(ctx context.Context, id bson.ObjectID) error
+}
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *entity.Review) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Review, error)
+	Update(ctx context.Context, review *entity.Review) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	GetByProduct(ctx context.Context, productID bson.ObjectID, sort entity.ReviewSort, page entity.PageRequest) ([]*entity.Review, entity.PageInfo, error)
+	AddHelpfulVote(ctx context.Context, id, userID bson.ObjectID) (bool, error)
+	RemoveHelpfulVote(ctx context.Context, id, userID bson.ObjectID) (bool, error)
+	GetRatingSummary(ctx context.Context, productID bson.ObjectID) (*entity.RatingSummary, error)
+}
+
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *entity.ImportJob) error
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.ImportJob, error)
+	Update(ctx context.Context, job *entity.ImportJob) error
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+}
+
+// BlobStore stores uploaded files under slash separated keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// ProductLookup loads single products; ProductUseCase serves them through a read-through cache.
+// ApplySales prices products loaded elsewhere with the sales in effect, ReserveStock and
+// ReleaseStock take purchased items out of stock and put them back
+type ProductLookup interface {
+	GetByID(ctx context.Context, id bson.ObjectID) (*entity.Product, error)
+	ApplySales(ctx context.Context, products ...*entity.Product) error
+	ReserveStock(ctx context.Context, items []entity.PurchaseItem) error
+	ReleaseStock(ctx context.Context, items []entity.PurchaseItem) error
 }
 
 type GraphRepository interface {
 	//	User - Product
 	CreateUserProductRelation(ctx context.Context, userID, productID bson.ObjectID, relationType string, weight float64) error
 	GetUserProductRelations(ctx context.Context, userID bson.ObjectID) ([]entity.Interaction, error)
+	MergeUsers(ctx context.Context, fromID, toID bson.ObjectID) error
+	DeleteProduct(ctx context.Context, productID bson.ObjectID) error
 
 	// Collaborative filtering
 	FindSimilarUsers(ctx context.Context, userID bson.ObjectID, limit int) ([]entity.UserSimilarity, error)
@@ -57,12 +248,20 @@ type GraphRepository interface {
 	// Product relationships
 	GetFrequentlyBoughtTogether(ctx context.Context, productID bson.ObjectID, limit int) ([]bson.ObjectID, error)
 	GetSimilarProducts(ctx context.Context, productID bson.ObjectID, limit int) ([]bson.ObjectID, error)
+	// RecordComparison links every pair of the products with a COMPARED_WITH edge
+	RecordComparison(ctx context.Context, productIDs []bson.ObjectID) error
 
 	// Graph analytics
 	CalculateUserSimilarity(ctx context.Context, userID1, userID2 bson.ObjectID) (float64, error)
 	GetProductPopularityScore(ctx context.Context, productID bson.ObjectID) (float64, error)
 }
 
+type Notifier interface {
+	SendCartReminder(ctx context.Context, message *entity.CartReminderMessage) error
+	SendPriceAlert(ctx context.Context, message *entity.PriceAlertMessage) error
+	SendGuestClaim(ctx context.Context, message *entity.GuestClaimMessage) error
+}
+
 type RecommendationEngine interface {
 	GetPersonalizedRecommendations(ctx context.Context, userID bson.ObjectID, limit int) (*entity.Recommendation, error)
 	GetCollaborativeRecommendations(ctx context.Context, userID bson.ObjectID, limit int) (*entity.Recommendation, error)
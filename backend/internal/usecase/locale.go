@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+	ErrInvalidLocales    = errors.New("invalid locale configuration")
+)
+
+// LocaleUseCase picks the locale a request reads content in. Content is written in the default
+// locale and may be translated to the supported ones
+type LocaleUseCase struct {
+	defaultLocale language.Tag
+	supported     []language.Tag
+	// matcher - over the default locale first, then the supported ones
+	matcher language.Matcher
+}
+
+func NewLocaleUseCase(defaultLocale string, supported []string) (*LocaleUseCase, error) {
+	def, err := language.Parse(strings.TrimSpace(defaultLocale))
+	if err != nil {
+		return nil, fmt.Errorf("%w: default locale %q", ErrInvalidLocales, defaultLocale)
+	}
+
+	uc := &LocaleUseCase{defaultLocale: def}
+	for _, s := range supported {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		tag, err := language.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: locale %q", ErrInvalidLocales, s)
+		}
+		if tag != def && !slices.Contains(uc.supported, tag) {
+			uc.supported = append(uc.supported, tag)
+		}
+	}
+	uc.matcher = language.NewMatcher(append([]language.Tag{def}, uc.supported...))
+	return uc, nil
+}
+
+func (uc *LocaleUseCase) Locales() entity.Locales {
+	locales := entity.Locales{Default: uc.defaultLocale.String(), Supported: []string{}}
+	for _, tag := range uc.supported {
+		locales.Supported = append(locales.Supported, tag.String())
+	}
+	return locales
+}
+
+// Negotiate resolves the locale of a request from an explicit locale, which must be supported, or
+// else from an Accept-Language header, which falls back to the default locale. Returns the chain of
+// translations to read, most specific first: the locale, then its supported parents, e.g. pt-BR then
+// pt. The chain is empty for the default locale, whose text needs no translation
+func (uc *LocaleUseCase) Negotiate(explicit, acceptLanguage string) ([]string, error) {
+	var tag language.Tag
+	if explicit = strings.TrimSpace(explicit); explicit != "" {
+		t, err := uc.Supported(explicit)
+		if err != nil {
+			return nil, err
+		}
+		tag = t
+	} else {
+		// A malformed header still yields the tags that could be parsed
+		requested, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+		_, i, confidence := uc.matcher.Match(requested...)
+		if i == 0 || confidence == language.No {
+			return nil, nil
+		}
+		tag = uc.supported[i-1]
+	}
+	if tag == uc.defaultLocale {
+		return nil, nil
+	}
+
+	chain := []string{tag.String()}
+	for parent := tag.Parent(); parent != language.Und && parent != uc.defaultLocale; parent = parent.Parent() {
+		if slices.Contains(uc.supported, parent) {
+			chain = append(chain, parent.String())
+		}
+	}
+	return chain, nil
+}
+
+// Supported parses a locale and checks it is the default or a supported one
+func (uc *LocaleUseCase) Supported(locale string) (language.Tag, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil || (tag != uc.defaultLocale && !slices.Contains(uc.supported, tag)) {
+		return language.Und, fmt.Errorf("%w: %s", ErrUnsupportedLocale, locale)
+	}
+	return tag, nil
+}
+
+// Translatable canonicalizes a locale content may be translated to, any supported locale but the default
+func (uc *LocaleUseCase) Translatable(locale string) (string, error) {
+	tag, err := uc.Supported(locale)
+	if err != nil {
+		return "", err
+	}
+	if tag == uc.defaultLocale {
+		return "", fmt.Errorf("%w: %s is the default locale, edit the product itself", ErrUnsupportedLocale, tag)
+	}
+	return tag.String(), nil
+}
+
+// Translated lists the locales content is translated to
+func (uc *LocaleUseCase) Translated() []string {
+	return uc.Locales().Supported
+}
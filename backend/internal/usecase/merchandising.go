@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/fuzzy"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	defaultBoostFactor = 2
+	maxBoostFactor     = 100
+	// buryFactor - buried products keep their order among themselves but fall behind everything else
+	buryFactor = 0.01
+	// maxSynonymWords - the longest synonym phrase that is looked up in a query
+	maxSynonymWords = 3
+	maxRulePins     = 20
+)
+
+var (
+	ErrSynonymSetNotFound = errors.New("synonym set not found")
+	ErrInvalidSynonymSet  = errors.New("invalid synonym set")
+	ErrSearchRuleNotFound = errors.New("search rule not found")
+	ErrInvalidSearchRule  = errors.New("invalid search rule")
+)
+
+// MerchandisingUseCase manages synonym sets and per-query search rules and applies them to product searches
+type MerchandisingUseCase struct {
+	synonymRepo SynonymRepository
+	ruleRepo    SearchRuleRepository
+	productUC   *ProductUseCase
+}
+
+func NewMerchandisingUseCase(synonymRepo SynonymRepository, ruleRepo SearchRuleRepository, productUC *ProductUseCase) *MerchandisingUseCase {
+	return &MerchandisingUseCase{
+		synonymRepo: synonymRepo,
+		ruleRepo:    ruleRepo,
+		productUC:   productUC,
+	}
+}
+
+// Search runs a product search with synonyms and the active rules of the query applied
+func (uc *MerchandisingUseCase) Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error) {
+	result, _, err := uc.search(ctx, params)
+	return result, err
+}
+
+// Preview runs the search with and without synonyms and rules. Previews aren't logged as search queries
+func (uc *MerchandisingUseCase) Preview(ctx context.Context, params entity.ProductSearchParams) (*entity.SearchPreview, error) {
+	params.Untracked = true
+
+	withRules, rules, err := uc.search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	withoutRules, err := uc.productUC.Search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = []*entity.SearchRule{}
+	}
+	return &entity.SearchPreview{
+		WithRules:    withRules,
+		WithoutRules: withoutRules,
+		Rules:        rules,
+	}, nil
+}
+
+// search expands the query with synonyms and applies the rules. Boosts and pins only apply when
+// results are ranked by relevance; pinned products that pass the filters lead the first page and
+// are left out of all pages below it
+func (uc *MerchandisingUseCase) search(
+	ctx context.Context,
+	params entity.ProductSearchParams,
+) (*entity.ProductSearchResult, []*entity.SearchRule, error) {
+	query := normalizeQuery(params.Query)
+	if query == "" {
+		result, err := uc.productUC.Search(ctx, params)
+		return result, nil, err
+	}
+
+	synonyms, err := uc.synonymTerms(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	params.SynonymTerms = synonyms
+
+	rules, err := uc.ruleRepo.FindActive(ctx, query, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pinned []*entity.Product
+	if params.Sort == "" || params.Sort == entity.SortRelevance {
+		for _, rule := range rules {
+			if !rule.Boost.Empty() {
+				params.Boosts = append(params.Boosts, entity.SearchBoost{Match: rule.Boost, Factor: rule.BoostFactor})
+			}
+			if !rule.Bury.Empty() {
+				params.Boosts = append(params.Boosts, entity.SearchBoost{Match: rule.Bury, Factor: buryFactor})
+			}
+		}
+
+		if pinned, err = uc.pinnedProducts(ctx, rules, params); err != nil {
+			return nil, nil, err
+		}
+		// At least one slot of the page is left to the search itself so that paging goes on from it
+		if len(pinned) >= params.Page.Limit {
+			pinned = pinned[:max(params.Page.Limit-1, 0)]
+		}
+		for _, p := range pinned {
+			params.ExcludeIDs = append(params.ExcludeIDs, p.ID)
+		}
+	}
+
+	firstPage := params.Page.Cursor == ""
+	if firstPage {
+		params.Page.Limit -= len(pinned)
+	}
+	result, err := uc.productUC.Search(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result.SynonymTerms = params.SynonymTerms
+	if len(pinned) > 0 {
+		result.Page.Total += len(pinned)
+		result.DidYouMean = ""
+		if firstPage {
+			result.Products = append(pinned, result.Products...)
+			for _, p := range pinned {
+				result.Pinned = append(result.Pinned, p.ID)
+			}
+		}
+	}
+	return result, rules, nil
+}
+
+// synonymTerms finds the synonym sets of the query's words and phrases and returns the words of the
+// other terms of those sets. Excluded -words are left alone
+func (uc *MerchandisingUseCase) synonymTerms(ctx context.Context, query string) ([]string, error) {
+	var words []string
+	for _, field := range strings.Fields(query) {
+		if !strings.HasPrefix(field, "-") {
+			words = append(words, fuzzy.Tokenize(field)...)
+		}
+	}
+
+	var phrases []string
+	for n := 1; n <= maxSynonymWords; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			phrases = append(phrases, strings.Join(words[i:i+n], " "))
+		}
+	}
+	if len(phrases) == 0 {
+		return nil, nil
+	}
+
+	sets, err := uc.synonymRepo.FindByTerms(ctx, phrases)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []string
+	for _, set := range sets {
+		for _, term := range set.Terms {
+			if slices.Contains(phrases, term) {
+				continue
+			}
+			for _, word := range fuzzy.Tokenize(term) {
+				if !slices.Contains(words, word) && !slices.Contains(terms, word) {
+					terms = append(terms, word)
+				}
+			}
+		}
+	}
+	return terms, nil
+}
+
+// pinnedProducts loads the pins of the rules in order. Archived products and products not passing
+// the filters are skipped
+func (uc *MerchandisingUseCase) pinnedProducts(
+	ctx context.Context,
+	rules []*entity.SearchRule,
+	params entity.ProductSearchParams,
+) ([]*entity.Product, error) {
+	if len(params.Categories) > 0 {
+		categories, err := uc.productUC.expandCategories(ctx, params.Categories)
+		if err != nil {
+			return nil, err
+		}
+		params.Categories = categories
+	}
+
+	var pinned []*entity.Product
+	seen := make(map[bson.ObjectID]bool)
+	for _, rule := range rules {
+		for _, id := range rule.Pins {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			product, err := uc.productUC.GetByID(ctx, id)
+			if errors.Is(err, ErrProductNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if params.Accepts(product) {
+				pinned = append(pinned, product)
+			}
+		}
+	}
+	return pinned, nil
+}
+
+func (uc *MerchandisingUseCase) ListSynonyms(ctx context.Context, page entity.PageRequest) ([]*entity.SynonymSet, entity.PageInfo, error) {
+	return uc.synonymRepo.List(ctx, page)
+}
+
+func (uc *MerchandisingUseCase) CreateSynonyms(ctx context.Context, set *entity.SynonymSet) error {
+	if err := prepareSynonymSet(set); err != nil {
+		return err
+	}
+	return uc.synonymRepo.Create(ctx, set)
+}
+
+// UpdateSynonyms replaces the terms of the set
+func (uc *MerchandisingUseCase) UpdateSynonyms(ctx context.Context, update *entity.SynonymSet) (*entity.SynonymSet, error) {
+	set, err := uc.getSynonyms(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSynonymSet(update); err != nil {
+		return nil, err
+	}
+
+	set.Terms = update.Terms
+	if err := uc.synonymRepo.Update(ctx, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (uc *MerchandisingUseCase) DeleteSynonyms(ctx context.Context, id bson.ObjectID) error {
+	err := uc.synonymRepo.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSynonymSetNotFound
+	}
+	return err
+}
+
+func (uc *MerchandisingUseCase) ListRules(ctx context.Context, page entity.PageRequest) ([]*entity.SearchRule, entity.PageInfo, error) {
+	return uc.ruleRepo.List(ctx, page)
+}
+
+func (uc *MerchandisingUseCase) GetRule(ctx context.Context, id bson.ObjectID) (*entity.SearchRule, error) {
+	rule, err := uc.ruleRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSearchRuleNotFound
+	}
+	return rule, err
+}
+
+func (uc *MerchandisingUseCase) CreateRule(ctx context.Context, rule *entity.SearchRule) error {
+	if err := prepareSearchRule(rule); err != nil {
+		return err
+	}
+	return uc.ruleRepo.Create(ctx, rule)
+}
+
+// UpdateRule replaces everything but the creation time of the rule
+func (uc *MerchandisingUseCase) UpdateRule(ctx context.Context, update *entity.SearchRule) (*entity.SearchRule, error) {
+	rule, err := uc.GetRule(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareSearchRule(update); err != nil {
+		return nil, err
+	}
+
+	update.CreatedAt = rule.CreatedAt
+	if err := uc.ruleRepo.Update(ctx, update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+func (uc *MerchandisingUseCase) DeleteRule(ctx context.Context, id bson.ObjectID) error {
+	err := uc.ruleRepo.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSearchRuleNotFound
+	}
+	return err
+}
+
+func (uc *MerchandisingUseCase) getSynonyms(ctx context.Context, id bson.ObjectID) (*entity.SynonymSet, error) {
+	set, err := uc.synonymRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSynonymSetNotFound
+	}
+	return set, err
+}
+
+// prepareSynonymSet normalizes the terms the way queries are tokenized and drops duplicates
+func prepareSynonymSet(set *entity.SynonymSet) error {
+	var terms []string
+	for _, term := range set.Terms {
+		words := fuzzy.Tokenize(term)
+		if len(words) == 0 {
+			continue
+		}
+		if len(words) > maxSynonymWords {
+			return fmt.Errorf("%w: %q has more than %d words", ErrInvalidSynonymSet, term, maxSynonymWords)
+		}
+		if term = strings.Join(words, " "); !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) < 2 {
+		return fmt.Errorf("%w: at least two different terms are required", ErrInvalidSynonymSet)
+	}
+	set.Terms = terms
+	return nil
+}
+
+// prepareSearchRule normalizes the queries and checks that the rule does something
+func prepareSearchRule(rule *entity.SearchRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+
+	var queries []string
+	for _, q := range rule.Queries {
+		if q = normalizeQuery(q); q != "" && !slices.Contains(queries, q) {
+			queries = append(queries, q)
+		}
+	}
+	rule.Queries = queries
+
+	switch {
+	case rule.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidSearchRule)
+	case len(rule.Queries) == 0:
+		return fmt.Errorf("%w: at least one query is required", ErrInvalidSearchRule)
+	case len(rule.Pins) == 0 && rule.Boost.Empty() && rule.Bury.Empty():
+		return fmt.Errorf("%w: the rule needs pins, boosts or burials", ErrInvalidSearchRule)
+	case len(rule.Pins) > maxRulePins:
+		return fmt.Errorf("%w: at most %d pins", ErrInvalidSearchRule, maxRulePins)
+	case rule.BoostFactor != 0 && (rule.BoostFactor <= 1 || rule.BoostFactor > maxBoostFactor):
+		return fmt.Errorf("%w: boost factor must be above 1 and at most %d", ErrInvalidSearchRule, maxBoostFactor)
+	case rule.ActiveFrom != nil && rule.ActiveUntil != nil && !rule.ActiveUntil.After(*rule.ActiveFrom):
+		return fmt.Errorf("%w: activeUntil must be after activeFrom", ErrInvalidSearchRule)
+	}
+
+	if rule.BoostFactor == 0 {
+		rule.BoostFactor = defaultBoostFactor
+	}
+	if rule.Pins == nil {
+		rule.Pins = []bson.ObjectID{}
+	}
+	return nil
+}
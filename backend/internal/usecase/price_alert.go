@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPriceWatches - watches a single user may keep
+	maxPriceWatches = 100
+	// priceAlertBatch - price changes checked per run, the rest wait for the next one
+	priceAlertBatch = 500
+)
+
+var (
+	ErrPriceWatchNotFound  = errors.New("price watch not found")
+	ErrPriceWatchExists    = errors.New("price is already watched")
+	ErrTooManyPriceWatches = errors.New("too many price watches")
+	ErrInvalidTargetPrice  = errors.New("target price must be positive and below the current price")
+)
+
+// PriceAlertUseCase manages price watches and notifies users when a watched price drops
+type PriceAlertUseCase struct {
+	watchRepo       PriceWatchRepository
+	priceRepo       PriceHistoryRepository
+	productUC       *ProductUseCase
+	userRepo        UserRepository
+	wishlistRepo    WishlistRepository
+	interactionRepo InteractionRepository
+	notifier        Notifier
+	l               *zap.SugaredLogger
+}
+
+func NewPriceAlertUseCase(
+	watchRepo PriceWatchRepository,
+	priceRepo PriceHistoryRepository,
+	productUC *ProductUseCase,
+	userRepo UserRepository,
+	wishlistRepo WishlistRepository,
+	interactionRepo InteractionRepository,
+	notifier Notifier,
+	l *zap.SugaredLogger,
+) *PriceAlertUseCase {
+	return &PriceAlertUseCase{
+		watchRepo:       watchRepo,
+		priceRepo:       priceRepo,
+		productUC:       productUC,
+		userRepo:        userRepo,
+		wishlistRepo:    wishlistRepo,
+		interactionRepo: interactionRepo,
+		notifier:        notifier,
+		l:               l,
+	}
+}
+
+// Watch starts watching the product price, or a variant price when sku is given. Without a target
+// every drop from the current price is reported
+func (uc *PriceAlertUseCase) Watch(
+	ctx context.Context,
+	userID, productID bson.ObjectID,
+	sku string,
+	target *money.Amount,
+) (*entity.PriceWatch, error) {
+	product, err := uc.productUC.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentPrice(product, sku)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTargetPrice(target, current); err != nil {
+		return nil, err
+	}
+
+	watches, err := uc.watchRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(watches) >= maxPriceWatches {
+		return nil, ErrTooManyPriceWatches
+	}
+
+	watch := &entity.PriceWatch{
+		UserID:         userID,
+		ProductID:      productID,
+		SKU:            sku,
+		TargetPrice:    target,
+		ReferencePrice: current,
+	}
+	if err := uc.watchRepo.Create(ctx, watch); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrPriceWatchExists
+		}
+		return nil, err
+	}
+	return watch, nil
+}
+
+// UpdateWatch changes the target price of the user's watch, nil reports every drop
+func (uc *PriceAlertUseCase) UpdateWatch(ctx context.Context, userID, id bson.ObjectID, target *money.Amount) (*entity.PriceWatch, error) {
+	watch, err := uc.getOwned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := uc.productUC.GetByID(ctx, watch.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentPrice(product, watch.SKU)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTargetPrice(target, current); err != nil {
+		return nil, err
+	}
+
+	watch.TargetPrice = target
+	watch.ReferencePrice = current
+	if err := uc.watchRepo.Update(ctx, watch); err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+func (uc *PriceAlertUseCase) Unwatch(ctx context.Context, userID, id bson.ObjectID) error {
+	if _, err := uc.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+	err := uc.watchRepo.Delete(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrPriceWatchNotFound
+	}
+	return err
+}
+
+func (uc *PriceAlertUseCase) ListWatches(
+	ctx context.Context,
+	userID bson.ObjectID,
+	page entity.PageRequest,
+) ([]*entity.PriceWatch, entity.PageInfo, error) {
+	return uc.watchRepo.ListByUser(ctx, userID, page)
+}
+
+// Candidates offers the wishlisted and liked products the user doesn't watch yet, wishlisted ones first
+func (uc *PriceAlertUseCase) Candidates(ctx context.Context, userID bson.ObjectID) ([]*entity.PriceAlertCandidate, error) {
+	watches, err := uc.watchRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[bson.ObjectID]bool, len(watches))
+	for _, w := range watches {
+		seen[w.ProductID] = true
+	}
+
+	candidates := []*entity.PriceAlertCandidate{}
+	add := func(productID bson.ObjectID, source string) error {
+		if seen[productID] {
+			return nil
+		}
+		seen[productID] = true
+
+		product, err := uc.productUC.GetByID(ctx, productID)
+		if errors.Is(err, ErrProductNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, &entity.PriceAlertCandidate{Product: product, Source: source})
+		return nil
+	}
+
+	wishlists, err := uc.wishlistRepo.GetUserWishlists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, wishlist := range wishlists {
+		for _, item := range wishlist.Items {
+			if err := add(item.ProductID, entity.PriceAlertFromWishlist); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	liked, err := uc.interactionRepo.GetUserProductIDs(ctx, userID, entity.InteractionLike)
+	if err != nil {
+		return nil, err
+	}
+	for _, productID := range liked {
+		if err := add(productID, entity.PriceAlertFromLike); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+// Run is the scheduled job: checks recorded price changes against the watches of the changed prices
+// and notifies the users whose watch condition is met. Alerts that fail are logged and counted,
+// they don't hold up the other watches and changes
+func (uc *PriceAlertUseCase) Run(ctx context.Context) (*entity.PriceAlertRun, error) {
+	changes, err := uc.priceRepo.GetPending(ctx, priceAlertBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &entity.PriceAlertRun{}
+	for _, change := range changes {
+		sent, failed, err := uc.alert(ctx, change)
+		run.AlertsSent += sent
+		run.Failed += failed
+		if err != nil {
+			return run, err
+		}
+		if err := uc.priceRepo.MarkAlerted(ctx, change.ID); err != nil {
+			return run, err
+		}
+		run.Changes++
+	}
+	return run, nil
+}
+
+// alert notifies the watchers of a changed price and returns the alerts sent and failed. Every watch
+// moves its reference to the new price, so a change that is checked again after a failed run doesn't
+// notify twice. Watches whose alert failed keep their reference and are alerted on the next drop
+func (uc *PriceAlertUseCase) alert(ctx context.Context, change *entity.PriceChange) (int, int, error) {
+	// Archived and purged products have nothing to buy
+	product, err := uc.productUC.GetByID(ctx, change.ProductID)
+	if errors.Is(err, ErrProductNotFound) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	watches, err := uc.watchRepo.FindByProduct(ctx, change.ProductID, change.SKU)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, watch := range watches {
+		var notifiedAt *time.Time
+		if change.Price < watch.ReferencePrice && (watch.TargetPrice == nil || change.Price <= *watch.TargetPrice) {
+			notified, err := uc.notify(ctx, watch, product, change)
+			if err != nil {
+				// One user that can't be notified doesn't stop the alerts of the others
+				uc.l.Warnw("Failed to send price alert",
+					"user_id", watch.UserID.Hex(), "watch_id", watch.ID.Hex(), "error", err)
+				failed++
+				continue
+			}
+			if notified {
+				now := time.Now()
+				notifiedAt = &now
+				sent++
+			}
+		}
+		if err := uc.watchRepo.SetReference(ctx, watch.ID, change.Price, notifiedAt); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+// notify reports false when the user no longer exists
+func (uc *PriceAlertUseCase) notify(
+	ctx context.Context,
+	watch *entity.PriceWatch,
+	product *entity.Product,
+	change *entity.PriceChange,
+) (bool, error) {
+	user, err := uc.userRepo.GetByID(ctx, watch.UserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = uc.notifier.SendPriceAlert(ctx, &entity.PriceAlertMessage{
+		User:     user,
+		Product:  product,
+		Watch:    watch,
+		OldPrice: watch.ReferencePrice,
+		NewPrice: change.Price,
+	})
+	return err == nil, err
+}
+
+func (uc *PriceAlertUseCase) getOwned(ctx context.Context, userID, id bson.ObjectID) (*entity.PriceWatch, error) {
+	watch, err := uc.watchRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPriceWatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Other users' watches are reported as missing
+	if watch.UserID != userID {
+		return nil, ErrPriceWatchNotFound
+	}
+	return watch, nil
+}
+
+func validateTargetPrice(target *money.Amount, current money.Amount) error {
+	if target != nil && (*target <= 0 || *target >= current) {
+		return ErrInvalidTargetPrice
+	}
+	return nil
+}
@@ -2,43 +2,674 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
 
 	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/fuzzy"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidVariants = errors.New("invalid product variants")
+	ErrVariantNotFound = errors.New("product variant not found")
+	ErrVariantRequired = errors.New("product has variants, sku is required")
+	ErrOutOfStock      = errors.New("not enough stock")
+	// ErrVariantsNotImported - a row without variants can't overwrite a product that has some
+	ErrVariantsNotImported = errors.New("product has variants, import it with its variants as JSONL")
+
+	ErrInvalidSearchSort = errors.New("invalid sort, use relevance, price_asc, price_desc, rating or newest")
+	ErrInvalidPriceRange = errors.New("minPrice must not exceed maxPrice")
 )
 
 type ProductUseCase struct {
-	repo      ProductRepository
-	cacheRepo CacheRepository
+	repo         ProductRepository
+	categoryRepo CategoryRepository
+	versionRepo  ProductVersionRepository
+	priceRepo    PriceHistoryRepository
+	suggestRepo  SuggestRepository
+	searchIndex  SearchIndex
+	cacheRepo    CacheRepository
+	cacheTTL     int
+
+	// fuzzyMaxEdits - typo tolerance of search, 0 disables fuzzy matching
+	fuzzyMaxEdits int
+	vocabulary    atomic.Pointer[fuzzy.Vocabulary]
 }
 
-func NewProductUseCase(repo ProductRepository, cacheRepo CacheRepository) *ProductUseCase {
+func NewProductUseCase(
+	repo ProductRepository,
+	categoryRepo CategoryRepository,
+	versionRepo ProductVersionRepository,
+	priceRepo PriceHistoryRepository,
+	suggestRepo SuggestRepository,
+	searchIndex SearchIndex,
+	cacheRepo CacheRepository,
+	cacheTTL int,
+	fuzzyMaxEdits int,
+) *ProductUseCase {
 	return &ProductUseCase{
-		repo:      repo,
-		cacheRepo: cacheRepo,
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		versionRepo:  versionRepo,
+		priceRepo:    priceRepo,
+		suggestRepo:  suggestRepo,
+		searchIndex:  searchIndex,
+		cacheRepo:    cacheRepo,
+		cacheTTL:     cacheTTL,
+
+		fuzzyMaxEdits: fuzzyMaxEdits,
 	}
 }
 
-func (uc *ProductUseCase) Create(ctx context.Context, product *entity.Product) error {
-	return uc.repo.Create(ctx, product)
+// cachedPage - a product list page as stored in the cache
+type cachedPage struct {
+	Products []*entity.Product `json:"products"`
+	Page     entity.PageInfo   `json:"page"`
 }
 
+// Create adds the product and starts its history with the acting user as the author
+func (uc *ProductUseCase) Create(ctx context.Context, userID bson.ObjectID, product *entity.Product) error {
+	if err := uc.prepare(ctx, product); err != nil {
+		return err
+	}
+	// Sales are scheduled with AddSale, translations added once the product exists
+	product.Sales = nil
+	product.Translations = nil
+	// The category may be on sale already
+	if err := uc.ApplySales(ctx, product); err != nil {
+		return err
+	}
+	product.SetCurrentPrices()
+	if err := uc.repo.Create(ctx, product); err != nil {
+		return err
+	}
+
+	// A new product only shifts list pages, nothing cached refers to it yet
+	_, _ = uc.cacheRepo.IncrementCounter(ctx, productListVersionKey())
+	if err := uc.indexProduct(ctx, product); err != nil {
+		return err
+	}
+	if err := uc.recordPrices(ctx, nil, product); err != nil {
+		return err
+	}
+	return uc.recordVersion(ctx, userID, entity.ProductCreated, nil, product, 0)
+}
+
+// GetByID reads through the cache, misses are loaded from the repository and cached for cacheTTL.
+// Archived products are reported as not found
 func (uc *ProductUseCase) GetByID(ctx context.Context, id bson.ObjectID) (*entity.Product, error) {
-	return uc.repo.GetByID(ctx, id)
+	product, err := uc.getCached(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.ArchivedAt != nil {
+		return nil, ErrProductNotFound
+	}
+	if err := uc.ApplySales(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (uc *ProductUseCase) Update(ctx context.Context, product *entity.Product) error {
-	return uc.repo.Update(ctx, product)
+func (uc *ProductUseCase) getCached(ctx context.Context, id bson.ObjectID) (*entity.Product, error) {
+	key := productCacheKey(id)
+	if cached, err := uc.cacheRepo.Get(ctx, key); err == nil {
+		var product entity.Product
+		if err := json.Unmarshal([]byte(cached), &product); err == nil {
+			return &product, nil
+		}
+	}
+
+	product, err := uc.repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(product); err == nil {
+		_ = uc.cacheRepo.Set(ctx, key, string(data), uc.cacheTTL)
+	}
+	return product, nil
+}
+
+// Update saves the editable fields of the product and records the change. Images, sales, ratings,
+// the external SKU (unless given) and the creation time are kept from the stored product
+func (uc *ProductUseCase) Update(ctx context.Context, userID bson.ObjectID, product *entity.Product) error {
+	return uc.update(ctx, userID, entity.ProductUpdated, product, 0)
+}
+
+func (uc *ProductUseCase) update(
+	ctx context.Context,
+	userID bson.ObjectID,
+	change entity.ProductChange,
+	product *entity.Product,
+	rolledBackTo int,
+) error {
+	current, err := uc.getStored(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if err := uc.prepare(ctx, product); err != nil {
+		return err
+	}
+
+	if product.ExternalSKU == "" {
+		product.ExternalSKU = current.ExternalSKU
+	}
+	product.Images = current.Images
+	product.Sales = current.Sales
+	product.Translations = current.Translations
+	product.Rating = current.Rating
+	product.ReviewCount = current.ReviewCount
+	product.ArchivedAt = current.ArchivedAt
+	product.CreatedAt = current.CreatedAt
+	if err := uc.ApplySales(ctx, product); err != nil {
+		return err
+	}
+	product.SetCurrentPrices()
+
+	if err := uc.repo.Update(ctx, product); err != nil {
+		return err
+	}
+	uc.invalidate(ctx, product.ID)
+	if err := uc.indexProduct(ctx, product); err != nil {
+		return err
+	}
+	if err := uc.recordPrices(ctx, current, product); err != nil {
+		return err
+	}
+	return uc.recordVersion(ctx, userID, change, current, product, rolledBackTo)
+}
+
+// Delete archives the product; it is removed for good by the purge job once the retention period passes
+func (uc *ProductUseCase) Delete(ctx context.Context, userID, id bson.ObjectID) error {
+	return uc.setArchived(ctx, userID, id, true)
 }
 
-func (uc *ProductUseCase) Delete(ctx context.Context, id bson.ObjectID) error {
-	return uc.repo.Delete(ctx, id)
+// Restore brings an archived product back into listings and recommendations
+func (uc *ProductUseCase) Restore(ctx context.Context, userID, id bson.ObjectID) error {
+	return uc.setArchived(ctx, userID, id, false)
 }
 
-func (uc *ProductUseCase) List(ctx context.Context, limit, offset int) ([]*entity.Product, error) {
-	return uc.repo.List(ctx, limit, offset)
+func (uc *ProductUseCase) setArchived(ctx context.Context, userID, id bson.ObjectID, archived bool) error {
+	current, err := uc.getStored(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	change, archive := entity.ProductRestored, uc.repo.Restore
+	if archived {
+		change, archive = entity.ProductArchived, uc.repo.Archive
+	}
+	if err := archive(ctx, id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	uc.invalidate(ctx, id)
+
+	updated, err := uc.getStored(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.indexProduct(ctx, updated); err != nil {
+		return err
+	}
+	return uc.recordVersion(ctx, userID, change, current, updated, 0)
+}
+
+// getStored reads the product from the repository, bypassing the cache and including archived products
+func (uc *ProductUseCase) getStored(ctx context.Context, id bson.ObjectID) (*entity.Product, error) {
+	product, err := uc.repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+func (uc *ProductUseCase) ListArchived(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error) {
+	return uc.repo.ListArchived(ctx, page)
+}
+
+// AddImage appends an uploaded image to the product and returns the updated product
+func (uc *ProductUseCase) AddImage(ctx context.Context, id bson.ObjectID, image entity.ProductImage) (*entity.Product, error) {
+	product, err := uc.repo.AddImage(ctx, id, image)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.invalidate(ctx, id)
+	return product, uc.indexProduct(ctx, product)
+}
+
+// RemoveImage takes the image out of the product and returns the product as it was before
+func (uc *ProductUseCase) RemoveImage(ctx context.Context, id bson.ObjectID, imageID string) (*entity.Product, error) {
+	previous, err := uc.repo.RemoveImage(ctx, id, imageID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.invalidate(ctx, id)
+	return previous, uc.reindexProduct(ctx, id)
+}
+
+// ReorderImages stores the images of the product in a new order, ErrImagesChanged when the product's
+// images were changed since they were read
+func (uc *ProductUseCase) ReorderImages(ctx context.Context, id bson.ObjectID, images []entity.ProductImage) error {
+	if err := uc.repo.ReorderImages(ctx, id, images); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrImagesChanged
+		}
+		return err
+	}
+	uc.invalidate(ctx, id)
+	return uc.reindexProduct(ctx, id)
+}
+
+// SetTranslations replaces the translations of the product
+func (uc *ProductUseCase) SetTranslations(ctx context.Context, id bson.ObjectID, translations entity.Translations) error {
+	if err := uc.repo.SetTranslations(ctx, id, translations); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	uc.invalidate(ctx, id)
+	// The search index matches translated names and descriptions too
+	return uc.reindexProduct(ctx, id)
+}
+
+// ReserveStock takes the purchased quantities out of the stock of the products and their variants.
+// Either every item is reserved or, when one is out of stock, none is
+func (uc *ProductUseCase) ReserveStock(ctx context.Context, items []entity.PurchaseItem) error {
+	for i, item := range items {
+		if err := uc.repo.AdjustStock(ctx, item.ProductID, item.SKU, -item.Quantity); err != nil {
+			if releaseErr := uc.ReleaseStock(ctx, items[:i]); releaseErr != nil {
+				err = errors.Join(err, releaseErr)
+			}
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return fmt.Errorf("%w: product %s %s", ErrOutOfStock, item.ProductID.Hex(), item.SKU)
+			}
+			return err
+		}
+		uc.stockChanged(ctx, item.ProductID)
+	}
+	return nil
+}
+
+// ReleaseStock puts reserved items back in stock, e.g. when the purchase couldn't be stored
+func (uc *ProductUseCase) ReleaseStock(ctx context.Context, items []entity.PurchaseItem) error {
+	var errs []error
+	for _, item := range items {
+		if err := uc.repo.AdjustStock(ctx, item.ProductID, item.SKU, item.Quantity); err != nil {
+			errs = append(errs, fmt.Errorf("release %s %s: %w", item.ProductID.Hex(), item.SKU, err))
+			continue
+		}
+		uc.stockChanged(ctx, item.ProductID)
+	}
+	return errors.Join(errs...)
+}
+
+// stockChanged drops the cached product and reindexes it, the search index filters on stock too.
+// Best effort: the stock is already taken, an index that lags catches up on the next write
+func (uc *ProductUseCase) stockChanged(ctx context.Context, id bson.ObjectID) {
+	uc.invalidate(ctx, id)
+	_ = uc.reindexProduct(ctx, id)
+}
+
+// RenameCategory follows a category rename on all its products, each moved product is dropped
+// from the cache and reindexed
+func (uc *ProductUseCase) RenameCategory(ctx context.Context, from, to string) error {
+	ids, err := uc.repo.RenameCategory(ctx, from, to)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	for _, id := range ids {
+		uc.invalidate(ctx, id)
+	}
+
+	// Archived products aren't returned, they are out of the index already
+	products, err := uc.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := uc.indexProduct(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRating stores review aggregates, rounded to two decimals
+func (uc *ProductUseCase) UpdateRating(ctx context.Context, id bson.ObjectID, summary *entity.RatingSummary) error {
+	summary.Average = math.Round(summary.Average*100) / 100
+	if err := uc.repo.UpdateRating(ctx, id, summary); err != nil {
+		return err
+	}
+	uc.invalidate(ctx, id)
+	return uc.reindexProduct(ctx, id)
 }
 
-func (uc *ProductUseCase) Search(ctx context.Context, query, category string, limit int) ([]*entity.Product, error) {
-	return uc.repo.Search(ctx, query, category, limit)
+// UpsertByExternalSKU creates or overwrites the product with the same external SKU on behalf of the
+// importing user, reports whether it was created. variants tells whether the product carries its
+// options and variants, a product imported without them keeps the stored ones and must have none
+func (uc *ProductUseCase) UpsertByExternalSKU(ctx context.Context, userID bson.ObjectID, product *entity.Product, variants bool) (bool, error) {
+	if err := uc.prepare(ctx, product); err != nil {
+		return false, err
+	}
+
+	previous, err := uc.repo.UpsertByExternalSKU(ctx, product, variants)
+	if !variants && mongo.IsDuplicateKeyError(err) {
+		// The external SKU is taken by a product with variants
+		return false, ErrVariantsNotImported
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Imports don't carry current prices, they are set once the stored sales are known
+	if err := uc.ApplySales(ctx, product); err != nil {
+		return false, err
+	}
+	if product.SetCurrentPrices() {
+		// Skipped when the price was edited meanwhile, that edit stored its own current prices
+		if err := uc.repo.SetCurrentPrices(ctx, product); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return false, err
+		}
+	}
+
+	if previous == nil {
+		_, _ = uc.cacheRepo.IncrementCounter(ctx, productListVersionKey())
+	} else {
+		uc.invalidate(ctx, product.ID)
+	}
+	if err := uc.indexProduct(ctx, product); err != nil {
+		return false, err
+	}
+	if err := uc.recordPrices(ctx, previous, product); err != nil {
+		return false, err
+	}
+	return previous == nil, uc.recordVersion(ctx, userID, entity.ProductImported, previous, product, 0)
+}
+
+// List serves pages from the cache; keys carry the list version so any catalog write retires all cached pages
+func (uc *ProductUseCase) List(ctx context.Context, page entity.PageRequest) ([]*entity.Product, entity.PageInfo, error) {
+	version, err := uc.cacheRepo.Get(ctx, productListVersionKey())
+	if err != nil {
+		version = "0"
+	}
+	key := productListCacheKey(version, page.Cursor, page.Limit)
+
+	if cached, err := uc.cacheRepo.Get(ctx, key); err == nil {
+		var p cachedPage
+		if err := json.Unmarshal([]byte(cached), &p); err == nil {
+			return p.Products, p.Page, uc.ApplySales(ctx, p.Products...)
+		}
+	}
+
+	products, info, err := uc.repo.List(ctx, page)
+	if err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+
+	// Pages are cached at regular prices, sales start and end while they are cached
+	if data, err := json.Marshal(cachedPage{Products: products, Page: info}); err == nil {
+		_ = uc.cacheRepo.Set(ctx, key, string(data), uc.cacheTTL)
+	}
+	return products, info, uc.ApplySales(ctx, products...)
+}
+
+// invalidate drops the cached product, retires list pages and removes every cached
+// "related products" list and user recommendation that contains it. Cache failures are not returned:
+// the write already succeeded and stale entries expire with their TTL
+func (uc *ProductUseCase) invalidate(ctx context.Context, id bson.ObjectID) {
+	_, _ = uc.cacheRepo.IncrementCounter(ctx, productListVersionKey())
+
+	keys := []string{productCacheKey(id), productRecCacheKey(id), productRecRefsKey(id), productUserRecRefsKey(id)}
+	if refs, err := uc.cacheRepo.GetSetMembers(ctx, productRecRefsKey(id)); err == nil {
+		for _, ref := range refs {
+			if refID, err := bson.ObjectIDFromHex(ref); err == nil {
+				keys = append(keys, productRecCacheKey(refID))
+			}
+		}
+	}
+	if refs, err := uc.cacheRepo.GetSetMembers(ctx, productUserRecRefsKey(id)); err == nil {
+		for _, ref := range refs {
+			if userID, err := bson.ObjectIDFromHex(ref); err == nil {
+				keys = append(keys, userRecCacheKey(userID))
+			}
+		}
+	}
+	_ = uc.cacheRepo.Delete(ctx, keys...)
+}
+
+// Search filters, sorts and pages products. Misspelled query words are also matched by their catalog
+// corrections; when nothing is found a corrected query is offered. Queries whose first page finds
+// results without corrections are logged for suggestions
+func (uc *ProductUseCase) Search(ctx context.Context, params entity.ProductSearchParams) (*entity.ProductSearchResult, error) {
+	switch params.Sort {
+	case "":
+		params.Sort = entity.SortRelevance
+	case entity.SortRelevance, entity.SortPriceAsc, entity.SortPriceDesc, entity.SortRating, entity.SortNewest:
+	default:
+		return nil, ErrInvalidSearchSort
+	}
+
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return nil, ErrInvalidPriceRange
+	}
+
+	if len(params.Categories) > 0 {
+		categories, err := uc.expandCategories(ctx, params.Categories)
+		if err != nil {
+			return nil, err
+		}
+		params.Categories = categories
+	}
+
+	schema, err := uc.prepareAttributeSearch(ctx, &params)
+	if err != nil {
+		return nil, err
+	}
+	params.FuzzyTerms = uc.fuzzyTerms(params.Query)
+
+	result, err := uc.searchIndex.Search(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	labelAttributeFacets(result.Facets.Attributes, schema)
+	if err := uc.ApplySales(ctx, result.Products...); err != nil {
+		return nil, err
+	}
+	result.FuzzyTerms = params.FuzzyTerms
+	if result.Page.Total == 0 && params.Query != "" {
+		result.DidYouMean = uc.didYouMean(params.Query)
+	}
+
+	// Logging is best effort, a failure doesn't fail the search
+	if params.Query != "" && params.Page.Cursor == "" && result.Page.Total > 0 && len(params.FuzzyTerms) == 0 && !params.Untracked {
+		_ = recordSearchQuery(ctx, uc.suggestRepo, params.Query)
+	}
+	return result, nil
+}
+
+// prepare checks the category against the category tree, the attributes against its schema and validates variants
+func (uc *ProductUseCase) prepare(ctx context.Context, product *entity.Product) error {
+	category, err := uc.categoryRepo.GetByName(ctx, product.Category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("%w: %q", ErrUnknownCategory, product.Category)
+		}
+		return err
+	}
+	schema, err := attributeSchema(ctx, uc.categoryRepo, category)
+	if err != nil {
+		return err
+	}
+	if err := prepareAttributes(schema, product); err != nil {
+		return err
+	}
+	return prepareVariants(product)
+}
+
+// expandCategories adds all subcategories of the given categories, unknown names are kept as they are
+func (uc *ProductUseCase) expandCategories(ctx context.Context, names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var expanded []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			expanded = append(expanded, name)
+		}
+	}
+
+	for _, name := range names {
+		add(name)
+
+		category, err := uc.categoryRepo.GetByName(ctx, name)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		descendants, err := uc.categoryRepo.GetDescendants(ctx, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range descendants {
+			add(d.Name)
+		}
+	}
+	return expanded, nil
+}
+
+// prepareVariants checks variants against the option axes and syncs the parent price and stock,
+// so listings, search and recommendations keep working on the parent product
+func prepareVariants(product *entity.Product) error {
+	if len(product.Variants) == 0 {
+		return nil
+	}
+	if len(product.Options) == 0 {
+		return fmt.Errorf("%w: variants require option axes", ErrInvalidVariants)
+	}
+
+	allowed := make(map[string]map[string]bool, len(product.Options))
+	for _, option := range product.Options {
+		if option.Name == "" || len(option.Values) == 0 {
+			return fmt.Errorf("%w: option needs a name and values", ErrInvalidVariants)
+		}
+		if allowed[option.Name] != nil {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidVariants, option.Name)
+		}
+		allowed[option.Name] = make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			allowed[option.Name][value] = true
+		}
+	}
+
+	skus := make(map[string]bool, len(product.Variants))
+	combinations := make(map[string]bool, len(product.Variants))
+	minPrice, totalStock := product.Variants[0].Price, 0
+
+	for _, variant := range product.Variants {
+		if variant.SKU == "" {
+			return fmt.Errorf("%w: sku is required", ErrInvalidVariants)
+		}
+		if skus[variant.SKU] {
+			return fmt.Errorf("%w: duplicate sku %q", ErrInvalidVariants, variant.SKU)
+		}
+		skus[variant.SKU] = true
+
+		if variant.Price < 0 || variant.Stock < 0 {
+			return fmt.Errorf("%w: sku %q has negative price or stock", ErrInvalidVariants, variant.SKU)
+		}
+		if len(variant.Options) != len(product.Options) {
+			return fmt.Errorf("%w: sku %q must set every option", ErrInvalidVariants, variant.SKU)
+		}
+
+		values := make([]string, 0, len(product.Options))
+		for _, option := range product.Options {
+			value := variant.Options[option.Name]
+			if !allowed[option.Name][value] {
+				return fmt.Errorf("%w: sku %q has invalid %s %q", ErrInvalidVariants, variant.SKU, option.Name, value)
+			}
+			values = append(values, value)
+		}
+
+		combination := strings.Join(values, "/")
+		if combinations[combination] {
+			return fmt.Errorf("%w: duplicate combination %q", ErrInvalidVariants, combination)
+		}
+		combinations[combination] = true
+
+		if variant.Price < minPrice {
+			minPrice = variant.Price
+		}
+		totalStock += variant.Stock
+	}
+
+	product.Price = minPrice
+	product.Stock = totalStock
+	return nil
+}
+
+// Reindex rebuilds the search index from the catalog, returns the number of indexed products
+func (uc *ProductUseCase) Reindex(ctx context.Context) (int, error) {
+	return ReindexProducts(ctx, uc.repo, uc.searchIndex)
+}
+
+// ReindexProducts replaces the index with every product that isn't archived
+func ReindexProducts(ctx context.Context, repo ProductRepository, index SearchIndex) (int, error) {
+	var products []*entity.Product
+	err := repo.ForEach(ctx, func(p *entity.Product) error {
+		if p.ArchivedAt == nil {
+			products = append(products, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := index.Replace(ctx, products); err != nil {
+		return 0, err
+	}
+	return len(products), nil
+}
+
+// indexProduct keeps the search index in step with a written product, archived products leave it
+func (uc *ProductUseCase) indexProduct(ctx context.Context, product *entity.Product) error {
+	if product.ArchivedAt != nil {
+		return uc.searchIndex.Delete(ctx, product.ID)
+	}
+	return uc.searchIndex.Index(ctx, product)
+}
+
+// reindexProduct reloads the product after a partial write and indexes it
+func (uc *ProductUseCase) reindexProduct(ctx context.Context, id bson.ObjectID) error {
+	product, err := uc.getStored(ctx, id)
+	if errors.Is(err, ErrProductNotFound) {
+		return uc.searchIndex.Delete(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	return uc.indexProduct(ctx, product)
 }
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// maxCategoryAttributes - attributes a single category may define
+	maxCategoryAttributes = 50
+	// maxAttributeTextLength - longest text attribute value, in bytes
+	maxAttributeTextLength = 500
+)
+
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var (
+	ErrInvalidAttributes      = errors.New("invalid product attributes")
+	ErrInvalidAttributeSchema = errors.New("invalid attribute schema")
+	ErrInvalidAttributeFilter = errors.New("invalid attribute filter")
+)
+
+// attributeSchema returns the attributes products of the category carry: the ancestors' ones from
+// the root down, then its own. A redefined key replaces the inherited definition in place
+func attributeSchema(ctx context.Context, repo CategoryRepository, category *entity.Category) ([]entity.AttributeDef, error) {
+	path := make([]*entity.Category, 0, len(category.Ancestors)+1)
+	for _, id := range category.Ancestors {
+		ancestor, err := repo.GetByID(ctx, id)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, ancestor)
+	}
+	return mergeAttributeSchemas(append(path, category)), nil
+}
+
+// mergeAttributeSchemas combines the schemas of a category path, root first
+func mergeAttributeSchemas(path []*entity.Category) []entity.AttributeDef {
+	var defs []entity.AttributeDef
+	for _, c := range path {
+		for _, d := range c.Attributes {
+			i := slices.IndexFunc(defs, func(def entity.AttributeDef) bool { return def.Key == d.Key })
+			if i >= 0 {
+				defs[i] = d
+			} else {
+				defs = append(defs, d)
+			}
+		}
+	}
+	return defs
+}
+
+func categoriesByID(categories []*entity.Category) map[bson.ObjectID]*entity.Category {
+	byID := make(map[bson.ObjectID]*entity.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	return byID
+}
+
+// categoryPath returns the loaded ancestors of the category from the root down, then the category
+func categoryPath(c *entity.Category, byID map[bson.ObjectID]*entity.Category) []*entity.Category {
+	path := make([]*entity.Category, 0, len(c.Ancestors)+1)
+	for _, id := range c.Ancestors {
+		if ancestor, ok := byID[id]; ok {
+			path = append(path, ancestor)
+		}
+	}
+	return append(path, c)
+}
+
+// prepareAttributes checks the product attributes against the schema of its category and stores
+// them typed: numbers as float64, text trimmed. Blank values count as missing
+func prepareAttributes(defs []entity.AttributeDef, product *entity.Product) error {
+	known := make(map[string]bool, len(defs))
+	for _, d := range defs {
+		known[d.Key] = true
+	}
+	for key := range product.Attributes {
+		if !known[key] {
+			return fmt.Errorf("%w: %q is not an attribute of %q", ErrInvalidAttributes, key, product.Category)
+		}
+	}
+
+	valid := make(entity.AttributeValues, len(product.Attributes))
+	for _, d := range defs {
+		raw, ok := product.Attributes[d.Key]
+		if s, isString := raw.(string); isString && strings.TrimSpace(s) == "" {
+			ok = false
+		}
+		if !ok || raw == nil {
+			if d.Required {
+				return fmt.Errorf("%w: %q is required", ErrInvalidAttributes, d.Key)
+			}
+			continue
+		}
+
+		v, err := attributeValue(d, raw)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAttributes, err.Error())
+		}
+		valid[d.Key] = v
+	}
+	product.Attributes = valid
+	return nil
+}
+
+func attributeValue(d entity.AttributeDef, raw any) (any, error) {
+	switch d.Type {
+	case entity.AttributeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case int32:
+			n = float64(v)
+		case int64:
+			n = float64(v)
+		case int:
+			n = float64(v)
+		default:
+			return nil, fmt.Errorf("%q must be a number", d.Key)
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%q must be a finite number", d.Key)
+		}
+		return n, nil
+	case entity.AttributeBoolean:
+		if v, ok := raw.(bool); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%q must be true or false", d.Key)
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%q must be a string", d.Key)
+		}
+		s = strings.TrimSpace(s)
+		if d.Type == entity.AttributeEnum && !slices.Contains(d.Values, s) {
+			return nil, fmt.Errorf("%q must be one of %s", d.Key, strings.Join(d.Values, ", "))
+		}
+		if len(s) > maxAttributeTextLength {
+			return nil, fmt.Errorf("%q is longer than %d characters", d.Key, maxAttributeTextLength)
+		}
+		return s, nil
+	}
+}
+
+// prepareAttributeDefs validates a category's own attribute schema, labels default to the key
+func prepareAttributeDefs(defs []entity.AttributeDef) error {
+	if len(defs) > maxCategoryAttributes {
+		return fmt.Errorf("%w: at most %d attributes", ErrInvalidAttributeSchema, maxCategoryAttributes)
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for i := range defs {
+		d := &defs[i]
+		d.Key = strings.TrimSpace(d.Key)
+		d.Label = strings.TrimSpace(d.Label)
+		d.Unit = strings.TrimSpace(d.Unit)
+
+		if !attributeKeyPattern.MatchString(d.Key) {
+			return fmt.Errorf("%w: key %q must start with a letter and use lowercase letters, digits and underscores",
+				ErrInvalidAttributeSchema, d.Key)
+		}
+		if seen[d.Key] {
+			return fmt.Errorf("%w: duplicate key %q", ErrInvalidAttributeSchema, d.Key)
+		}
+		seen[d.Key] = true
+		if d.Label == "" {
+			d.Label = d.Key
+		}
+
+		switch d.Type {
+		case entity.AttributeEnum:
+			values := make([]string, 0, len(d.Values))
+			for _, v := range d.Values {
+				if v = strings.TrimSpace(v); v != "" && !slices.Contains(values, v) {
+					values = append(values, v)
+				}
+			}
+			if len(values) == 0 {
+				return fmt.Errorf("%w: enum %q needs values", ErrInvalidAttributeSchema, d.Key)
+			}
+			d.Values = values
+		case entity.AttributeText, entity.AttributeNumber, entity.AttributeBoolean:
+			if len(d.Values) > 0 {
+				return fmt.Errorf("%w: only enum attributes take values, %q is %s", ErrInvalidAttributeSchema, d.Key, d.Type)
+			}
+		default:
+			return fmt.Errorf("%w: type of %q must be text, number, boolean or enum", ErrInvalidAttributeSchema, d.Key)
+		}
+		if d.Unit != "" && d.Type != entity.AttributeNumber {
+			return fmt.Errorf("%w: only number attributes take a unit", ErrInvalidAttributeSchema)
+		}
+	}
+	return nil
+}
+
+// prepareAttributeSearch types the attribute filters by the schemas of the searched categories, of all
+// categories when none are given, and asks for facets of their faceted attributes. Returns the schema by key
+func (uc *ProductUseCase) prepareAttributeSearch(ctx context.Context, params *entity.ProductSearchParams) (map[string]entity.AttributeDef, error) {
+	all, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := categoriesByID(all)
+
+	schema := make(map[string]entity.AttributeDef)
+	params.AttributeFacets = nil
+	for _, c := range all {
+		if len(params.Categories) > 0 && !slices.Contains(params.Categories, c.Name) {
+			continue
+		}
+		// The first definition of a key wins when categories define it differently
+		for _, d := range mergeAttributeSchemas(categoryPath(c, byID)) {
+			if _, ok := schema[d.Key]; ok {
+				continue
+			}
+			schema[d.Key] = d
+			if d.Facet {
+				params.AttributeFacets = append(params.AttributeFacets, d.Key)
+			}
+		}
+	}
+
+	for i := range params.Attributes {
+		f := &params.Attributes[i]
+		d, ok := schema[f.Key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttributeFilter, f.Key)
+		}
+		if (f.Min != nil || f.Max != nil) && d.Type != entity.AttributeNumber {
+			return nil, fmt.Errorf("%w: %q is not a number, it has no range", ErrInvalidAttributeFilter, f.Key)
+		}
+
+		f.Values = nil
+		for _, raw := range f.Raw {
+			v, err := parseAttributeValue(d, raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidAttributeFilter, err.Error())
+			}
+			f.Values = append(f.Values, v)
+		}
+	}
+	return schema, nil
+}
+
+// parseAttributeValue reads a value of the attribute as given in a query or a facet
+func parseAttributeValue(d entity.AttributeDef, raw string) (any, error) {
+	switch d.Type {
+	case entity.AttributeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q must be a number", d.Key)
+		}
+		return n, nil
+	case entity.AttributeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q must be true or false", d.Key)
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+// labelAttributeFacets adds the label, type and unit of the schema to the facets the index counted
+func labelAttributeFacets(facets []entity.AttributeFacet, schema map[string]entity.AttributeDef) {
+	for i := range facets {
+		d := schema[facets[i].Key]
+		facets[i].Label = d.Label
+		facets[i].Type = d.Type
+		facets[i].Unit = d.Unit
+	}
+}
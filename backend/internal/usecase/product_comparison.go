@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	minComparedProducts = 2
+	maxComparedProducts = 4
+)
+
+var ErrInvalidComparison = errors.New("invalid comparison")
+
+// Compare lines up the products side by side: price, rating, review count, stock, category and the
+// attributes of their categories, in the order the categories define them. Prices include the sales
+// in effect. Archived products count as missing
+func (uc *ProductUseCase) Compare(ctx context.Context, ids []bson.ObjectID) (*entity.ProductComparison, error) {
+	ids, err := comparisonIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := uc.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	products := make([]*entity.Product, len(ids))
+	for i, id := range ids {
+		j := slices.IndexFunc(loaded, func(p *entity.Product) bool { return p.ID == id })
+		if j < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrProductNotFound, id.Hex())
+		}
+		products[i] = loaded[j]
+	}
+	if err := uc.ApplySales(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	defs, err := uc.comparedAttributes(ctx, products)
+	if err != nil {
+		return nil, err
+	}
+
+	values := func(fn func(p *entity.Product) any) []any {
+		v := make([]any, len(products))
+		for i, p := range products {
+			v[i] = fn(p)
+		}
+		return v
+	}
+	rows := []entity.ComparisonRow{
+		entity.NewComparisonRow("price", "Price", entity.ComparisonPrice, "",
+			values(func(p *entity.Product) any { return p.EffectivePrice() })),
+		entity.NewComparisonRow("rating", "Rating", string(entity.AttributeNumber), "",
+			values(func(p *entity.Product) any { return p.Rating })),
+		entity.NewComparisonRow("reviewCount", "Reviews", string(entity.AttributeNumber), "",
+			values(func(p *entity.Product) any { return p.ReviewCount })),
+		entity.NewComparisonRow("stock", "Stock", string(entity.AttributeNumber), "",
+			values(func(p *entity.Product) any { return p.Stock })),
+		entity.NewComparisonRow("category", "Category", string(entity.AttributeText), "",
+			values(func(p *entity.Product) any { return p.Category })),
+	}
+	for _, d := range defs {
+		rows = append(rows, entity.NewComparisonRow("attributes."+d.Key, d.Label, string(d.Type), d.Unit,
+			values(func(p *entity.Product) any { return p.Attributes[d.Key] })))
+	}
+
+	var signals []entity.ComparisonSignal
+	for i := range ids {
+		for _, other := range ids[i+1:] {
+			signals = append(signals, entity.ComparisonSignal{ProductID: ids[i], ComparedWith: other})
+		}
+	}
+
+	return &entity.ProductComparison{Products: products, Rows: rows, Signals: signals}, nil
+}
+
+// comparedAttributes returns the attributes of the products' categories, each key once. A key
+// defined differently by two categories keeps the definition of the first product's category
+func (uc *ProductUseCase) comparedAttributes(ctx context.Context, products []*entity.Product) ([]entity.AttributeDef, error) {
+	all, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := categoriesByID(all)
+
+	var defs []entity.AttributeDef
+	var seen []string
+	for _, p := range products {
+		i := slices.IndexFunc(all, func(c *entity.Category) bool { return c.Name == p.Category })
+		if i < 0 || slices.Contains(seen, p.Category) {
+			continue
+		}
+		seen = append(seen, p.Category)
+		for _, d := range mergeAttributeSchemas(categoryPath(all[i], byID)) {
+			if !slices.ContainsFunc(defs, func(def entity.AttributeDef) bool { return def.Key == d.Key }) {
+				defs = append(defs, d)
+			}
+		}
+	}
+	return defs, nil
+}
+
+// comparisonIDs drops repeated ids and checks that 2 to 4 products are left
+func comparisonIDs(ids []bson.ObjectID) ([]bson.ObjectID, error) {
+	var unique []bson.ObjectID
+	for _, id := range ids {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) < minComparedProducts || len(unique) > maxComparedProducts {
+		return nil, fmt.Errorf("%w: compare %d to %d different products", ErrInvalidComparison,
+			minComparedProducts, maxComparedProducts)
+	}
+	return unique, nil
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/fuzzy"
+)
+
+const (
+	// Words shorter than this are never corrected, almost any edit turns them into another word
+	minFuzzyWordLength = 4
+	// Words at least this long get the full edit tolerance, shorter ones a single edit
+	longFuzzyWordLength = 8
+	// fuzzyMatchesPerWord - corrections searched for each misspelled word
+	fuzzyMatchesPerWord = 3
+	// didYouMeanMaxEdits - "did you mean" looks further than the search itself
+	didYouMeanMaxEdits = 2
+)
+
+// RefreshVocabulary rebuilds the words fuzzy search corrects to from product names in every locale,
+// tags and categories, returns the vocabulary size. Until the first refresh searches are exact only
+func (uc *ProductUseCase) RefreshVocabulary(ctx context.Context) (int, error) {
+	frequency := make(map[string]int)
+	err := uc.repo.ForEach(ctx, func(p *entity.Product) error {
+		if p.ArchivedAt != nil {
+			return nil
+		}
+		text := p.Name + " " + p.Category + " " + strings.Join(p.Tags, " ")
+		for _, t := range p.Translations {
+			text += " " + t.Name
+		}
+		words := fuzzy.Tokenize(text)
+		for _, w := range words {
+			frequency[w]++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	vocabulary := fuzzy.NewVocabulary(frequency)
+	uc.vocabulary.Store(vocabulary)
+	return vocabulary.Len(), nil
+}
+
+// fuzzyTerms corrects query words missing from the catalog vocabulary within the edit tolerance.
+// Words the catalog knows are never expanded, so a fuzzy match can't outrank an exact one.
+// Phrases and negated words are left alone
+func (uc *ProductUseCase) fuzzyTerms(query string) []string {
+	vocabulary := uc.vocabulary.Load()
+	if vocabulary == nil || uc.fuzzyMaxEdits <= 0 || strings.Contains(query, `"`) {
+		return nil
+	}
+
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		for _, word := range fuzzy.Tokenize(field) {
+			if vocabulary.Contains(word) {
+				continue
+			}
+			for _, m := range vocabulary.Lookup(word, uc.maxEditsFor(word), fuzzyMatchesPerWord) {
+				terms = append(terms, m.Word)
+			}
+		}
+	}
+	return terms
+}
+
+// didYouMean replaces each unknown query word with its closest catalog word,
+// empty when nothing could be corrected
+func (uc *ProductUseCase) didYouMean(query string) string {
+	vocabulary := uc.vocabulary.Load()
+	if vocabulary == nil {
+		return ""
+	}
+
+	words := fuzzy.Tokenize(query)
+	corrected := false
+	for i, word := range words {
+		if vocabulary.Contains(word) || len([]rune(word)) < minFuzzyWordLength {
+			continue
+		}
+		if matches := vocabulary.Lookup(word, didYouMeanMaxEdits, 1); len(matches) > 0 {
+			words[i] = matches[0].Word
+			corrected = true
+		}
+	}
+	if !corrected {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+func (uc *ProductUseCase) maxEditsFor(word string) int {
+	switch n := len([]rune(word)); {
+	case n < minFuzzyWordLength:
+		return 0
+	case n < longFuzzyWordLength:
+		return min(1, uc.fuzzyMaxEdits)
+	default:
+		return uc.fuzzyMaxEdits
+	}
+}
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/thumbnail"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// maxImagePixels guards against small files that decode into huge bitmaps
+	maxImagePixels       = 40_000_000
+	thumbnailJPEGQuality = 85
+)
+
+// imageExtensions - accepted upload types, detected from the file content rather than its name
+var imageExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+var (
+	ErrImageTooLarge        = errors.New("image exceeds the maximum upload size")
+	ErrUnsupportedImageType = errors.New("unsupported image type, use jpeg, png or gif")
+	ErrInvalidImage         = errors.New("invalid or too large image")
+	ErrImageNotFound        = errors.New("image not found")
+	ErrInvalidImageOrder    = errors.New("image order must list every image of the product exactly once")
+	ErrImagesChanged        = errors.New("the product's images have changed, reload them and try again")
+)
+
+type ProductImageUseCase struct {
+	productRepo   ProductRepository
+	productUC     *ProductUseCase
+	blobStore     BlobStore
+	maxUploadSize int64
+	thumbnailSize int
+}
+
+func NewProductImageUseCase(
+	productRepo ProductRepository,
+	productUC *ProductUseCase,
+	blobStore BlobStore,
+	maxUploadSize int64,
+	thumbnailSize int,
+) *ProductImageUseCase {
+	return &ProductImageUseCase{
+		productRepo:   productRepo,
+		productUC:     productUC,
+		blobStore:     blobStore,
+		maxUploadSize: maxUploadSize,
+		thumbnailSize: thumbnailSize,
+	}
+}
+
+// MaxUploadSize - the largest accepted image in bytes
+func (uc *ProductImageUseCase) MaxUploadSize() int64 {
+	return uc.maxUploadSize
+}
+
+// Upload checks the type and size of the image, stores the original with a thumbnail
+// and appends it to the product's images, next to images uploaded at the same time
+func (uc *ProductImageUseCase) Upload(ctx context.Context, productID bson.ObjectID, data []byte) (*entity.Product, error) {
+	if int64(len(data)) > uc.maxUploadSize {
+		return nil, ErrImageTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImageType
+	}
+
+	if _, err := uc.getProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrInvalidImage
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	thumb, thumbExt, err := encodeThumbnail(thumbnail.Fit(img, uc.thumbnailSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	imageID, err := newImageID()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("products/%s/%s.%s", productID.Hex(), imageID, ext)
+	thumbKey := fmt.Sprintf("products/%s/%s_thumb.%s", productID.Hex(), imageID, thumbExt)
+
+	if err := uc.blobStore.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := uc.blobStore.Put(ctx, thumbKey, bytes.NewReader(thumb)); err != nil {
+		_ = uc.blobStore.Delete(ctx, key)
+		return nil, err
+	}
+
+	product, err := uc.productUC.AddImage(ctx, productID, entity.ProductImage{
+		ID:           imageID,
+		URL:          uc.blobStore.URL(key),
+		ThumbnailURL: uc.blobStore.URL(thumbKey),
+		Key:          key,
+		ThumbnailKey: thumbKey,
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        cfg.Width,
+		Height:       cfg.Height,
+		UploadedAt:   time.Now(),
+	})
+	if err != nil {
+		_ = uc.blobStore.Delete(ctx, key)
+		_ = uc.blobStore.Delete(ctx, thumbKey)
+		return nil, err
+	}
+	return product, nil
+}
+
+// Delete removes the image and its files
+func (uc *ProductImageUseCase) Delete(ctx context.Context, productID bson.ObjectID, imageID string) (*entity.Product, error) {
+	if _, err := uc.getProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	previous, err := uc.productUC.RemoveImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files go last, a failure here leaves orphaned files rather than broken links
+	for _, img := range previous.Images {
+		if img.ID != imageID {
+			continue
+		}
+		if err := uc.blobStore.Delete(ctx, img.Key); err != nil {
+			return nil, err
+		}
+		if err := uc.blobStore.Delete(ctx, img.ThumbnailKey); err != nil {
+			return nil, err
+		}
+	}
+	return uc.getProduct(ctx, productID)
+}
+
+// Reorder puts the images in the given order, the first one becomes the primary image.
+// ErrImagesChanged is returned when images were uploaded or deleted since the order was read
+func (uc *ProductImageUseCase) Reorder(ctx context.Context, productID bson.ObjectID, imageIDs []string) (*entity.Product, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIDs) != len(product.Images) {
+		return nil, ErrInvalidImageOrder
+	}
+	if len(imageIDs) == 0 {
+		return product, nil
+	}
+
+	byID := make(map[string]entity.ProductImage, len(product.Images))
+	for _, img := range product.Images {
+		byID[img.ID] = img
+	}
+
+	images := make([]entity.ProductImage, 0, len(imageIDs))
+	for _, id := range imageIDs {
+		img, ok := byID[id]
+		if !ok {
+			return nil, ErrInvalidImageOrder
+		}
+		delete(byID, id)
+		images = append(images, img)
+	}
+
+	if err := uc.productUC.ReorderImages(ctx, productID, images); err != nil {
+		return nil, err
+	}
+	product.Images = images
+	product.ImageURL = images[0].URL
+	return product, nil
+}
+
+// Open returns a stored file for serving
+func (uc *ProductImageUseCase) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return uc.blobStore.Get(ctx, key)
+}
+
+// getProduct reads the product from the repository, the cached copy doesn't carry the storage keys
+func (uc *ProductImageUseCase) getProduct(ctx context.Context, id bson.ObjectID) (*entity.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+// encodeThumbnail keeps JPEGs as JPEG, PNG and GIF thumbnails are written as PNG to keep transparency
+func encodeThumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "jpg", nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "png", nil
+}
+
+func newImageID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// PriceHistory returns the product price, or the variant price when sku is given, from since until now.
+// Prices set before history was recorded start at the first known price
+func (uc *ProductUseCase) PriceHistory(ctx context.Context, id bson.ObjectID, sku string, since time.Time) (*entity.PriceHistory, error) {
+	product, err := uc.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := currentPrice(product, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := uc.priceRepo.GetByProduct(ctx, id, sku, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []entity.PricePoint
+	start, err := uc.priceRepo.GetLastBefore(ctx, id, sku, since)
+	switch {
+	case err == nil:
+		points = append(points, entity.PricePoint{At: since, Price: start.Price})
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return nil, err
+	case len(changes) == 0:
+		// Unchanged since before history was recorded
+		points = append(points, entity.PricePoint{At: since, Price: current})
+	}
+	for _, change := range changes {
+		points = append(points, entity.PricePoint{At: change.ChangedAt, Price: change.Price})
+	}
+	now := entity.PricePoint{At: time.Now(), Price: current}
+	points = append(points, now)
+
+	history := &entity.PriceHistory{
+		ProductID: id,
+		SKU:       sku,
+		Since:     since,
+		Current:   now,
+		Lowest:    points[0],
+		Highest:   points[0],
+		Points:    points,
+	}
+	for _, p := range points[1:] {
+		if p.Price < history.Lowest.Price {
+			history.Lowest = p
+		}
+		if p.Price > history.Highest.Price {
+			history.Highest = p
+		}
+	}
+	return history, nil
+}
+
+// currentPrice - the price the product or variant sells at now, like the prices in its history
+func currentPrice(product *entity.Product, sku string) (money.Amount, error) {
+	if sku == "" {
+		return product.EffectivePrice(), nil
+	}
+	variant, ok := product.Variant(sku)
+	if !ok {
+		return 0, ErrVariantNotFound
+	}
+	return variant.EffectivePrice(), nil
+}
+
+// recordPrices appends the current prices of the product and its variants that differ from before to the
+// price history, sale prices included; before is nil for new products. First prices have nothing to
+// compare to and are never alerted on
+func (uc *ProductUseCase) recordPrices(ctx context.Context, before, after *entity.Product) error {
+	now := time.Now()
+	var changes []*entity.PriceChange
+	add := func(sku string, price money.Amount, previous *money.Amount) {
+		if previous != nil && *previous == price {
+			return
+		}
+		changes = append(changes, &entity.PriceChange{
+			ProductID:     after.ID,
+			SKU:           sku,
+			Price:         price,
+			PreviousPrice: previous,
+			ChangedAt:     now,
+			Alerted:       previous == nil,
+		})
+	}
+
+	var previous *money.Amount
+	if before != nil {
+		price := currentOrRegular(before.CurrentPrice, before.Price)
+		previous = &price
+	}
+	add("", currentOrRegular(after.CurrentPrice, after.Price), previous)
+
+	for _, variant := range after.Variants {
+		previous = nil
+		if before != nil {
+			if v, ok := before.Variant(variant.SKU); ok {
+				price := currentOrRegular(v.CurrentPrice, v.Price)
+				previous = &price
+			}
+		}
+		add(variant.SKU, currentOrRegular(variant.CurrentPrice, variant.Price), previous)
+	}
+	return uc.priceRepo.Create(ctx, changes)
+}
+
+// currentOrRegular - the current price of a stored product or variant. Products stored before current
+// prices were kept only have the regular one
+func currentOrRegular(current, regular money.Amount) money.Amount {
+	if current > 0 {
+		return current
+	}
+	return regular
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+)
+
+type ProductPurgeUseCase struct {
+	productRepo ProductRepository
+	versionRepo ProductVersionRepository
+	priceRepo   PriceHistoryRepository
+	watchRepo   PriceWatchRepository
+	productUC   *ProductUseCase
+	graphRepo   GraphRepository
+	blobStore   BlobStore
+	retention   time.Duration
+}
+
+func NewProductPurgeUseCase(
+	productRepo ProductRepository,
+	versionRepo ProductVersionRepository,
+	priceRepo PriceHistoryRepository,
+	watchRepo PriceWatchRepository,
+	productUC *ProductUseCase,
+	graphRepo GraphRepository,
+	blobStore BlobStore,
+	retention time.Duration,
+) *ProductPurgeUseCase {
+	return &ProductPurgeUseCase{
+		productRepo: productRepo,
+		versionRepo: versionRepo,
+		priceRepo:   priceRepo,
+		watchRepo:   watchRepo,
+		productUC:   productUC,
+		graphRepo:   graphRepo,
+		blobStore:   blobStore,
+		retention:   retention,
+	}
+}
+
+// Run permanently removes products archived longer than the retention period, returns how many were purged
+func (uc *ProductPurgeUseCase) Run(ctx context.Context) (int, error) {
+	products, err := uc.productRepo.FindArchivedBefore(ctx, time.Now().Add(-uc.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, product := range products {
+		if err := uc.purge(ctx, product); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge removes the graph node with its edges, the image files, the history, price watches and cached entries; the document
+// goes last so a failed purge is retried on the next run
+func (uc *ProductPurgeUseCase) purge(ctx context.Context, product *entity.Product) error {
+	if err := uc.graphRepo.DeleteProduct(ctx, product.ID); err != nil {
+		return err
+	}
+
+	for _, img := range product.Images {
+		if err := uc.blobStore.Delete(ctx, img.Key); err != nil {
+			return err
+		}
+		if err := uc.blobStore.Delete(ctx, img.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+
+	if err := uc.versionRepo.DeleteByProduct(ctx, product.ID); err != nil {
+		return err
+	}
+	if err := uc.priceRepo.DeleteByProduct(ctx, product.ID); err != nil {
+		return err
+	}
+	if err := uc.watchRepo.DeleteByProduct(ctx, product.ID); err != nil {
+		return err
+	}
+	if err := uc.productRepo.Delete(ctx, product.ID); err != nil {
+		return err
+	}
+	uc.productUC.invalidate(ctx, product.ID)
+	return nil
+}
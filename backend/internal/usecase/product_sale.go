@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// maxSaleWindows - scheduled and running sales a product or category may have
+	maxSaleWindows = 20
+	// maxOnSaleProducts - the most products the on-sale listing returns
+	maxOnSaleProducts = 100
+)
+
+var (
+	ErrInvalidSale  = errors.New("invalid sale")
+	ErrSaleNotFound = errors.New("sale not found")
+)
+
+// AddSale schedules a sale on the product. Sales that have ended are dropped at the same time
+func (uc *ProductUseCase) AddSale(ctx context.Context, id bson.ObjectID, sale *entity.SaleWindow) (*entity.Product, error) {
+	product, err := uc.getStored(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sale.Price != nil {
+		if len(product.Variants) > 0 {
+			return nil, fmt.Errorf("%w: products with variants take percentOff only", ErrInvalidSale)
+		}
+		if *sale.Price >= product.Price {
+			return nil, fmt.Errorf("%w: sale price must be below the regular price", ErrInvalidSale)
+		}
+	}
+	sales, err := addSaleWindow(product.Sales, sale)
+	if err != nil {
+		return nil, err
+	}
+	return uc.setSales(ctx, product, sales)
+}
+
+// DeleteSale cancels a scheduled sale or ends a running one
+func (uc *ProductUseCase) DeleteSale(ctx context.Context, id, saleID bson.ObjectID) (*entity.Product, error) {
+	product, err := uc.getStored(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := deleteSaleWindow(product.Sales, saleID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.setSales(ctx, product, sales)
+}
+
+func (uc *ProductUseCase) setSales(ctx context.Context, product *entity.Product, sales []entity.SaleWindow) (*entity.Product, error) {
+	if err := uc.repo.SetSales(ctx, product.ID, sales); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	product.Sales = sales
+
+	uc.invalidate(ctx, product.ID)
+	if err := uc.ApplySales(ctx, product); err != nil {
+		return nil, err
+	}
+	repriced, err := uc.refreshPrices(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	// The search index keeps its own copy of the sales, repriced products have been reindexed
+	if !repriced {
+		if err := uc.indexProduct(ctx, product); err != nil {
+			return nil, err
+		}
+	}
+	return product, nil
+}
+
+// RefreshPrices is the scheduled job that follows sales as they start and end. Products whose effective
+// prices moved away from their current prices get them stored, so that search filters and sorts on
+// them, and recorded in the price history, where price watches pick up the drops. Returns the number
+// of repriced products
+func (uc *ProductUseCase) RefreshPrices(ctx context.Context) (int, error) {
+	now := time.Now()
+	categorySales, err := uc.categorySales(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	categories := make([]string, 0, len(categorySales))
+	for name := range categorySales {
+		categories = append(categories, name)
+	}
+
+	repriced := 0
+	err = uc.repo.ForEachRepriceCandidate(ctx, now, categories, func(p *entity.Product) error {
+		applySales(p, categorySales, now)
+		changed, err := uc.refreshPrices(ctx, p)
+		if changed {
+			repriced++
+		}
+		return err
+	})
+	return repriced, err
+}
+
+// refreshPrices stores the effective prices of a stored product, its sales applied, as its current
+// prices when they differ, records them and reindexes the product. A product whose price was edited
+// meanwhile is skipped, the edit stored its own current prices. Reports whether prices were stored
+func (uc *ProductUseCase) refreshPrices(ctx context.Context, product *entity.Product) (bool, error) {
+	before := *product
+	before.Variants = slices.Clone(product.Variants)
+	if !product.SetCurrentPrices() {
+		return false, nil
+	}
+	if err := uc.repo.SetCurrentPrices(ctx, product); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	uc.invalidate(ctx, product.ID)
+	if err := uc.indexProduct(ctx, product); err != nil {
+		return false, err
+	}
+	return true, uc.recordPrices(ctx, &before, product)
+}
+
+// ApplySales sets the sale prices of the products from their own sales and the sales of their
+// categories and ancestor categories that are in effect now
+func (uc *ProductUseCase) ApplySales(ctx context.Context, products ...*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	now := time.Now()
+	categorySales, err := uc.categorySales(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		applySales(p, categorySales, now)
+	}
+	return nil
+}
+
+// applySales sets the sale prices of the product from its own sales and those of its category at t
+func applySales(p *entity.Product, categorySales map[string][]entity.SaleWindow, t time.Time) {
+	windows := p.Sales
+	if inherited := categorySales[p.Category]; len(inherited) > 0 {
+		windows = append(slices.Clone(p.Sales), inherited...)
+	}
+	p.ApplySales(windows, t)
+}
+
+// categorySales maps category names to the sales in effect at t on the category or its ancestors.
+// The whole tree is only loaded while some category is on sale
+func (uc *ProductUseCase) categorySales(ctx context.Context, t time.Time) (map[string][]entity.SaleWindow, error) {
+	onSale, err := uc.categoryRepo.FindWithActiveSales(ctx, t)
+	if err != nil || len(onSale) == 0 {
+		return nil, err
+	}
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	salesByID := make(map[bson.ObjectID][]entity.SaleWindow, len(onSale))
+	for _, c := range onSale {
+		salesByID[c.ID] = c.Sales
+	}
+	sales := make(map[string][]entity.SaleWindow)
+	for _, c := range categories {
+		for _, id := range append(slices.Clone(c.Ancestors), c.ID) {
+			sales[c.Name] = append(sales[c.Name], salesByID[id]...)
+		}
+		if len(sales[c.Name]) == 0 {
+			delete(sales, c.Name)
+		}
+	}
+	return sales, nil
+}
+
+// OnSale lists up to limit products selling below their regular price now, the sales ending soonest first
+func (uc *ProductUseCase) OnSale(ctx context.Context, limit int) ([]*entity.Product, error) {
+	if limit <= 0 || limit > maxOnSaleProducts {
+		limit = maxOnSaleProducts
+	}
+	now := time.Now()
+	categorySales, err := uc.categorySales(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	categories := make([]string, 0, len(categorySales))
+	for name := range categorySales {
+		categories = append(categories, name)
+	}
+
+	products, err := uc.repo.FindOnSale(ctx, now, categories, limit)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.ApplySales(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	// Sales above the regular price don't lower it
+	products = slices.DeleteFunc(products, func(p *entity.Product) bool { return p.SalePrice == nil })
+	slices.SortStableFunc(products, func(a, b *entity.Product) int {
+		return a.SaleEndsAt.Compare(*b.SaleEndsAt)
+	})
+	return products, nil
+}
+
+// addSaleWindow validates the sale and adds it to the windows that haven't ended yet
+func addSaleWindow(windows []entity.SaleWindow, sale *entity.SaleWindow) ([]entity.SaleWindow, error) {
+	sale.Name = strings.TrimSpace(sale.Name)
+	switch {
+	case sale.StartsAt.IsZero() || sale.EndsAt.IsZero():
+		return nil, fmt.Errorf("%w: startsAt and endsAt are required", ErrInvalidSale)
+	case !sale.EndsAt.After(sale.StartsAt):
+		return nil, fmt.Errorf("%w: endsAt must be after startsAt", ErrInvalidSale)
+	case !sale.EndsAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: the sale has already ended", ErrInvalidSale)
+	case (sale.Price == nil) == (sale.PercentOff == 0):
+		return nil, fmt.Errorf("%w: give either price or percentOff", ErrInvalidSale)
+	case sale.Price != nil && *sale.Price <= 0:
+		return nil, fmt.Errorf("%w: price must be positive", ErrInvalidSale)
+	case sale.PercentOff < 0 || sale.PercentOff >= 100:
+		return nil, fmt.Errorf("%w: percentOff must be between 0 and 100", ErrInvalidSale)
+	}
+
+	now := time.Now()
+	kept := slices.DeleteFunc(slices.Clone(windows), func(w entity.SaleWindow) bool {
+		return !w.EndsAt.After(now)
+	})
+	if len(kept) >= maxSaleWindows {
+		return nil, fmt.Errorf("%w: at most %d sales may be scheduled", ErrInvalidSale, maxSaleWindows)
+	}
+
+	sale.ID = bson.NewObjectID()
+	return append(kept, *sale), nil
+}
+
+func deleteSaleWindow(windows []entity.SaleWindow, id bson.ObjectID) ([]entity.SaleWindow, error) {
+	i := slices.IndexFunc(windows, func(w entity.SaleWindow) bool { return w.ID == id })
+	if i < 0 {
+		return nil, ErrSaleNotFound
+	}
+	return slices.Delete(slices.Clone(windows), i, i+1), nil
+}
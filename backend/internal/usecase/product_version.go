@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// versionedFields - product fields compared between versions, named as in the API.
+// Ratings, images and timestamps change outside of admin edits and are left out
+var versionedFields = []string{
+	"externalSku", "name", "description", "category", "price", "imageUrl",
+	"stock", "tags", "attributes", "options", "variants", "archivedAt",
+}
+
+var ErrProductVersionNotFound = errors.New("product version not found")
+
+// History pages through the recorded changes of a product, newest first. History starts with
+// the first change made after it was introduced, products created earlier have no baseline version
+func (uc *ProductUseCase) History(ctx context.Context, id bson.ObjectID, page entity.PageRequest) ([]*entity.ProductVersion, entity.PageInfo, error) {
+	if _, err := uc.getStored(ctx, id); err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+	return uc.versionRepo.GetByProduct(ctx, id, page)
+}
+
+func (uc *ProductUseCase) GetVersion(ctx context.Context, id bson.ObjectID, number int) (*entity.ProductVersion, error) {
+	version, err := uc.versionRepo.GetByNumber(ctx, id, number)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductVersionNotFound
+	}
+	return version, err
+}
+
+// Rollback restores the editable fields of the product as they were in the given version.
+// The rollback is recorded as a new version, archived products stay archived
+func (uc *ProductUseCase) Rollback(ctx context.Context, userID, id bson.ObjectID, number int) (*entity.Product, error) {
+	version, err := uc.GetVersion(ctx, id, number)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := version.Snapshot
+	product := &entity.Product{
+		ID:          id,
+		ExternalSKU: snapshot.ExternalSKU,
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		Category:    snapshot.Category,
+		Price:       snapshot.Price,
+		ImageURL:    snapshot.ImageURL,
+		Stock:       snapshot.Stock,
+		Tags:        snapshot.Tags,
+		Attributes:  snapshot.Attributes,
+		Options:     snapshot.Options,
+		Variants:    snapshot.Variants,
+	}
+	if err := uc.update(ctx, userID, entity.ProductRolledBack, product, number); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// recordVersion appends a version with the diff between before and after; before is nil for new products.
+// Updates and imports that change nothing are not recorded
+func (uc *ProductUseCase) recordVersion(
+	ctx context.Context,
+	userID bson.ObjectID,
+	change entity.ProductChange,
+	before, after *entity.Product,
+	rolledBackTo int,
+) error {
+	diff, err := diffProducts(before, after)
+	if err != nil {
+		return err
+	}
+	if len(diff) == 0 && (change == entity.ProductUpdated || change == entity.ProductImported) {
+		return nil
+	}
+
+	latest, err := uc.versionRepo.GetLatestNumber(ctx, after.ID)
+	if err != nil {
+		return err
+	}
+
+	snapshot := *after
+	return uc.versionRepo.Create(ctx, &entity.ProductVersion{
+		ProductID:    after.ID,
+		Version:      latest + 1,
+		Change:       change,
+		UserID:       userID,
+		Diff:         diff,
+		RolledBackTo: rolledBackTo,
+		Snapshot:     &snapshot,
+	})
+}
+
+// diffProducts compares the versioned fields through their JSON form, so the diff reads like the API
+func diffProducts(before, after *entity.Product) ([]entity.FieldChange, error) {
+	old, err := productFields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := productFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := []entity.FieldChange{}
+	for _, field := range versionedFields {
+		if bytes.Equal(old[field], updated[field]) {
+			continue
+		}
+		change := entity.FieldChange{Field: field}
+		if err := decodeField(old[field], &change.Old); err != nil {
+			return nil, err
+		}
+		if err := decodeField(updated[field], &change.New); err != nil {
+			return nil, err
+		}
+		diff = append(diff, change)
+	}
+	return diff, nil
+}
+
+func productFields(product *entity.Product) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if product == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(product)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// decodeField leaves v nil for fields that are absent (omitted empty values)
+func decodeField(raw json.RawMessage, v *interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -3,17 +3,30 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 
 	"github.com/m4rk1sov/ecommerce/internal/entity"
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// siblingCategoryWeight - share of a category's score given to its sibling categories
+	siblingCategoryWeight = 0.5
+	// attributeSimilarityWeight - score a content-based candidate gains when all its attribute values
+	// match the ones the user interacted with
+	attributeSimilarityWeight = 0.3
 )
 
 type RecommendationUseCase struct {
 	userRepo        UserRepository
 	productRepo     ProductRepository
+	products        ProductLookup
+	categoryRepo    CategoryRepository
 	interactionRepo InteractionRepository
 	cacheRepo       CacheRepository
 	graphRepo       GraphRepository
@@ -24,6 +37,8 @@ type RecommendationUseCase struct {
 func NewRecommendationUseCase(
 	userRepo UserRepository,
 	productRepo ProductRepository,
+	products ProductLookup,
+	categoryRepo CategoryRepository,
 	interactionRepo InteractionRepository,
 	cacheRepo CacheRepository,
 	graphRepo GraphRepository,
@@ -32,6 +47,8 @@ func NewRecommendationUseCase(
 	return &RecommendationUseCase{
 		userRepo:        userRepo,
 		productRepo:     productRepo,
+		products:        products,
+		categoryRepo:    categoryRepo,
 		interactionRepo: interactionRepo,
 		cacheRepo:       cacheRepo,
 		graphRepo:       graphRepo,
@@ -47,11 +64,11 @@ func (uc *RecommendationUseCase) GetPersonalizedRecommendations(
 	limit int,
 ) (*entity.Recommendation, error) {
 	// Step 1: Check cache first (Redis)
-	cacheKey := fmt.Sprintf("rec:user:%s", userID.Hex())
+	cacheKey := userRecCacheKey(userID)
 	if cached, err := uc.cacheRepo.Get(ctx, cacheKey); err == nil {
 		var rec entity.Recommendation
 		if json.Unmarshal([]byte(cached), &rec) == nil {
-			return &rec, nil
+			return &rec, uc.applyPrices(ctx, userID, &rec)
 		}
 	}
 
@@ -75,12 +92,50 @@ func (uc *RecommendationUseCase) GetPersonalizedRecommendations(
 		return nil, err
 	}
 
-	// Step 3: Cache result (Redis)
+	// Step 3: Cache result (Redis), remembering the products it contains for invalidation
 	if data, err := json.Marshal(recommendation); err == nil {
-		err = uc.cacheRepo.Set(ctx, cacheKey, string(data), uc.cacheTTL)
+		if err = uc.cacheRepo.Set(ctx, cacheKey, string(data), uc.cacheTTL); err == nil {
+			for _, rec := range recommendation.Products {
+				_ = uc.cacheRepo.AddToSet(ctx, productUserRecRefsKey(rec.Product.ID), uc.cacheTTL, userID.Hex())
+			}
+		}
+	}
+
+	return recommendation, uc.applyPrices(ctx, userID, recommendation)
+}
+
+// applyPrices sets the sale prices in effect and drops the products the user's price range excludes
+// at those prices. Sales start and end while recommendations are cached, so this runs on every read
+func (uc *RecommendationUseCase) applyPrices(ctx context.Context, userID bson.ObjectID, rec *entity.Recommendation) error {
+	products := make([]*entity.Product, len(rec.Products))
+	for i := range rec.Products {
+		products[i] = &rec.Products[i].Product
+	}
+	if err := uc.products.ApplySales(ctx, products...); err != nil {
+		return err
 	}
 
-	return recommendation, nil
+	priceRange, err := uc.priceRange(ctx, userID)
+	if err != nil {
+		return err
+	}
+	rec.Products = slices.DeleteFunc(rec.Products, func(p entity.RecommendedProduct) bool {
+		return !priceRange.Contains(p.Product.EffectivePrice())
+	})
+	rec.Score = uc.calculateRecommendationScore(rec.Products)
+	return nil
+}
+
+// priceRange returns the user's preferred price range, an open one when the user is gone
+func (uc *RecommendationUseCase) priceRange(ctx context.Context, userID bson.ObjectID) (entity.PriceRange, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return entity.PriceRange{}, nil
+	}
+	if err != nil {
+		return entity.PriceRange{}, err
+	}
+	return user.Preferences.PriceRange, nil
 }
 
 // GetCollaborativeRecommendations - User-based collaborative filtering using Neo4j
@@ -95,11 +150,16 @@ func (uc *RecommendationUseCase) GetCollaborativeRecommendations(
 		return nil, err
 	}
 
-	// Fetch product details from MongoDB
+	priceRange, err := uc.priceRange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch product details through the product cache, it prices them with the sales in effect
 	var recommendedProducts []entity.RecommendedProduct
 	for i, productID := range productIDs {
-		product, err := uc.productRepo.GetByID(ctx, productID)
-		if err != nil {
+		product, err := uc.products.GetByID(ctx, productID)
+		if err != nil || !priceRange.Contains(product.EffectivePrice()) {
 			continue
 		}
 
@@ -131,20 +191,25 @@ func (uc *RecommendationUseCase) GetContentBasedRecommendations(
 		return nil, err
 	}
 
-	// Build user profile (categories and preferences)
+	// Build user profile (categories, attribute values and preferences)
 	categoryScores := make(map[string]float64)
 	interactedProducts := make(map[string]bool)
+	attributes := make(attributeProfile)
 
 	for _, interaction := range interactions {
-		product, err := uc.productRepo.GetByID(ctx, interaction.ProductID)
+		product, err := uc.products.GetByID(ctx, interaction.ProductID)
 		if err != nil {
 			continue
 		}
 
 		categoryScores[product.Category] += interaction.Weight
 		interactedProducts[interaction.ProductID.Hex()] = true
+		attributes.add(product.Attributes, interaction.Weight)
 	}
 
+	// Interest in a category carries over to its siblings at a lower score
+	siblingOf := uc.addSiblingCategoryScores(ctx, categoryScores)
+
 	// Find top categories
 	var topCategories []string
 	for category := range categoryScores {
@@ -168,27 +233,45 @@ func (uc *RecommendationUseCase) GetContentBasedRecommendations(
 		allProducts = append(allProducts, products...)
 	}
 
-	// Filter out already interacted products and score remaining
+	if err := uc.products.ApplySales(ctx, allProducts...); err != nil {
+		return nil, err
+	}
+	priceRange, err := uc.priceRange(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter out already interacted products and products outside the user's price range, score remaining
 	var recommendedProducts []entity.RecommendedProduct
 	for _, product := range allProducts {
-		if interactedProducts[product.ID.Hex()] {
+		if interactedProducts[product.ID.Hex()] || !priceRange.Contains(product.EffectivePrice()) {
 			continue
 		}
 
-		score := categoryScores[product.Category] / 100.0
-		if score > 1.0 {
-			score = 1.0
+		// Products sharing attribute values with the ones the user interacted with rank higher
+		similarity := attributes.similarity(product.Attributes)
+		score := math.Min(categoryScores[product.Category]/100.0+attributeSimilarityWeight*similarity, 1.0)
+
+		reason := fmt.Sprintf("Based on your interest in %s", product.Category)
+		if source, ok := siblingOf[product.Category]; ok {
+			reason = fmt.Sprintf("Similar to your interest in %s", source)
+		}
+		if similarity >= 0.5 {
+			reason = fmt.Sprintf("Specifications like the %s you were interested in", product.Category)
 		}
 
 		recommendedProducts = append(recommendedProducts, entity.RecommendedProduct{
 			Product: *product,
 			Score:   score,
-			Reason:  fmt.Sprintf("Based on your interest in %s", product.Category),
+			Reason:  reason,
 		})
+	}
 
-		if len(recommendedProducts) >= limit {
-			break
-		}
+	sort.SliceStable(recommendedProducts, func(i, j int) bool {
+		return recommendedProducts[i].Score > recommendedProducts[j].Score
+	})
+	if len(recommendedProducts) > limit {
+		recommendedProducts = recommendedProducts[:limit]
 	}
 
 	return &entity.Recommendation{
@@ -199,6 +282,87 @@ func (uc *RecommendationUseCase) GetContentBasedRecommendations(
 	}, nil
 }
 
+// attributeProfile - interaction weight per attribute key and formatted value
+type attributeProfile map[string]map[string]float64
+
+func (p attributeProfile) add(values entity.AttributeValues, weight float64) {
+	for key, v := range values {
+		if p[key] == nil {
+			p[key] = make(map[string]float64)
+		}
+		p[key][entity.FormatAttributeValue(v)] += weight
+	}
+}
+
+// similarity - over the attributes the profile knows, the average share of weight the product's values have;
+// 0 when they have none in common
+func (p attributeProfile) similarity(values entity.AttributeValues) float64 {
+	var sum float64
+	var keys int
+	for key, v := range values {
+		weights, ok := p[key]
+		if !ok {
+			continue
+		}
+		var total float64
+		for _, w := range weights {
+			total += w
+		}
+		if total > 0 {
+			sum += weights[entity.FormatAttributeValue(v)] / total
+			keys++
+		}
+	}
+	if keys == 0 {
+		return 0
+	}
+	return sum / float64(keys)
+}
+
+// addSiblingCategoryScores gives categories the user hasn't interacted with a share of their
+// best scoring sibling's score. Returns the sibling each added category was derived from
+func (uc *RecommendationUseCase) addSiblingCategoryScores(ctx context.Context, categoryScores map[string]float64) map[string]string {
+	siblingOf := make(map[string]string)
+
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return siblingOf
+	}
+
+	byParent := make(map[bson.ObjectID][]string)
+	parentOf := make(map[string]bson.ObjectID)
+	for _, c := range categories {
+		// Root categories aren't siblings of each other, they are too far apart
+		if c.ParentID == nil {
+			continue
+		}
+		byParent[*c.ParentID] = append(byParent[*c.ParentID], c.Name)
+		parentOf[c.Name] = *c.ParentID
+	}
+
+	inferred := make(map[string]float64)
+	for category, score := range categoryScores {
+		parent, ok := parentOf[category]
+		if !ok {
+			continue
+		}
+		for _, sibling := range byParent[parent] {
+			if _, direct := categoryScores[sibling]; direct {
+				continue
+			}
+			if s := score * siblingCategoryWeight; s > inferred[sibling] {
+				inferred[sibling] = s
+				siblingOf[sibling] = category
+			}
+		}
+	}
+
+	for category, score := range inferred {
+		categoryScores[category] = score
+	}
+	return siblingOf
+}
+
 // GetHybridRecommendations - Combines collaborative and content-based
 func (uc *RecommendationUseCase) GetHybridRecommendations(
 	ctx context.Context,
@@ -265,11 +429,11 @@ func (uc *RecommendationUseCase) GetProductRecommendations(
 	limit int,
 ) ([]*entity.Product, error) {
 	// Check cache first
-	cacheKey := fmt.Sprintf("rec:product:%s", productID.Hex())
+	cacheKey := productRecCacheKey(productID)
 	if cached, err := uc.cacheRepo.Get(ctx, cacheKey); err == nil {
 		var products []*entity.Product
 		if json.Unmarshal([]byte(cached), &products) == nil {
-			return products, nil
+			return products, uc.products.ApplySales(ctx, products...)
 		}
 	}
 
@@ -279,19 +443,23 @@ func (uc *RecommendationUseCase) GetProductRecommendations(
 		return nil, err
 	}
 
-	// Fetch product details from MongoDB
+	// Fetch product details through the product cache
 	var products []*entity.Product
 	for _, id := range productIDs {
-		product, err := uc.productRepo.GetByID(ctx, id)
+		product, err := uc.products.GetByID(ctx, id)
 		if err != nil {
 			continue
 		}
 		products = append(products, product)
 	}
 
-	// Cache result
+	// Cache result, and remember which cached lists each product appears in for invalidation
 	if data, err := json.Marshal(products); err == nil {
-		err = uc.cacheRepo.Set(ctx, cacheKey, string(data), uc.cacheTTL)
+		if err = uc.cacheRepo.Set(ctx, cacheKey, string(data), uc.cacheTTL); err == nil {
+			for _, product := range products {
+				_ = uc.cacheRepo.AddToSet(ctx, productRecRefsKey(product.ID), uc.cacheTTL, productID.Hex())
+			}
+		}
 	}
 
 	return products, nil
@@ -340,6 +508,5 @@ func (uc *RecommendationUseCase) calculateRecommendationScore(products []entity.
 
 // InvalidateUserCache - Call this when user makes new interactions
 func (uc *RecommendationUseCase) InvalidateUserCache(ctx context.Context, userID bson.ObjectID) error {
-	cacheKey := fmt.Sprintf("rec:user:%s", userID.Hex())
-	return uc.cacheRepo.Delete(ctx, cacheKey)
+	return uc.cacheRepo.Delete(ctx, userRecCacheKey(userID))
 }
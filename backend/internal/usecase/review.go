@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	minReviewRating = 1
+	maxReviewRating = 5
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("product already reviewed, edit the existing review")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrInvalidReviewSort   = errors.New("invalid sort, use newest or helpful")
+	ErrOwnReviewVote       = errors.New("cannot vote on own review")
+	ErrHelpfulVoteNotFound = errors.New("review was not voted helpful")
+)
+
+type ReviewUseCase struct {
+	repo            ReviewRepository
+	productUC       *ProductUseCase
+	interactionRepo InteractionRepository
+	interactionUC   *InteractionUseCase
+}
+
+func NewReviewUseCase(
+	repo ReviewRepository,
+	productUC *ProductUseCase,
+	interactionRepo InteractionRepository,
+	interactionUC *InteractionUseCase,
+) *ReviewUseCase {
+	return &ReviewUseCase{
+		repo:            repo,
+		productUC:       productUC,
+		interactionRepo: interactionRepo,
+		interactionUC:   interactionUC,
+	}
+}
+
+// Create adds the user's review of a product, marks it verified when the user bought the product
+// and records it as a review interaction
+func (uc *ReviewUseCase) Create(ctx context.Context, review *entity.Review) error {
+	if err := validateReview(review); err != nil {
+		return err
+	}
+
+	if _, err := uc.productUC.GetByID(ctx, review.ProductID); err != nil {
+		return err
+	}
+
+	verified, err := uc.hasPurchased(ctx, review.UserID, review.ProductID)
+	if err != nil {
+		return err
+	}
+	review.VerifiedPurchase = verified
+	review.HelpfulCount = 0
+	review.HelpfulVoters = nil
+
+	if err := uc.repo.Create(ctx, review); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrReviewExists
+		}
+		return err
+	}
+
+	if err := uc.refreshRating(ctx, review.ProductID); err != nil {
+		return err
+	}
+	return uc.interactionUC.RecordReview(ctx, review.UserID, review.ProductID, review.Rating)
+}
+
+// Update edits the rating, title and body of the user's own review
+func (uc *ReviewUseCase) Update(ctx context.Context, userID, reviewID bson.ObjectID, rating int, title, body string) (*entity.Review, error) {
+	review, err := uc.getOwned(ctx, userID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Rating = rating
+	review.Title = title
+	review.Body = body
+	if err := validateReview(review); err != nil {
+		return nil, err
+	}
+
+	// A purchase made after writing the review verifies it
+	if !review.VerifiedPurchase {
+		if review.VerifiedPurchase, err = uc.hasPurchased(ctx, userID, review.ProductID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.repo.Update(ctx, review); err != nil {
+		return nil, err
+	}
+	if err := uc.refreshRating(ctx, review.ProductID); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (uc *ReviewUseCase) Delete(ctx context.Context, userID, reviewID bson.ObjectID) error {
+	review, err := uc.getOwned(ctx, userID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.Delete(ctx, review.ID); err != nil {
+		return err
+	}
+	return uc.refreshRating(ctx, review.ProductID)
+}
+
+// GetProductReviews lists reviews of a product, newest first or most helpful first
+func (uc *ReviewUseCase) GetProductReviews(
+	ctx context.Context,
+	productID bson.ObjectID,
+	sort entity.ReviewSort,
+	page entity.PageRequest,
+) ([]*entity.Review, entity.PageInfo, error) {
+	switch sort {
+	case "":
+		sort = entity.ReviewSortNewest
+	case entity.ReviewSortNewest, entity.ReviewSortHelpful:
+	default:
+		return nil, entity.PageInfo{}, ErrInvalidReviewSort
+	}
+
+	return uc.repo.GetByProduct(ctx, productID, sort, page)
+}
+
+// VoteHelpful marks the review helpful for the user, repeated votes are ignored
+func (uc *ReviewUseCase) VoteHelpful(ctx context.Context, userID, reviewID bson.ObjectID) (*entity.Review, error) {
+	review, err := uc.get(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID == userID {
+		return nil, ErrOwnReviewVote
+	}
+
+	if _, err := uc.repo.AddHelpfulVote(ctx, reviewID, userID); err != nil {
+		return nil, err
+	}
+	return uc.get(ctx, reviewID)
+}
+
+func (uc *ReviewUseCase) RemoveHelpfulVote(ctx context.Context, userID, reviewID bson.ObjectID) (*entity.Review, error) {
+	if _, err := uc.get(ctx, reviewID); err != nil {
+		return nil, err
+	}
+
+	removed, err := uc.repo.RemoveHelpfulVote(ctx, reviewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrHelpfulVoteNotFound
+	}
+	return uc.get(ctx, reviewID)
+}
+
+// refreshRating recomputes Rating and ReviewCount of the product from all its reviews
+func (uc *ReviewUseCase) refreshRating(ctx context.Context, productID bson.ObjectID) error {
+	summary, err := uc.repo.GetRatingSummary(ctx, productID)
+	if err != nil {
+		return err
+	}
+	return uc.productUC.UpdateRating(ctx, productID, summary)
+}
+
+func (uc *ReviewUseCase) hasPurchased(ctx context.Context, userID, productID bson.ObjectID) (bool, error) {
+	_, err := uc.interactionRepo.FindPurchaseContaining(ctx, userID, []bson.ObjectID{productID}, time.Time{})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (uc *ReviewUseCase) get(ctx context.Context, reviewID bson.ObjectID) (*entity.Review, error) {
+	review, err := uc.repo.GetByID(ctx, reviewID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReviewNotFound
+	}
+	return review, err
+}
+
+func (uc *ReviewUseCase) getOwned(ctx context.Context, userID, reviewID bson.ObjectID) (*entity.Review, error) {
+	review, err := uc.get(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+func validateReview(review *entity.Review) error {
+	if review.Rating < minReviewRating || review.Rating > maxReviewRating {
+		return ErrInvalidRating
+	}
+	review.Title = strings.TrimSpace(review.Title)
+	review.Body = strings.TrimSpace(review.Body)
+	return nil
+}
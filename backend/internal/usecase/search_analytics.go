@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	defaultSearchReportLimit = 20
+	maxSearchReportLimit     = 100
+)
+
+var (
+	ErrSearchNotFound           = errors.New("search not found")
+	ErrInvalidClickPosition     = errors.New("position must be between 1 and the number of returned results")
+	ErrProductNotInSearch       = errors.New("product wasn't at that position of the search results")
+	ErrInvalidSearchReportLimit = errors.New("limit must be between 1 and 100")
+)
+
+// SearchAnalyticsUseCase logs searches and result clicks and reports on them
+type SearchAnalyticsUseCase struct {
+	logRepo       SearchLogRepository
+	interactionUC *InteractionUseCase
+	products      ProductLookup
+}
+
+func NewSearchAnalyticsUseCase(logRepo SearchLogRepository, interactionUC *InteractionUseCase, products ProductLookup) *SearchAnalyticsUseCase {
+	return &SearchAnalyticsUseCase{
+		logRepo:       logRepo,
+		interactionUC: interactionUC,
+		products:      products,
+	}
+}
+
+// Record logs a search. Searches without a query (plain filtering) aren't logged and keep a nil ID
+func (uc *SearchAnalyticsUseCase) Record(ctx context.Context, log *entity.SearchLog) error {
+	log.Query = normalizeQuery(log.Query)
+	if log.Query == "" {
+		return nil
+	}
+	return uc.logRepo.Create(ctx, log)
+}
+
+// RecordClick records a click on the result at position (from 1) of a search logged for the same
+// user or guest. Every page is logged as its own search, so position counts within that page and the
+// product must be the active one returned there; a position is counted once, clicking it again records nothing
+func (uc *SearchAnalyticsUseCase) RecordClick(
+	ctx context.Context,
+	userID, guestID, searchID, productID bson.ObjectID,
+	position int,
+) error {
+	search, err := uc.logRepo.GetByID(ctx, searchID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrSearchNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// Anonymous searches and searches of someone else look the same as missing ones
+	if (search.UserID.IsZero() && search.GuestID.IsZero()) || search.UserID != userID || search.GuestID != guestID {
+		return ErrSearchNotFound
+	}
+	if position < 1 || position > len(search.ProductIDs) {
+		return ErrInvalidClickPosition
+	}
+	if search.ProductIDs[position-1] != productID {
+		return ErrProductNotInSearch
+	}
+	if _, err := uc.products.GetByID(ctx, productID); err != nil {
+		return err
+	}
+
+	counted, err := uc.logRepo.AddClick(ctx, searchID, position)
+	if err != nil || !counted {
+		return err
+	}
+	return uc.interactionUC.RecordSearchClick(ctx, userID, guestID, productID, search, position)
+}
+
+func (uc *SearchAnalyticsUseCase) GetStats(ctx context.Context, since time.Time) (*entity.SearchStats, error) {
+	return uc.logRepo.GetStats(ctx, since)
+}
+
+// TopQueries lists the most searched queries, with their click-through rates
+func (uc *SearchAnalyticsUseCase) TopQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error) {
+	limit, err := searchReportLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	return uc.logRepo.TopQueries(ctx, since, limit)
+}
+
+// ZeroResultQueries lists the most searched queries that found nothing, i.e. the gaps of the catalog
+func (uc *SearchAnalyticsUseCase) ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]*entity.SearchQueryStats, error) {
+	limit, err := searchReportLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	return uc.logRepo.ZeroResultQueries(ctx, since, limit)
+}
+
+func searchReportLimit(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return defaultSearchReportLimit, nil
+	case limit < 1 || limit > maxSearchReportLimit:
+		return 0, ErrInvalidSearchReportLimit
+	}
+	return limit, nil
+}
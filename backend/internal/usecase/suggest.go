@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+)
+
+const (
+	defaultSuggestLimit = 8
+	maxSuggestLimit     = 20
+	// suggestScanFactor - candidates read per requested suggestion, a name matched through several
+	// of its words comes back once per word
+	suggestScanFactor = 4
+
+	minSuggestPrefixLength = 1
+	minLoggedQueryLength   = 2
+	maxLoggedQueryLength   = 64
+	// maxSuggestTermWords - names are also completed from their later words, up to this many
+	maxSuggestTermWords = 6
+)
+
+var ErrInvalidSuggestLimit = errors.New("limit must be between 1 and 20")
+
+type SuggestUseCase struct {
+	repo             SuggestRepository
+	productRepo      ProductRepository
+	categoryRepo     CategoryRepository
+	interactionRepo  InteractionRepository
+	popularityWindow time.Duration
+	maxQueries       int
+}
+
+func NewSuggestUseCase(
+	repo SuggestRepository,
+	productRepo ProductRepository,
+	categoryRepo CategoryRepository,
+	interactionRepo InteractionRepository,
+	popularityWindow time.Duration,
+	maxQueries int,
+) *SuggestUseCase {
+	return &SuggestUseCase{
+		repo:             repo,
+		productRepo:      productRepo,
+		categoryRepo:     categoryRepo,
+		interactionRepo:  interactionRepo,
+		popularityWindow: popularityWindow,
+		maxQueries:       maxQueries,
+	}
+}
+
+// Suggest completes the prefix with product names, categories and past queries, most popular first.
+// Names match from the start of any of their words
+func (uc *SuggestUseCase) Suggest(ctx context.Context, prefix string, limit int) ([]entity.Suggestion, error) {
+	if limit == 0 {
+		limit = defaultSuggestLimit
+	}
+	if limit < 1 || limit > maxSuggestLimit {
+		return nil, ErrInvalidSuggestLimit
+	}
+
+	prefix = normalizeQuery(prefix)
+	if utf8.RuneCountInString(prefix) < minSuggestPrefixLength {
+		return []entity.Suggestion{}, nil
+	}
+
+	candidates, err := uc.repo.FindByPrefix(ctx, prefix, limit*suggestScanFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	// A name matched through several of its words shows up once
+	suggestions := []entity.Suggestion{}
+	seen := make(map[entity.Suggestion]bool)
+	for _, s := range candidates {
+		key := s
+		key.Score = 0
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, s)
+		if len(suggestions) == limit {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// Rebuild reindexes product names and categories. Products score by their interaction weight over
+// the popularity window, categories by the sum of their products. Rarely searched queries are dropped
+func (uc *SuggestUseCase) Rebuild(ctx context.Context) (int, error) {
+	popularity, err := uc.interactionRepo.GetProductPopularity(ctx, time.Now().Add(-uc.popularityWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	var terms []entity.SuggestionTerm
+	categoryScores := make(map[string]float64)
+	err = uc.productRepo.ForEach(ctx, func(p *entity.Product) error {
+		if p.ArchivedAt != nil {
+			return nil
+		}
+		score := 1 + popularity[p.ID]
+		categoryScores[p.Category] += score
+		terms = appendSuggestionTerms(terms, p.Name, entity.Suggestion{
+			Text:      p.Name,
+			Type:      entity.SuggestionProduct,
+			ProductID: p.ID.Hex(),
+			Score:     score,
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range categories {
+		terms = appendSuggestionTerms(terms, c.Name, entity.Suggestion{
+			Text:         c.Name,
+			Type:         entity.SuggestionCategory,
+			CategorySlug: c.Slug,
+			Score:        1 + categoryScores[c.Name],
+		})
+	}
+
+	if err := uc.repo.ReplaceCatalog(ctx, terms); err != nil {
+		return 0, err
+	}
+	if err := uc.repo.TrimQueries(ctx, uc.maxQueries); err != nil {
+		return 0, err
+	}
+	return len(terms), nil
+}
+
+// recordSearchQuery logs the normalized query; very short or long queries are ignored
+func recordSearchQuery(ctx context.Context, repo SuggestRepository, query string) error {
+	query = normalizeQuery(query)
+	if n := utf8.RuneCountInString(query); n < minLoggedQueryLength || n > maxLoggedQueryLength {
+		return nil
+	}
+	return repo.IncrementQuery(ctx, query)
+}
+
+// appendSuggestionTerms indexes the name from each of its first words: "Trail Running Shoes"
+// completes "trail", "running" and "shoes"
+func appendSuggestionTerms(terms []entity.SuggestionTerm, name string, s entity.Suggestion) []entity.SuggestionTerm {
+	words := strings.Fields(normalizeQuery(name))
+	for i := 0; i < len(words) && i < maxSuggestTermWords; i++ {
+		terms = append(terms, entity.SuggestionTerm{Term: strings.Join(words[i:], " "), Suggestion: s})
+	}
+	return terms
+}
+
+// normalizeQuery lowercases and collapses whitespace
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
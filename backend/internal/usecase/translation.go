@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidTranslation  = errors.New("invalid translation")
+	ErrTranslationNotFound = errors.New("translation not found")
+)
+
+// TranslationUseCase manages the translations of product and category text to the supported locales
+type TranslationUseCase struct {
+	productUC    *ProductUseCase
+	productRepo  ProductRepository
+	categoryRepo CategoryRepository
+	locales      *LocaleUseCase
+}
+
+func NewTranslationUseCase(
+	productUC *ProductUseCase,
+	productRepo ProductRepository,
+	categoryRepo CategoryRepository,
+	locales *LocaleUseCase,
+) *TranslationUseCase {
+	return &TranslationUseCase{
+		productUC:    productUC,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		locales:      locales,
+	}
+}
+
+// SetProductTranslation adds or replaces the product's translation to the locale. A translation
+// without a description shows the default one
+func (uc *TranslationUseCase) SetProductTranslation(ctx context.Context, id bson.ObjectID, translation entity.Translation) (*entity.Product, error) {
+	product, err := uc.productUC.getStored(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.prepare(&translation); err != nil {
+		return nil, err
+	}
+
+	translations := setTranslation(product.Translations, translation)
+	if err := uc.productUC.SetTranslations(ctx, id, translations); err != nil {
+		return nil, err
+	}
+	product.Translations = translations
+	return product, nil
+}
+
+func (uc *TranslationUseCase) DeleteProductTranslation(ctx context.Context, id bson.ObjectID, locale string) (*entity.Product, error) {
+	product, err := uc.productUC.getStored(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := uc.deleteTranslation(product.Translations, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.productUC.SetTranslations(ctx, id, translations); err != nil {
+		return nil, err
+	}
+	product.Translations = translations
+	return product, nil
+}
+
+// SetCategoryTranslation adds or replaces the translation of the category name to the locale
+func (uc *TranslationUseCase) SetCategoryTranslation(ctx context.Context, id bson.ObjectID, translation entity.Translation) (*entity.Category, error) {
+	category, err := uc.getCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if translation.Description != "" {
+		return nil, fmt.Errorf("%w: categories have no description", ErrInvalidTranslation)
+	}
+	if err := uc.prepare(&translation); err != nil {
+		return nil, err
+	}
+
+	translations := setTranslation(category.Translations, translation)
+	if err := uc.categoryRepo.SetTranslations(ctx, id, translations); err != nil {
+		return nil, err
+	}
+	category.Translations = translations
+	return category, nil
+}
+
+func (uc *TranslationUseCase) DeleteCategoryTranslation(ctx context.Context, id bson.ObjectID, locale string) (*entity.Category, error) {
+	category, err := uc.getCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	translations, err := uc.deleteTranslation(category.Translations, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.categoryRepo.SetTranslations(ctx, id, translations); err != nil {
+		return nil, err
+	}
+	category.Translations = translations
+	return category, nil
+}
+
+// MissingProductTranslations pages through the active products, newest first, still to be
+// translated to the locale, to any supported locale when it is empty
+func (uc *TranslationUseCase) MissingProductTranslations(
+	ctx context.Context,
+	locale string,
+	page entity.PageRequest,
+) ([]entity.MissingTranslations, entity.PageInfo, error) {
+	locales, err := uc.missingLocales(locale)
+	if err != nil || len(locales) == 0 {
+		return []entity.MissingTranslations{}, entity.PageInfo{}, err
+	}
+
+	products, info, err := uc.productRepo.ListMissingTranslations(ctx, locales, page)
+	if err != nil {
+		return nil, entity.PageInfo{}, err
+	}
+	missing := make([]entity.MissingTranslations, len(products))
+	for i, p := range products {
+		missing[i] = entity.MissingTranslations{
+			ID:      p.ID,
+			Name:    p.Name,
+			Locales: p.Translations.Missing(locales, p.Description != ""),
+		}
+	}
+	return missing, info, nil
+}
+
+// MissingCategoryTranslations lists the categories, in tree order, whose name is still to be
+// translated to the locale, to any supported locale when it is empty
+func (uc *TranslationUseCase) MissingCategoryTranslations(ctx context.Context, locale string) ([]entity.MissingTranslations, error) {
+	locales, err := uc.missingLocales(locale)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := []entity.MissingTranslations{}
+	for _, c := range categories {
+		if l := c.Translations.Missing(locales, false); len(l) > 0 {
+			missing = append(missing, entity.MissingTranslations{ID: c.ID, Name: c.Name, Locales: l})
+		}
+	}
+	return missing, nil
+}
+
+func (uc *TranslationUseCase) prepare(translation *entity.Translation) error {
+	locale, err := uc.locales.Translatable(translation.Locale)
+	if err != nil {
+		return err
+	}
+	translation.Locale = locale
+	translation.Name = strings.TrimSpace(translation.Name)
+	translation.Description = strings.TrimSpace(translation.Description)
+	if translation.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTranslation)
+	}
+	return nil
+}
+
+func (uc *TranslationUseCase) deleteTranslation(translations entity.Translations, locale string) (entity.Translations, error) {
+	locale, err := uc.locales.Translatable(locale)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(translations, func(t entity.Translation) bool { return t.Locale == locale })
+	if i < 0 {
+		return nil, ErrTranslationNotFound
+	}
+	return slices.Delete(slices.Clone(translations), i, i+1), nil
+}
+
+// missingLocales returns the locale to check, canonicalized, or every supported one
+func (uc *TranslationUseCase) missingLocales(locale string) ([]string, error) {
+	if locale == "" {
+		return uc.locales.Translated(), nil
+	}
+	l, err := uc.locales.Translatable(locale)
+	if err != nil {
+		return nil, err
+	}
+	return []string{l}, nil
+}
+
+func (uc *TranslationUseCase) getCategory(ctx context.Context, id bson.ObjectID) (*entity.Category, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+// setTranslation replaces the translation to the same locale or adds it
+func setTranslation(translations entity.Translations, translation entity.Translation) entity.Translations {
+	translations = slices.Clone(translations)
+	i := slices.IndexFunc(translations, func(t entity.Translation) bool { return t.Locale == translation.Locale })
+	if i >= 0 {
+		translations[i] = translation
+		return translations
+	}
+	return append(translations, translation)
+}
@@ -8,18 +8,23 @@ import (
 	"github.com/m4rk1sov/ecommerce/internal/entity"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUseCase struct {
 	repo      UserRepository
+	guestUC   *GuestUseCase
+	l         *zap.SugaredLogger
 	jwtSecret string
 	jwtExpiry time.Duration
 }
 
-func NewUserUseCase(repo UserRepository, sessionRepo interface{}, jwtSecret string, jwtExpiry time.Duration) *UserUseCase {
+func NewUserUseCase(repo UserRepository, sessionRepo interface{}, guestUC *GuestUseCase, l *zap.SugaredLogger, jwtSecret string, jwtExpiry time.Duration) *UserUseCase {
 	return &UserUseCase{
 		repo:      repo,
+		guestUC:   guestUC,
+		l:         l,
 		jwtSecret: jwtSecret,
 		jwtExpiry: jwtExpiry,
 	}
@@ -44,6 +49,12 @@ func (uc *UserUseCase) Register(ctx context.Context, email, username, password,
 		return nil, "", err
 	}
 
+	// Past guest checkouts with this email are claimed once the emailed token proves the address.
+	// Best effort: the account already exists and the user can ask for the email again
+	if _, err := uc.guestUC.RequestClaim(ctx, user); err != nil {
+		uc.l.Errorw("Failed to send guest claim", "user_id", user.ID.Hex(), "error", err)
+	}
+
 	token, err := uc.generateToken(user.ID)
 	if err != nil {
 		return nil, "", err
@@ -78,6 +89,28 @@ func (uc *UserUseCase) Update(ctx context.Context, user *entity.User) error {
 	return uc.repo.Update(ctx, user)
 }
 
+// RequestGuestClaim emails the user a token to claim the guest checkouts made with their email
+func (uc *UserUseCase) RequestGuestClaim(ctx context.Context, userID bson.ObjectID) (int, error) {
+	user, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return uc.guestUC.RequestClaim(ctx, user)
+}
+
+// ClaimGuests redeems a guest claim token for the user
+func (uc *UserUseCase) ClaimGuests(ctx context.Context, userID bson.ObjectID, token string) (int, error) {
+	user, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	claimed, err := uc.guestUC.Claim(ctx, user, token)
+	if err != nil && claimed > 0 {
+		uc.l.Errorw("Guest claim stopped partway", "user_id", userID.Hex(), "claimed", claimed, "error", err)
+	}
+	return claimed, err
+}
+
 func (uc *UserUseCase) generateToken(userID bson.ObjectID) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID.Hex(),
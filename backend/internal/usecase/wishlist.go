@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const defaultWishlistName = "My Wishlist"
+
+var (
+	ErrWishlistNotFound      = errors.New("wishlist not found")
+	ErrWishlistItemNotFound  = errors.New("product is not on the wishlist")
+	ErrDefaultWishlistDelete = errors.New("default wishlist cannot be deleted")
+)
+
+type WishlistUseCase struct {
+	repo          WishlistRepository
+	productRepo   ProductRepository
+	interactionUC *InteractionUseCase
+}
+
+func NewWishlistUseCase(repo WishlistRepository, productRepo ProductRepository, interactionUC *InteractionUseCase) *WishlistUseCase {
+	return &WishlistUseCase{
+		repo:          repo,
+		productRepo:   productRepo,
+		interactionUC: interactionUC,
+	}
+}
+
+// CreateList creates an additional named wishlist
+func (uc *WishlistUseCase) CreateList(ctx context.Context, userID bson.ObjectID, name string) (*entity.Wishlist, error) {
+	wishlist := &entity.Wishlist{
+		UserID: userID,
+		Name:   name,
+	}
+	if err := uc.repo.Create(ctx, wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// GetLists returns all wishlists of the user, creating the default one on first use
+func (uc *WishlistUseCase) GetLists(ctx context.Context, userID bson.ObjectID) ([]*entity.Wishlist, error) {
+	if _, err := uc.getOrCreateDefault(ctx, userID); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetUserWishlists(ctx, userID)
+}
+
+func (uc *WishlistUseCase) GetList(ctx context.Context, userID, wishlistID bson.ObjectID) (*entity.Wishlist, error) {
+	return uc.getOwned(ctx, userID, wishlistID)
+}
+
+// GetShared returns a public wishlist by its share token
+func (uc *WishlistUseCase) GetShared(ctx context.Context, token string) (*entity.Wishlist, error) {
+	wishlist, err := uc.repo.GetByShareToken(ctx, token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, err
+}
+
+func (uc *WishlistUseCase) RenameList(ctx context.Context, userID, wishlistID bson.ObjectID, name string) (*entity.Wishlist, error) {
+	wishlist, err := uc.getOwned(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist.Name = name
+	if err := uc.repo.Update(ctx, wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (uc *WishlistUseCase) DeleteList(ctx context.Context, userID, wishlistID bson.ObjectID) error {
+	wishlist, err := uc.getOwned(ctx, userID, wishlistID)
+	if err != nil {
+		return err
+	}
+	if wishlist.IsDefault {
+		return ErrDefaultWishlistDelete
+	}
+	return uc.repo.Delete(ctx, wishlist.ID)
+}
+
+// SetPublic toggles sharing; a share token is generated the first time a list is made public
+func (uc *WishlistUseCase) SetPublic(ctx context.Context, userID, wishlistID bson.ObjectID, public bool) (*entity.Wishlist, error) {
+	wishlist, err := uc.getOwned(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist.IsPublic = public
+	if public && wishlist.ShareToken == "" {
+		token, err := generateShareToken()
+		if err != nil {
+			return nil, err
+		}
+		wishlist.ShareToken = token
+	}
+
+	if err := uc.repo.Update(ctx, wishlist); err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// AddItem adds a product to the given wishlist (or the default one when wishlistID is nil)
+// and records it as a like, so recommendations get the same signal as before
+func (uc *WishlistUseCase) AddItem(ctx context.Context, userID, wishlistID, productID bson.ObjectID) (*entity.Wishlist, error) {
+	wishlist, err := uc.resolve(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	added, err := uc.repo.AddItem(ctx, wishlist.ID, entity.WishlistItem{ProductID: productID})
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		if err := uc.interactionUC.RecordInteraction(ctx, userID, productID, "", entity.InteractionLike); err != nil {
+			return nil, err
+		}
+	}
+
+	return uc.repo.GetByID(ctx, wishlist.ID)
+}
+
+func (uc *WishlistUseCase) RemoveItem(ctx context.Context, userID, wishlistID, productID bson.ObjectID) (*entity.Wishlist, error) {
+	wishlist, err := uc.resolve(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := uc.repo.RemoveItem(ctx, wishlist.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrWishlistItemNotFound
+	}
+
+	return uc.repo.GetByID(ctx, wishlist.ID)
+}
+
+// MoveToCart records a cart interaction (with the chosen variant) and removes the product from the wishlist
+func (uc *WishlistUseCase) MoveToCart(ctx context.Context, userID, wishlistID, productID bson.ObjectID, sku string) (*entity.Wishlist, error) {
+	wishlist, err := uc.resolve(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	onList := false
+	for _, item := range wishlist.Items {
+		if item.ProductID == productID {
+			onList = true
+			break
+		}
+	}
+	if !onList {
+		return nil, ErrWishlistItemNotFound
+	}
+
+	if err := uc.interactionUC.RecordInteraction(ctx, userID, productID, sku, entity.InteractionCart); err != nil {
+		return nil, err
+	}
+
+	return uc.RemoveItem(ctx, userID, wishlist.ID, productID)
+}
+
+func (uc *WishlistUseCase) resolve(ctx context.Context, userID, wishlistID bson.ObjectID) (*entity.Wishlist, error) {
+	if wishlistID.IsZero() {
+		return uc.getOrCreateDefault(ctx, userID)
+	}
+	return uc.getOwned(ctx, userID, wishlistID)
+}
+
+func (uc *WishlistUseCase) getOwned(ctx context.Context, userID, wishlistID bson.ObjectID) (*entity.Wishlist, error) {
+	wishlist, err := uc.repo.GetByID(ctx, wishlistID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Don't reveal other users' lists
+	if wishlist.UserID != userID {
+		return nil, ErrWishlistNotFound
+	}
+	return wishlist, nil
+}
+
+func (uc *WishlistUseCase) getOrCreateDefault(ctx context.Context, userID bson.ObjectID) (*entity.Wishlist, error) {
+	wishlist, err := uc.repo.GetDefault(ctx, userID)
+	if err == nil {
+		return wishlist, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	wishlist = &entity.Wishlist{
+		UserID:    userID,
+		Name:      defaultWishlistName,
+		IsDefault: true,
+	}
+	if err := uc.repo.Create(ctx, wishlist); err != nil {
+		// A concurrent request created the default list first
+		if mongo.IsDuplicateKeyError(err) {
+			return uc.repo.GetDefault(ctx, userID)
+		}
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func generateShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -7,11 +7,37 @@ db.users.createIndex({ "username": 1 }, { unique: true });
 db.users.createIndex({ "created_at": -1 });
 
 // Products collection
-db.products.createIndex({ "name": "text", "description": "text", "tags": "text" });
+// Weighted text index (name > tags > description) over the default text and the translations,
+// replaces the earlier ones
+db.products.getIndexes()
+    .filter(idx => idx.key._fts === "text" && idx.name !== "products_text_localized")
+    .forEach(idx => db.products.dropIndex(idx.name));
+db.products.createIndex(
+    { "name": "text", "translations.name": "text", "description": "text", "translations.description": "text", "tags": "text" },
+    {
+        name: "products_text_localized",
+        weights: { "name": 10, "translations.name": 10, "tags": 5, "description": 1, "translations.description": 1 },
+        default_language: "english",
+        // Translations carry a locale, not a text index language, so every field is stemmed alike
+        language_override: "text_language"
+    }
+);
 db.products.createIndex({ "category": 1 });
-db.products.createIndex({ "price": 1 });
+// Search filters and sorts on the current price, the sale price while a sale is in effect
+db.products.createIndex({ "current_price": 1 });
 db.products.createIndex({ "rating": -1 });
 db.products.createIndex({ "created_at": -1 });
+db.products.createIndex({ "tags": 1 });
+db.products.createIndex({ "category": 1, "current_price": 1 });
+db.products.createIndex({ "variants.sku": 1 }, { unique: true, partialFilterExpression: { "variants.sku": { "$exists": true } } });
+db.products.createIndex({ "external_sku": 1 }, { unique: true, partialFilterExpression: { "external_sku": { "$exists": true } } });
+db.products.createIndex({ "archived_at": -1 }, { partialFilterExpression: { "archived_at": { "$exists": true } } });
+db.products.createIndex({ "sales.ends_at": 1, "sales.starts_at": 1 }, { partialFilterExpression: { "sales": { "$exists": true } } });
+db.products.createIndex({ "attributes.$**": 1 });
+db.products.createIndex({ "translations.locale": 1 });
+
+// Product history
+db.product_versions.createIndex({ "product_id": 1, "version": -1 }, { unique: true });
 
 // Interactions collection
 db.interactions.createIndex({ "user_id": 1, "timestamp": -1 });
@@ -19,9 +45,57 @@ db.interactions.createIndex({ "product_id": 1, "timestamp": -1 });
 db.interactions.createIndex({ "user_id": 1, "product_id": 1 });
 db.interactions.createIndex({ "type": 1 });
 db.interactions.createIndex({ "timestamp": -1 });
+db.interactions.createIndex({ "guest_id": 1 }, { sparse: true });
 
 // Purchases collection
 db.purchases.createIndex({ "user_id": 1, "created_at": -1 });
 db.purchases.createIndex({ "status": 1 });
+db.purchases.createIndex({ "guest_id": 1, "created_at": -1 }, { sparse: true });
+
+// Guests collection
+db.guests.createIndex({ "email": 1 });
+
+// Wishlists collection
+db.wishlists.createIndex({ "user_id": 1, "created_at": 1 });
+db.wishlists.createIndex({ "user_id": 1, "is_default": 1 }, { unique: true, partialFilterExpression: { "is_default": true } });
+// Only lists that are shared carry a token
+db.wishlists.createIndex(
+    { "share_token": 1 },
+    { name: "wishlists_share_token", unique: true, partialFilterExpression: { "share_token": { "$gt": "" } } }
+);
+
+// Cart reminders collection
+db.cart_reminders.createIndex({ "user_id": 1, "sent_at": -1 });
+db.cart_reminders.createIndex({ "recovered": 1, "sent_at": -1 });
+
+// Reviews collection
+db.reviews.createIndex({ "product_id": 1, "user_id": 1 }, { unique: true });
+db.reviews.createIndex({ "product_id": 1, "created_at": -1 });
+db.reviews.createIndex({ "product_id": 1, "helpful_count": -1 });
+
+// Categories collection
+db.categories.createIndex({ "name": 1 }, { unique: true });
+db.categories.createIndex({ "slug": 1 }, { unique: true });
+db.categories.createIndex({ "ancestors": 1 });
+db.categories.createIndex({ "parent_id": 1, "order": 1 });
+db.categories.createIndex({ "sales.ends_at": 1 }, { partialFilterExpression: { "sales": { "$exists": true } } });
+
+// Search synonyms and merchandising rules
+db.search_synonyms.createIndex({ "terms": 1 });
+db.search_synonyms.createIndex({ "created_at": -1, "_id": -1 });
+db.search_rules.createIndex({ "queries": 1 });
+db.search_rules.createIndex({ "created_at": -1, "_id": -1 });
+
+// Search logs, kept for 90 days
+db.search_logs.createIndex({ "created_at": 1 }, { expireAfterSeconds: 7776000 });
+
+// Price history, changes not yet checked against watches are picked up in order
+db.price_history.createIndex({ "product_id": 1, "sku": 1, "changed_at": 1 });
+db.price_history.createIndex({ "alerted": 1, "changed_at": 1 });
+
+// Price watches, one per user and product price
+db.price_watches.createIndex({ "user_id": 1, "product_id": 1, "sku": 1 }, { unique: true });
+db.price_watches.createIndex({ "user_id": 1, "created_at": -1 });
+db.price_watches.createIndex({ "product_id": 1, "sku": 1 });
 
 print("MongoDB indexes created successfully!");
@@ -0,0 +1,54 @@
+// Prices used to be stored as doubles in major units (19.99), they are now int64 minor units
+// of the base currency (1999). Only doubles are converted, so the script can be run again safely
+db = db.getSiblingDB('ecommerce');
+
+const toMinor = (field) => ({
+    $cond: [
+        { $eq: [{ $type: field }, "double"] },
+        { $toLong: { $round: [{ $multiply: [field, 100] }, 0] } },
+        field
+    ]
+});
+
+const convertItems = (field) => ({
+    $cond: [
+        { $isArray: field },
+        {
+            $map: {
+                input: field,
+                as: "item",
+                in: { $mergeObjects: ["$$item", { price: toMinor("$$item.price") }] }
+            }
+        },
+        field
+    ]
+});
+
+let result = db.products.updateMany(
+    { $or: [{ price: { $type: "double" } }, { "variants.price": { $type: "double" } }] },
+    [{ $set: { price: toMinor("$price"), variants: convertItems("$variants") } }]
+);
+print(`products: ${result.modifiedCount}`);
+
+result = db.purchases.updateMany(
+    { $or: [{ total: { $type: "double" } }, { "products.price": { $type: "double" } }] },
+    [{ $set: { total: toMinor("$total"), products: convertItems("$products") } }]
+);
+print(`purchases: ${result.modifiedCount}`);
+
+result = db.cart_reminders.updateMany(
+    { recovered_total: { $type: "double" } },
+    [{ $set: { recovered_total: toMinor("$recovered_total") } }]
+);
+print(`cart_reminders: ${result.modifiedCount}`);
+
+result = db.users.updateMany(
+    { $or: [{ "preferences.price_range.min": { $type: "double" } }, { "preferences.price_range.max": { $type: "double" } }] },
+    [{ $set: {
+        "preferences.price_range.min": toMinor("$preferences.price_range.min"),
+        "preferences.price_range.max": toMinor("$preferences.price_range.max")
+    } }]
+);
+print(`users: ${result.modifiedCount}`);
+
+print("Prices converted to minor units!");
@@ -0,0 +1,16 @@
+// Unsharing a wishlist used to store an empty share token, which the unique token index covered, so a
+// second unshared list failed to save. Empty tokens are removed and the old index is dropped, init.js
+// creates the one over non-empty tokens. Runs before init.js and can be run again safely
+db = db.getSiblingDB('ecommerce');
+
+const result = db.wishlists.updateMany({ share_token: "" }, { $unset: { share_token: "" } });
+print(`wishlists: ${result.modifiedCount}`);
+
+db.wishlists.getIndexes()
+    .filter(idx => idx.key.share_token === 1 && idx.name !== "wishlists_share_token")
+    .forEach(idx => {
+        db.wishlists.dropIndex(idx.name);
+        print(`dropped index ${idx.name}`);
+    });
+
+print("Wishlist share tokens cleaned up!");
@@ -0,0 +1,141 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Distance is the optimal string alignment distance between a and b: insertions, deletions,
+// substitutions and swaps of adjacent letters ("keybaord" -> "keyboard") cost one edit each
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rolling rows are enough, the transposition looks two rows back
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// Tokenize lowercases s and splits it into words of letters and digits
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Match - a vocabulary word close to the looked up word
+type Match struct {
+	Word      string
+	Distance  int
+	Frequency int
+}
+
+// Vocabulary finds known words close to a misspelled one. Candidates are narrowed down with a
+// trigram index before computing edit distances. A Vocabulary is immutable and safe for concurrent use
+type Vocabulary struct {
+	frequency map[string]int
+	trigrams  map[string][]string
+}
+
+// NewVocabulary indexes words with how often they occur
+func NewVocabulary(frequency map[string]int) *Vocabulary {
+	v := &Vocabulary{
+		frequency: frequency,
+		trigrams:  make(map[string][]string),
+	}
+	for word := range frequency {
+		for _, t := range trigrams(word) {
+			v.trigrams[t] = append(v.trigrams[t], word)
+		}
+	}
+	return v
+}
+
+func (v *Vocabulary) Len() int {
+	return len(v.frequency)
+}
+
+func (v *Vocabulary) Contains(word string) bool {
+	_, ok := v.frequency[word]
+	return ok
+}
+
+// Lookup returns up to limit words within maxEdits of word, closest first, then most frequent
+func (v *Vocabulary) Lookup(word string, maxEdits, limit int) []Match {
+	if maxEdits <= 0 || limit <= 0 {
+		return nil
+	}
+
+	grams := trigrams(word)
+	// Each edit changes at most three trigrams, words sharing fewer can't be close enough
+	minShared := max(len(grams)-3*maxEdits, 1)
+
+	shared := make(map[string]int)
+	for _, t := range grams {
+		for _, candidate := range v.trigrams[t] {
+			shared[candidate]++
+		}
+	}
+
+	length := len([]rune(word))
+	var matches []Match
+	for candidate, n := range shared {
+		if n < minShared || candidate == word {
+			continue
+		}
+		if diff := len([]rune(candidate)) - length; diff > maxEdits || -diff > maxEdits {
+			continue
+		}
+		if d := Distance(word, candidate); d <= maxEdits {
+			matches = append(matches, Match{Word: candidate, Distance: d, Frequency: v.frequency[candidate]})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		if matches[i].Frequency != matches[j].Frequency {
+			return matches[i].Frequency > matches[j].Frequency
+		}
+		return matches[i].Word < matches[j].Word
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// trigrams of the word padded as "$$word$", so short words and word starts get trigrams too
+func trigrams(word string) []string {
+	r := []rune("$$" + word + "$")
+	grams := make([]string, 0, len(r)-2)
+	seen := make(map[string]bool, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		g := string(r[i : i+3])
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
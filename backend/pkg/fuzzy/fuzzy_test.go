@@ -0,0 +1,95 @@
+package fuzzy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "keyboard", b: "keyboard", want: 0},
+		{a: "", b: "", want: 0},
+		{a: "", b: "abc", want: 3},
+		{a: "abc", b: "", want: 3},
+		// A swap of adjacent letters is one edit
+		{a: "keybaord", b: "keyboard", want: 1},
+		{a: "keybord", b: "keyboard", want: 1},
+		{a: "keyboardd", b: "keyboard", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		// Optimal string alignment doesn't edit a swapped pair again
+		{a: "ca", b: "abc", want: 3},
+		{a: "café", b: "cafe", want: 1},
+		{a: "naïve", b: "naive", want: 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "Wireless Keyboard", want: []string{"wireless", "keyboard"}},
+		{in: "USB-C  charger, 65W!", want: []string{"usb", "c", "charger", "65w"}},
+		{in: "Café crème", want: []string{"café", "crème"}},
+		{in: " -- ", want: []string{}},
+	}
+	for _, tt := range tests {
+		got := Tokenize(tt.in)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVocabularyLookup(t *testing.T) {
+	v := NewVocabulary(map[string]int{
+		"keyboard":  10,
+		"keyboards": 3,
+		"key":       50,
+		"mouse":     7,
+		"short":     9,
+		"shirt":     5,
+		"shift":     20,
+	})
+
+	tests := []struct {
+		name     string
+		word     string
+		maxEdits int
+		limit    int
+		want     []string
+	}{
+		{name: "closest first", word: "keybord", maxEdits: 2, limit: 5, want: []string{"keyboard", "keyboards"}},
+		{name: "within max edits only", word: "keybord", maxEdits: 1, limit: 5, want: []string{"keyboard"}},
+		{name: "same distance, most frequent first", word: "shurt", maxEdits: 1, limit: 5, want: []string{"short", "shirt"}},
+		{name: "limit", word: "shurt", maxEdits: 2, limit: 2, want: []string{"short", "shirt"}},
+		{name: "further edits rank after", word: "shurt", maxEdits: 2, limit: 5, want: []string{"short", "shirt", "shift"}},
+		{name: "the word itself isn't a correction", word: "mouse", maxEdits: 2, limit: 5, want: nil},
+		{name: "nothing close", word: "monitor", maxEdits: 2, limit: 5, want: nil},
+		{name: "no edits allowed", word: "keybord", maxEdits: 0, limit: 5, want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, m := range v.Lookup(tt.word, tt.maxEdits, tt.limit) {
+			got = append(got, m.Word)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Lookup(%q, %d, %d) = %q, want %q", tt.name, tt.word, tt.maxEdits, tt.limit, got, tt.want)
+		}
+	}
+
+	if !v.Contains("mouse") || v.Contains("mice") || v.Len() != 7 {
+		t.Errorf("Contains/Len don't reflect the vocabulary")
+	}
+}
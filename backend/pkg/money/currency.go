@@ -0,0 +1,97 @@
+package money
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+// Currency - an ISO 4217 currency with the number of decimals of its minor unit
+type Currency struct {
+	Code     string `json:"code"`
+	Decimals int    `json:"decimals"`
+}
+
+// currencies - the currencies prices can be shown and paid in
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", Decimals: 2},
+	"EUR": {Code: "EUR", Decimals: 2},
+	"GBP": {Code: "GBP", Decimals: 2},
+	"CHF": {Code: "CHF", Decimals: 2},
+	"CAD": {Code: "CAD", Decimals: 2},
+	"AUD": {Code: "AUD", Decimals: 2},
+	"CNY": {Code: "CNY", Decimals: 2},
+	"INR": {Code: "INR", Decimals: 2},
+	"KZT": {Code: "KZT", Decimals: 2},
+	"RUB": {Code: "RUB", Decimals: 2},
+	"TRY": {Code: "TRY", Decimals: 2},
+	"PLN": {Code: "PLN", Decimals: 2},
+	"SEK": {Code: "SEK", Decimals: 2},
+	"JPY": {Code: "JPY", Decimals: 0},
+	"KRW": {Code: "KRW", Decimals: 0},
+	"KWD": {Code: "KWD", Decimals: 3},
+}
+
+// LookupCurrency finds a supported currency by its code, case-insensitively
+func LookupCurrency(code string) (Currency, bool) {
+	c, ok := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// Money - an amount in minor units of Currency
+type Money struct {
+	Amount   int64  `bson:"amount" json:"-"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+// MarshalJSON writes the amount as a decimal number in the currency's precision
+func (m Money) MarshalJSON() ([]byte, error) {
+	decimals := BaseDecimals
+	if c, ok := LookupCurrency(m.Currency); ok {
+		decimals = c.Decimals
+	}
+	return json.Marshal(struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}{
+		Amount:   json.Number(FormatMinor(m.Amount, decimals)),
+		Currency: m.Currency,
+	})
+}
+
+// Quote - a currency with its exchange rate to the base currency, in units of the currency per base unit
+type Quote struct {
+	Currency Currency
+	Rate     *big.Rat
+}
+
+// Convert turns a base amount into the quoted currency, rounding half away from zero
+func (q Quote) Convert(a Amount) Money {
+	v := new(big.Rat).SetInt64(int64(a))
+	v.Mul(v, q.Rate)
+	v.Mul(v, pow10Rat(q.Currency.Decimals-BaseDecimals))
+	return Money{Amount: round(v), Currency: q.Currency.Code}
+}
+
+// ToBase turns minor units of the quoted currency into a base amount, rounding half away from zero
+func (q Quote) ToBase(minor int64) Amount {
+	v := new(big.Rat).SetInt64(minor)
+	v.Quo(v, q.Rate)
+	v.Mul(v, pow10Rat(BaseDecimals-q.Currency.Decimals))
+	return Amount(round(v))
+}
+
+// RateString - the rate as a decimal with up to 10 decimals and no trailing zeros
+func (q Quote) RateString() string {
+	s := q.Rate.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func pow10Rat(exp int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(exp, -exp))), nil)
+	if exp < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+	return new(big.Rat).SetInt(p)
+}
@@ -0,0 +1,155 @@
+// Package money does exact arithmetic on amounts kept in minor currency units (e.g. cents)
+// and converts them between currencies with decimal exchange rates
+package money
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// BaseDecimals - amounts of the base currency are kept in hundredths
+const BaseDecimals = 2
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrInvalidRate   = errors.New("invalid exchange rate")
+)
+
+// Amount - money in minor units (hundredths) of the base currency. It is written to JSON as a decimal
+// number ("19.99") and to BSON as an int64
+type Amount int64
+
+// ParseAmount reads a decimal amount of the base currency, e.g. "19.99"
+func ParseAmount(s string) (Amount, error) {
+	v, err := ParseMinor(s, BaseDecimals)
+	return Amount(v), err
+}
+
+func (a Amount) String() string {
+	return FormatMinor(int64(a), BaseDecimals)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string with at most two decimals
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	v, err := ParseAmount(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// UnmarshalBSONValue reads int64 and int32 minor units. Doubles are prices written before amounts
+// were kept in minor units and hold major units
+func (a *Amount) UnmarshalBSONValue(typ byte, data []byte) error {
+	switch typ {
+	case byte(bson.TypeInt64):
+		if len(data) != 8 {
+			return ErrInvalidAmount
+		}
+		*a = Amount(int64(binary.LittleEndian.Uint64(data)))
+	case byte(bson.TypeInt32):
+		if len(data) != 4 {
+			return ErrInvalidAmount
+		}
+		*a = Amount(int32(binary.LittleEndian.Uint32(data)))
+	case byte(bson.TypeDouble):
+		if len(data) != 8 {
+			return ErrInvalidAmount
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(data))
+		*a = Amount(math.Round(f * math.Pow10(BaseDecimals)))
+	case byte(bson.TypeNull):
+		*a = 0
+	default:
+		return fmt.Errorf("%w: cannot decode BSON type 0x%02x", ErrInvalidAmount, typ)
+	}
+	return nil
+}
+
+// ParseMinor reads a decimal number into minor units with the given number of decimals.
+// More decimals than the currency has are rejected rather than rounded
+func ParseMinor(s string, decimals int) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") || len(frac) > decimals || !digitsOnly(whole) || !digitsOnly(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	v, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// FormatMinor writes minor units as a decimal number with the given number of decimals
+func FormatMinor(v int64, decimals int) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = uint64(-v)
+	}
+
+	digits := strconv.FormatUint(u, 10)
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+}
+
+func digitsOnly(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRate reads a positive decimal exchange rate, e.g. "0.9215"
+func ParseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 || strings.ContainsAny(s, "/eE") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return r, nil
+}
+
+// round rounds half away from zero
+func round(r *big.Rat) int64 {
+	q, rem := new(big.Int).QuoRem(new(big.Int).Abs(r.Num()), r.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
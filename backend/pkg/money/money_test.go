@@ -0,0 +1,190 @@
+package money
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestParseMinor(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+		want     int64
+		wantErr  bool
+	}{
+		{in: "19.99", decimals: 2, want: 1999},
+		{in: "19.9", decimals: 2, want: 1990},
+		{in: "19", decimals: 2, want: 1900},
+		{in: " 0.05 ", decimals: 2, want: 5},
+		{in: "-0.5", decimals: 2, want: -50},
+		{in: "1500", decimals: 0, want: 1500},
+		{in: "1.234", decimals: 3, want: 1234},
+		{in: "0.5", decimals: 3, want: 500},
+		// More decimals than the currency has are rejected, not rounded
+		{in: "19.999", decimals: 2, wantErr: true},
+		{in: "1.5", decimals: 0, wantErr: true},
+		{in: "1.2345", decimals: 3, wantErr: true},
+		{in: "", decimals: 2, wantErr: true},
+		{in: "1.", decimals: 2, wantErr: true},
+		{in: ".5", decimals: 2, wantErr: true},
+		{in: "1e3", decimals: 2, wantErr: true},
+		{in: "1,50", decimals: 2, wantErr: true},
+		{in: "--1", decimals: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMinor(tt.in, tt.decimals)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("ParseMinor(%q, %d) error = %v, want ErrInvalidAmount", tt.in, tt.decimals, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMinor(%q, %d) = %d, %v, want %d", tt.in, tt.decimals, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatMinor(t *testing.T) {
+	tests := []struct {
+		v        int64
+		decimals int
+		want     string
+	}{
+		{v: 1999, decimals: 2, want: "19.99"},
+		{v: 5, decimals: 2, want: "0.05"},
+		{v: -50, decimals: 2, want: "-0.50"},
+		{v: 0, decimals: 2, want: "0.00"},
+		{v: 1500, decimals: 0, want: "1500"},
+		{v: 1234, decimals: 3, want: "1.234"},
+		{v: 7, decimals: 3, want: "0.007"},
+	}
+	for _, tt := range tests {
+		if got := FormatMinor(tt.v, tt.decimals); got != tt.want {
+			t.Errorf("FormatMinor(%d, %d) = %q, want %q", tt.v, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{num: 5, denom: 2, want: 3},
+		{num: -5, denom: 2, want: -3},
+		{num: 3, denom: 2, want: 2},
+		{num: -3, denom: 2, want: -2},
+		{num: 24999, denom: 10000, want: 2},
+		{num: -24999, denom: 10000, want: -2},
+		{num: 7, denom: 3, want: 2},
+		{num: 8, denom: 3, want: 3},
+		{num: 4, denom: 1, want: 4},
+		{num: 0, denom: 1, want: 0},
+	}
+	for _, tt := range tests {
+		if got := round(big.NewRat(tt.num, tt.denom)); got != tt.want {
+			t.Errorf("round(%d/%d) = %d, want %d", tt.num, tt.denom, got, tt.want)
+		}
+	}
+}
+
+func mustQuote(t *testing.T, code, rate string) Quote {
+	t.Helper()
+	c, ok := LookupCurrency(code)
+	if !ok {
+		t.Fatalf("unknown currency %s", code)
+	}
+	r, err := ParseRate(rate)
+	if err != nil {
+		t.Fatalf("ParseRate(%q): %v", rate, err)
+	}
+	return Quote{Currency: c, Rate: r}
+}
+
+func TestQuoteConvert(t *testing.T) {
+	tests := []struct {
+		code, rate string
+		amount     Amount
+		want       int64
+	}{
+		{code: "USD", rate: "1", amount: 1999, want: 1999},
+		{code: "EUR", rate: "0.9215", amount: 1999, want: 1842},
+		// 19.99 * 150 = 2998.5 yen, halves round away from zero
+		{code: "JPY", rate: "150", amount: 1999, want: 2999},
+		{code: "JPY", rate: "150", amount: -1999, want: -2999},
+		// 19.99 * 0.3075 = 6.146925 dinars, kept in thousandths
+		{code: "KWD", rate: "0.3075", amount: 1999, want: 6147},
+		{code: "KWD", rate: "0.3075", amount: 0, want: 0},
+	}
+	for _, tt := range tests {
+		got := mustQuote(t, tt.code, tt.rate).Convert(tt.amount)
+		if got.Amount != tt.want || got.Currency != tt.code {
+			t.Errorf("Convert(%d) to %s at %s = %d %s, want %d", tt.amount, tt.code, tt.rate, got.Amount, got.Currency, tt.want)
+		}
+	}
+}
+
+func TestQuoteToBase(t *testing.T) {
+	tests := []struct {
+		code, rate string
+		minor      int64
+		want       Amount
+	}{
+		{code: "USD", rate: "1", minor: 1999, want: 1999},
+		{code: "EUR", rate: "0.9215", minor: 1842, want: 1999},
+		{code: "JPY", rate: "150", minor: 2999, want: 1999},
+		// 1 yen at 200 per dollar is half a cent
+		{code: "JPY", rate: "200", minor: 1, want: 1},
+		{code: "JPY", rate: "200", minor: -1, want: -1},
+		{code: "KWD", rate: "0.3075", minor: 6147, want: 1999},
+	}
+	for _, tt := range tests {
+		if got := mustQuote(t, tt.code, tt.rate).ToBase(tt.minor); got != tt.want {
+			t.Errorf("ToBase(%d) from %s at %s = %d, want %d", tt.minor, tt.code, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalBSONValue(t *testing.T) {
+	double := func(f float64) []byte {
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(f))
+	}
+	tests := []struct {
+		name    string
+		typ     bson.Type
+		data    []byte
+		want    Amount
+		wantErr bool
+	}{
+		{name: "int64", typ: bson.TypeInt64, data: binary.LittleEndian.AppendUint64(nil, 1999), want: 1999},
+		{name: "int32", typ: bson.TypeInt32, data: binary.LittleEndian.AppendUint32(nil, uint32(0xFFFFFFFB)), want: -5},
+		{name: "null", typ: bson.TypeNull, want: 0},
+		// Legacy prices were doubles in major units, binary fractions are rounded to the nearest cent
+		{name: "legacy double", typ: bson.TypeDouble, data: double(19.99), want: 1999},
+		{name: "legacy double below the cent", typ: bson.TypeDouble, data: double(0.29), want: 29},
+		{name: "legacy negative double", typ: bson.TypeDouble, data: double(-4.35), want: -435},
+		{name: "legacy whole double", typ: bson.TypeDouble, data: double(100), want: 10000},
+		{name: "short int64", typ: bson.TypeInt64, data: []byte{1, 2}, wantErr: true},
+		{name: "short double", typ: bson.TypeDouble, data: []byte{1}, wantErr: true},
+		{name: "string", typ: bson.TypeString, data: []byte("19.99"), wantErr: true},
+	}
+	for _, tt := range tests {
+		var a Amount
+		err := a.UnmarshalBSONValue(byte(tt.typ), tt.data)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("%s: error = %v, want ErrInvalidAmount", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || a != tt.want {
+			t.Errorf("%s: got %d, %v, want %d", tt.name, a, err, tt.want)
+		}
+	}
+}
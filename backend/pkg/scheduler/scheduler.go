@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a unit of periodic background work
+type Job func(ctx context.Context) error
+
+type Scheduler struct {
+	l  *zap.SugaredLogger
+	wg sync.WaitGroup
+}
+
+func New(l *zap.SugaredLogger) *Scheduler {
+	return &Scheduler{l: l}
+}
+
+// Every runs job on each tick of interval until ctx is cancelled
+func (s *Scheduler) Every(ctx context.Context, name string, interval time.Duration, job Job) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.l.Infow("Scheduled job started", "job", name, "interval", interval.String())
+		for {
+			select {
+			case <-ctx.Done():
+				s.l.Infow("Scheduled job stopped", "job", name)
+				return
+			case <-ticker.C:
+				start := time.Now()
+				if err := job(ctx); err != nil {
+					s.l.Errorw("Scheduled job failed", "job", name, "error", err)
+					continue
+				}
+				s.l.Debugw("Scheduled job finished", "job", name, "duration", time.Since(start).String())
+			}
+		}
+	}()
+}
+
+// Once runs job a single time in the background, e.g. to warm up what a periodic job maintains
+func (s *Scheduler) Once(ctx context.Context, name string, job Job) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		start := time.Now()
+		if err := job(ctx); err != nil {
+			s.l.Errorw("Job failed", "job", name, "error", err)
+			return
+		}
+		s.l.Debugw("Job finished", "job", name, "duration", time.Since(start).String())
+	}()
+}
+
+// Wait blocks until every job has returned after its context was cancelled
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
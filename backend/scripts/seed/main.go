@@ -10,6 +10,7 @@ import (
 	
 	"github.com/m4rk1sov/ecommerce/config"
 	"github.com/m4rk1sov/ecommerce/internal/entity"
+	"github.com/m4rk1sov/ecommerce/pkg/money"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/crypto/bcrypt"
@@ -42,6 +43,7 @@ func main() {
 	// Initialize repositories
 	userRepo := mongoRepo.NewUserRepository(db)
 	productRepo := mongoRepo.NewProductRepository(db)
+	categoryRepo := mongoRepo.NewCategoryRepository(db)
 	
 	log.Println("Starting database seeding...")
 	start := time.Now()
@@ -49,6 +51,10 @@ func main() {
 	users := seedUsers(ctx, userRepo)
 	log.Printf("✅ Created %d users\n", len(users))
 	
+	// Seed categories
+	categories := seedCategories(ctx, categoryRepo)
+	log.Printf("✅ Created %d categories\n", len(categories))
+	
 	// Seed products
 	products := seedProducts(ctx, productRepo)
 	log.Printf("✅ Created %d products\n", len(products))
@@ -75,7 +81,7 @@ func seedUsers(ctx context.Context, repo *mongoRepo.UserRepository) []*entity.Us
 			LastName:     fmt.Sprintf("Last%d", i),
 			Preferences: entity.UserPreferences{
 				Categories: []string{},
-				PriceRange: entity.PriceRange{Min: 0, Max: 1000},
+				PriceRange: entity.PriceRange{Min: 0, Max: 100000},
 			},
 		}
 		
@@ -90,8 +96,31 @@ func seedUsers(ctx context.Context, repo *mongoRepo.UserRepository) []*entity.Us
 	return users
 }
 
+var seedCategoryNames = []string{"Electronics", "Clothing", "Books", "Home & Garden", "Sports", "Toys", "Health and Beauty", "Accessories"}
+
+func seedCategories(ctx context.Context, repo *mongoRepo.CategoryRepository) []*entity.Category {
+	categories := make([]*entity.Category, 0, len(seedCategoryNames))
+	
+	for i, name := range seedCategoryNames {
+		category := &entity.Category{
+			Name:  name,
+			Slug:  strings.Trim(strings.NewReplacer(" & ", "-", " ", "-").Replace(strings.ToLower(name)), "-"),
+			Order: i,
+		}
+		
+		if err := repo.Create(ctx, category); err != nil {
+			log.Printf("Failed to create category %s: %v\n", name, err)
+			continue
+		}
+		
+		categories = append(categories, category)
+	}
+	
+	return categories
+}
+
 func seedProducts(ctx context.Context, repo *mongoRepo.ProductRepository) []*entity.Product {
-	categories := []string{"Electronics", "Clothing", "Books", "Home & Garden", "Sports", "Toys", "Health and Beauty", "Accessories"}
+	categories := seedCategoryNames
 	
 	products := make([]*entity.Product, 0, 50)
 	
@@ -139,7 +168,7 @@ func seedProducts(ctx context.Context, repo *mongoRepo.ProductRepository) []*ent
 		templates := productTemplates[category]
 		
 		for _, name := range templates {
-			basePrice := float64(rand.Intn(1000)) + 9.99
+			basePrice := money.Amount(rand.Intn(1000)*100 + 999)
 			
 			product := &entity.Product{
 				Name:        name,